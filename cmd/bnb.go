@@ -16,12 +16,17 @@ limitations under the License.
 package cmd
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io/ioutil"
-	"log"
+	"net"
 	"os"
+	"os/signal"
+	"strings"
 	"sync"
+	"syscall"
 	"time"
 
 	"github.com/davidkleiven/goselect/featselect"
@@ -32,35 +37,142 @@ import (
 var bnbCmd = &cobra.Command{
 	Use:   "bnb",
 	Short: "Optimized models via branch and bound",
-	Long: `Minimizes AICc by branch and bound.
+	Long: `Minimizes the criterion given by --criterion (AICc by default) by branch and bound.
 	
 Example:
-goselect bnb -csv mydataset.csv -target -1 -out result.json
+goselect bnb -csv mydataset.csv -target -1 -out result.json -criterion bic
+
+The search can be distributed over several processes. The coordinator owns the queue and
+is started with --listen. Workers are started with --worker and the same data, criterion,
+cutoff and constraints as the coordinator:
+goselect bnb -csv mydataset.csv -listen :7000
+goselect bnb -csv mydataset.csv -worker coordinatorhost:7000
 	`,
-	Run: func(cmd *cobra.Command, args []string) {
-		csvfile, err := cmd.Flags().GetString("csv")
+	SilenceUsage: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		outfile, _ := cmd.Flags().GetString("out")
+		critName, _ := cmd.Flags().GetString("criterion")
+		listen, _ := cmd.Flags().GetString("listen")
+		worker, _ := cmd.Flags().GetString("worker")
+
+		if listen != "" && worker != "" {
+			return fmt.Errorf("listen and worker can not be combined")
+		}
+
+		var coordinator *coordinatorConfig
+		if listen != "" {
+			opts := featselect.NewDistributedOptParams()
+			opts.NodesPerUnit, _ = cmd.Flags().GetInt("nodes-per-unit")
+			coordinator = &coordinatorConfig{addr: listen, opts: opts}
+		}
+
+		params, err := bnbParams(cmd)
 		if err != nil {
-			log.Print(err)
-			return
+			return err
 		}
 
-		target, _ := cmd.Flags().GetInt("target")
-		cutoff, _ := cmd.Flags().GetFloat64("cutoff")
-		outfile, _ := cmd.Flags().GetString("out")
-		maxQueue, _ := cmd.Flags().GetInt("maxqueue")
+		if stream, _ := cmd.Flags().GetBool("stream"); stream {
+			if holdout, _ := cmd.Flags().GetFloat64("holdout"); holdout > 0.0 {
+				return fmt.Errorf("holdout can not be combined with stream")
+			}
+
+			stats, err := readStats(cmd)
+			if err != nil {
+				return err
+			}
+			if _, nFeat := stats.Dims(); !resumable(params, nFeat) {
+				return errNotResumable(params, nFeat)
+			}
+
+			params.Constraints, err = readConstraints(cmd, stats.FeatureNames(), nil, nil)
+			if err != nil {
+				return err
+			}
+
+			if worker != "" {
+				return runWorker(worker, func(ctx context.Context) error {
+					return featselect.RunWorkerFromStats(ctx, worker, stats, params)
+				})
+			}
+			_, err = findOptimalSolutionStats(stats, params, outfile, coordinator)
+			return err
+		}
+
+		dset, err := readDataset(cmd, false)
+		if err != nil {
+			return err
+		}
+
+		dset, basis, err := expandDataset(cmd, dset)
+		if err != nil {
+			return err
+		}
+
+		train, test, err := holdoutSplit(cmd, dset)
+		if err != nil {
+			return err
+		}
+
+		if _, nFeat := train.X.Dims(); !resumable(params, nFeat) {
+			return errNotResumable(params, nFeat)
+		}
+
+		params.Constraints, err = readConstraints(cmd, train.FeatureNames(), basis, train.Categorical)
+		if err != nil {
+			return err
+		}
 
-		findOptimalSolution(csvfile, target, cutoff, outfile, maxQueue)
+		if worker != "" {
+			params.Weights = train.Weights
+			return runWorker(worker, func(ctx context.Context) error {
+				return featselect.RunWorker(ctx, worker, train.X, train.Y, params)
+			})
+		}
+
+		highscore, err := findOptimalSolution(train, params, outfile, coordinator)
+		if err != nil || test == nil {
+			return err
+		}
+
+		models := []holdoutModel{}
+		for item := highscore.Items.Front(); item != nil; item = item.Next() {
+			node := item.Value.(*featselect.Node)
+			if node.Model.NumFeatures() == 0 {
+				continue
+			}
+			model, err := refitHoldoutModel(train, node.Model.Selected(), -node.Score)
+			if err != nil {
+				return err
+			}
+			models = append(models, model)
+		}
+		printHoldout(test, models, strings.ToUpper(critName))
+		return nil
 	},
 }
 
 func init() {
 	rootCmd.AddCommand(bnbCmd)
 
-	bnbCmd.Flags().String("csv", "", "CSV file containing the data")
-	bnbCmd.Flags().Int("target", -1, "Column in the CSV file with the target data. If negative it wraps around.")
+	bnbCmd.Flags().String("csv", "", "CSV file containing the data. Files ending with .npy or .npz are read as NumPy arrays")
+	bnbCmd.Flags().String("target", "-1", "Name or index of the column in the CSV file with the target data. If negative it wraps around.")
 	bnbCmd.Flags().String("out", "bnbSearch.json", "Outfile for the search")
 	bnbCmd.Flags().Float64("cutoff", 0.0, "Cutoff that will be added to the cost function when when branches are pruned")
 	bnbCmd.Flags().Int("maxqueue", 10000000, "Maximum size of the queue. If this limit is reached, subtrees will be removed. If you run out of memory, this number should be lowered.")
+	bnbCmd.Flags().String("max-memory", "", "Maximum memory used by the queue (e.g. 16GB or 512MiB). If this limit is reached, the least promising subtrees are removed")
+	bnbCmd.Flags().String("criterion", "aicc", "Selection criterion that is minimized |aic|aicc|bic|cp|")
+	bnbCmd.Flags().String("strategy", "breadth", "Order in which the nodes are explored |breadth|depth|best|")
+	bnbCmd.Flags().String("checkpoint", "", "File where the state of the search is written periodically and when the search is interrupted (SIGINT/SIGTERM)")
+	bnbCmd.Flags().Duration("checkpoint-interval", 10*time.Minute, "Time between checkpoints")
+	bnbCmd.Flags().String("resume", "", "Continue the search from this checkpoint file. Unless --checkpoint is given, new checkpoints are written to the same file")
+	bnbCmd.Flags().Bool("stream", false, "Read the CSV file in chunks and keep only the sufficient statistics (XᵀX, Xᵀy, yᵀy) in memory")
+	bnbCmd.Flags().String("listen", "", "Coordinate a distributed search, and listen for workers on this address (e.g. :7000)")
+	bnbCmd.Flags().String("worker", "", "Run as a worker for the coordinator at this address (e.g. coordinatorhost:7000)")
+	bnbCmd.Flags().Int("nodes-per-unit", 10000, "Number of nodes a worker scores before the unexplored nodes are sent back to the coordinator")
+	addDatasetFlags(bnbCmd)
+	addHoldoutFlags(bnbCmd)
+	addExpansionFlags(bnbCmd)
+	addConstraintFlags(bnbCmd)
 }
 
 func saveHighscoreList(fname string, h *featselect.Highscore) {
@@ -75,11 +187,88 @@ func setSearchFinished(finished chan int) {
 	finished <- 0
 }
 
-func findOptimalSolution(csvfile string, targetCol int, cutoff float64, outfile string, maxQueueSize int) {
-	dset := featselect.ReadCSV(csvfile, targetCol)
+// bnbParams returns the search parameters given by the flags. If a checkpoint file is
+// given, the search is interrupted when SIGINT or SIGTERM is received
+func bnbParams(cmd *cobra.Command) (*featselect.SelectModelOptParams, error) {
+	cutoff, _ := cmd.Flags().GetFloat64("cutoff")
+	maxQueue, _ := cmd.Flags().GetInt("maxqueue")
+	maxMemory, _ := cmd.Flags().GetString("max-memory")
+	critName, _ := cmd.Flags().GetString("criterion")
+	strategy, _ := cmd.Flags().GetString("strategy")
+	checkpoint, _ := cmd.Flags().GetString("checkpoint")
+	interval, _ := cmd.Flags().GetDuration("checkpoint-interval")
+	resume, _ := cmd.Flags().GetString("resume")
+
 	params := featselect.NewSelectModelOptParams()
 	params.Cutoff = cutoff
-	params.MaxQueueSize = maxQueueSize
+	params.MaxQueueSize = maxQueue
+
+	var err error
+	params.Criterion, err = featselect.ParseCriterion(critName)
+	if err != nil {
+		return nil, err
+	}
+
+	params.Strategy, err = featselect.ParseSearchStrategy(strategy)
+	if err != nil {
+		return nil, err
+	}
+
+	if maxMemory != "" {
+		params.MaxMemory, err = featselect.ParseMemorySize(maxMemory)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if resume != "" {
+		params.Resume, err = featselect.ReadCheckpoint(resume)
+		if err != nil {
+			return nil, err
+		}
+
+		if checkpoint == "" {
+			checkpoint = resume
+		}
+		fmt.Printf("Resuming search from %s (%d nodes in queue)\n", resume, len(params.Resume.Queue))
+	}
+
+	if checkpoint != "" {
+		params.CheckpointFile = checkpoint
+		params.CheckpointInterval = interval
+		params.Interrupt = interruptOnSignal()
+	}
+	return params, nil
+}
+
+// resumable returns false if the search can not be resumed from the checkpoint in
+// params with the given number of features
+func resumable(params *featselect.SelectModelOptParams, nFeat int) bool {
+	return params.Resume == nil || params.Resume.NumFeatures == nFeat
+}
+
+// errNotResumable returns the error reported when resumable is false
+func errNotResumable(params *featselect.SelectModelOptParams, nFeat int) error {
+	return fmt.Errorf("the checkpoint has %d features, but the dataset has %d: %w", params.Resume.NumFeatures, nFeat, featselect.ErrDimensionMismatch)
+}
+
+// interruptOnSignal returns a channel that is closed when SIGINT or SIGTERM is received
+func interruptOnSignal() <-chan struct{} {
+	sigs := make(chan os.Signal, 1)
+	signal.Notify(sigs, os.Interrupt, syscall.SIGTERM)
+
+	interrupt := make(chan struct{})
+	go func() {
+		<-sigs
+		signal.Stop(sigs)
+		fmt.Printf("Received interrupt. Writing checkpoint and stopping the search\n")
+		close(interrupt)
+	}()
+	return interrupt
+}
+
+func findOptimalSolution(dset *featselect.Dataset, params *featselect.SelectModelOptParams, outfile string, coordinator *coordinatorConfig) (*featselect.Highscore, error) {
+	params.Weights = dset.Weights
 
 	num := 10
 	if len(dset.Y) < num {
@@ -87,22 +276,78 @@ func findOptimalSolution(csvfile string, targetCol int, cutoff float64, outfile
 	}
 	fmt.Printf("First few items of target column\n%v\n", dset.Y[:num])
 
+	if cost, ok := warmStartCost(params); ok {
+		// Get a good initial model from SA
+		fmt.Printf("Searching for good initial model with SA\n")
+		X, y := featselect.WeightRows(dset.X, dset.Y, dset.Weights)
+		res, err := featselect.SelectModelSAConstrained(X, y, 100, cost, params.Constraints)
+		if err != nil {
+			return nil, err
+		}
+		_, nFeat := dset.X.Dims()
+		params.RootModel = featselect.Selected2Model(res.Selected, nFeat)
+	}
+	return runBnbSearch(outfile, dset.FeatureNames(), func(highscore *featselect.Highscore, progress *featselect.SearchProgress) error {
+		if coordinator != nil {
+			_, nFeat := dset.X.Dims()
+			return coordinator.serve(nFeat, highscore, progress, params)
+		}
+		return featselect.SelectModelContext(context.Background(), dset.X, dset.Y, highscore, progress, params)
+	})
+}
+
+func findOptimalSolutionStats(stats *featselect.SufficientStats, params *featselect.SelectModelOptParams, outfile string, coordinator *coordinatorConfig) (*featselect.Highscore, error) {
+	fmt.Printf("Accumulated statistics of %d rows\n", stats.NumRows)
+
+	if cost, ok := warmStartCost(params); ok {
+		// Get a good initial model from SA
+		fmt.Printf("Searching for good initial model with SA\n")
+		res, err := featselect.SelectModelSAConstrainedFromStats(stats, 100, cost, params.Constraints)
+		if err != nil {
+			return nil, err
+		}
+		_, nFeat := stats.Dims()
+		params.RootModel = featselect.Selected2Model(res.Selected, nFeat)
+	}
+	return runBnbSearch(outfile, stats.FeatureNames(), func(highscore *featselect.Highscore, progress *featselect.SearchProgress) error {
+		if coordinator != nil {
+			_, nFeat := stats.Dims()
+			return coordinator.serve(nFeat, highscore, progress, params)
+		}
+		return featselect.SelectModelFromStatsContext(context.Background(), stats, highscore, progress, params)
+	})
+}
+
+// warmStartCost returns the cost function of the SA search for the initial model. No
+// initial model is searched for when the search is resumed, or for Mallows' Cp, which
+// depends on the noise variance that is estimated when the search starts
+func warmStartCost(params *featselect.SelectModelOptParams) (func(int, int, float64) float64, bool) {
+	if params.Resume != nil {
+		return nil, false
+	}
+
+	if f, ok := params.Criterion.(featselect.CritFunc); ok {
+		return f, true
+	}
+	return nil, false
+}
+
+// runBnbSearch runs the search in a separate go-routine and saves the highscore list periodically.
+// The feature names are written together with each model. An interrupted search is not an error,
+// since the state is written to the checkpoint file
+func runBnbSearch(outfile string, names []string, search func(*featselect.Highscore, *featselect.SearchProgress) error) (*featselect.Highscore, error) {
 	var wg sync.WaitGroup
+	var searchErr error
 	var progress featselect.SearchProgress
 	searchFinished := make(chan int)
 	highscore := featselect.NewHighscore(10)
+	highscore.Names = names
 
-	// Get a good initial model from SA
-	fmt.Printf("Searching for good initial model with SA\n")
-	res := featselect.SelectModelSA(dset.X, dset.Y, 100, featselect.Aicc)
-	_, nFeat := dset.X.Dims()
-
-	params.RootModel = featselect.Selected2Model(res.Selected, nFeat)
 	wg.Add(1)
 	go func() {
 		defer wg.Done()
 		defer setSearchFinished(searchFinished)
-		featselect.SelectModel(dset.X, dset.Y, highscore, &progress, params)
+		searchErr = search(highscore, &progress)
 	}()
 
 	c := time.Tick(60 * time.Second)
@@ -113,13 +358,80 @@ timeloop:
 		select {
 		case <-c:
 			score, numChecked, log2Pruned := progress.Get()
-			fmt.Printf("%v: Score: %f, Num. checked: %d, Log2 pruned: %f\n", time.Now().Format(time.RFC3339), score, numChecked, log2Pruned)
+			fmt.Printf("%v: Score: %f, Num. checked: %d, Log2 pruned: %f", time.Now().Format(time.RFC3339), score, numChecked, log2Pruned)
+			if numWorkers := progress.GetNumWorkers(); numWorkers > 0 {
+				fmt.Printf(", Active workers: %d", numWorkers)
+			}
+			fmt.Printf("\n")
 			saveHighscoreList(outfile, highscore)
 		case <-searchFinished:
 			break timeloop
 		}
 	}
-	saveHighscoreList(outfile, highscore)
 	wg.Wait()
+	if searchErr != nil && !errors.Is(searchErr, featselect.ErrInterrupted) {
+		return nil, searchErr
+	}
+	saveHighscoreList(outfile, highscore)
 	fmt.Printf("Selection finished\n")
+	printEviction(progress.GetEvicted(), -highscore.BestScore())
+	return highscore, nil
+}
+
+// coordinatorConfig holds the settings of a distributed search
+type coordinatorConfig struct {
+	addr string
+	opts *featselect.DistributedOptParams
+}
+
+// serve coordinates a distributed search, where the workers connect to addr
+func (c *coordinatorConfig) serve(numFeatures int, highscore *featselect.Highscore, progress *featselect.SearchProgress, params *featselect.SelectModelOptParams) error {
+	coordinator, err := featselect.NewCoordinator(numFeatures, highscore, progress, params, c.opts)
+	if err != nil {
+		return err
+	}
+
+	listener, err := net.Listen("tcp", c.addr)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Waiting for workers on %s\n", listener.Addr())
+	return coordinator.Serve(context.Background(), listener)
+}
+
+// runWorker runs a worker until the search is finished or SIGINT or SIGTERM is received
+func runWorker(addr string, work func(ctx context.Context) error) error {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	interrupt := interruptOnSignal()
+	go func() {
+		select {
+		case <-interrupt:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+
+	fmt.Printf("Working for the coordinator at %s\n", addr)
+	if err := work(ctx); err != nil && !errors.Is(err, context.Canceled) {
+		return err
+	}
+	fmt.Printf("Worker finished\n")
+	return nil
+}
+
+// printEviction reports the nodes that were removed from the queue without being explored,
+// and whether they could have contained a model better than best
+func printEviction(e featselect.Eviction, best float64) {
+	if e.NumNodes == 0 {
+		return
+	}
+
+	fmt.Printf("%d nodes were evicted from the queue. Their lower bounds are in [%e, %e]\n", e.NumNodes, e.MinLower, e.MaxLower)
+	if e.Optimal(best) {
+		fmt.Printf("All lower bounds are above the best score (%e), the result is still optimal\n", best)
+	} else {
+		fmt.Printf("WARNING: Evicted nodes may contain models with a score down to %e (best: %e). The result may not be optimal\n", e.MinLower, best)
+	}
 }
@@ -0,0 +1,116 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/davidkleiven/goselect/featselect"
+	"github.com/spf13/cobra"
+)
+
+// addConstraintFlags adds the flags that restrict the models considered by the search
+func addConstraintFlags(cmd *cobra.Command) {
+	cmd.Flags().StringSlice("force-in", nil, "Comma separated list of features that are part of every model")
+	cmd.Flags().StringSlice("force-out", nil, "Comma separated list of features that are never selected")
+	cmd.Flags().Int("min-features", 0, "Minimum number of selected features")
+	cmd.Flags().Int("max-features", 0, "Maximum number of selected features. If zero, there is no limit")
+	cmd.Flags().StringSlice("exclusive", nil, "Groups of features where at most one can be selected. Features in a group are separated by +, and groups by comma (e.g. a+b,c+d+e)")
+	cmd.Flags().String("categorical-groups", "whole", "How the columns of a categorical variable are selected |whole|exclusive|free|. With whole, all or none of the columns are selected, and with exclusive at most one")
+	cmd.Flags().String("heredity", "", "Only select power and interaction terms together with the terms they are formed from |strong|weak|. With strong heredity, a*b requires a and b, and with weak heredity a or b")
+}
+
+// constraintsRequested returns true if any of the constraint flags are given
+func constraintsRequested(cmd *cobra.Command) bool {
+	for _, name := range []string{"force-in", "force-out", "min-features", "max-features", "exclusive", "heredity"} {
+		if cmd.Flags().Changed(name) {
+			return true
+		}
+	}
+	return false
+}
+
+// readConstraints returns the constraints given by the flags, where the feature names are
+// resolved in names. The heredity is derived from the basis set of the feature expansion
+// (nil if the features are not expanded), and the columns of each categorical group are
+// constrained as given by the categorical-groups flag. If no constraints are given, nil
+// is returned
+func readConstraints(cmd *cobra.Command, names []string, basis *featselect.BasisSet, groups []featselect.CategoricalGroup) (*featselect.Constraints, error) {
+	catGroups, _ := cmd.Flags().GetString("categorical-groups")
+	if catGroups != "whole" && catGroups != "exclusive" && catGroups != "free" {
+		return nil, fmt.Errorf("unknown categorical groups %s", catGroups)
+	}
+
+	if catGroups == "free" {
+		groups = nil
+	}
+
+	if !constraintsRequested(cmd) && len(groups) == 0 {
+		return nil, nil
+	}
+	forceIn, _ := cmd.Flags().GetStringSlice("force-in")
+	forceOut, _ := cmd.Flags().GetStringSlice("force-out")
+	minFeat, _ := cmd.Flags().GetInt("min-features")
+	maxFeat, _ := cmd.Flags().GetInt("max-features")
+	exclusive, _ := cmd.Flags().GetStringSlice("exclusive")
+	heredity, _ := cmd.Flags().GetString("heredity")
+
+	var c featselect.Constraints
+	var err error
+	c.MinFeatures = minFeat
+	c.MaxFeatures = maxFeat
+	c.Include, err = featureNumbers(forceIn, names)
+	if err != nil {
+		return nil, err
+	}
+
+	c.Exclude, err = featureNumbers(forceOut, names)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, group := range exclusive {
+		features, err := featureNumbers(strings.Split(group, "+"), names)
+		if err != nil {
+			return nil, err
+		}
+		c.Exclusive = append(c.Exclusive, features)
+	}
+
+	for _, g := range groups {
+		if catGroups == "whole" {
+			c.Together = append(c.Together, g.Features)
+		} else {
+			c.Exclusive = append(c.Exclusive, g.Features)
+		}
+	}
+
+	switch heredity {
+	case "":
+	case "strong", "weak":
+		if basis == nil {
+			return nil, fmt.Errorf("heredity requires expanded features (poly-degree or interactions)")
+		}
+		c.Heredity = basis.Heredity(heredity == "strong")
+	default:
+		return nil, fmt.Errorf("unknown heredity %s", heredity)
+	}
+
+	if err := c.Validate(len(names)); err != nil {
+		return nil, err
+	}
+	return &c, nil
+}
+
+// featureNumbers returns the position of each feature in names
+func featureNumbers(features []string, names []string) ([]int, error) {
+	dset := featselect.Dataset{Names: names, TargetCol: len(names)}
+	numbers := make([]int, len(features))
+	for i, f := range features {
+		n, err := dset.TryFeatNoByName(strings.TrimSpace(f))
+		if err != nil {
+			return nil, err
+		}
+		numbers[i] = n
+	}
+	return numbers, nil
+}
@@ -0,0 +1,206 @@
+package cmd
+
+import (
+	"fmt"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/davidkleiven/goselect/featselect"
+	"github.com/spf13/cobra"
+)
+
+// addDatasetFlags adds the flags that control how the dataset is read
+func addDatasetFlags(cmd *cobra.Command) {
+	cmd.Flags().String("missing", "error", "How to handle missing values |error|drop|mean|median|indicator|")
+	addReadFlags(cmd)
+}
+
+// addReadFlags adds the flags that control how the dataset is read, except the missing
+// value policy
+func addReadFlags(cmd *cobra.Command) {
+	cmd.Flags().String("delimiter", "auto", "Column delimiter |auto|,|;|tab|whitespace|. If auto, it is detected from the header")
+	cmd.Flags().StringSlice("categorical", nil, "Comma separated list of categorical columns")
+	cmd.Flags().Bool("auto-categorical", false, "Treat all columns with values that are not numbers as categorical")
+	cmd.Flags().String("encoding", "onehot", "Encoding of categorical columns |onehot|dummy|")
+	cmd.Flags().String("weights", "", "Name of the column with observation weights. If empty, all observations have equal weight")
+	cmd.Flags().StringSlice("include", nil, "Comma separated list of features (or glob patterns) to use. If empty, all columns except the target are used")
+	cmd.Flags().StringSlice("exclude", nil, "Comma separated list of features (or glob patterns) that should not be used")
+}
+
+// addExpansionFlags adds the flags that control the expansion of the features
+func addExpansionFlags(cmd *cobra.Command) {
+	cmd.Flags().Int("poly-degree", 1, "Add powers of each feature up to this degree as new features (e.g. a^2, a^3)")
+	cmd.Flags().Bool("interactions", false, "Add products of different features (e.g. a*b) up to the polynomial degree (at least 2) as new features")
+	cmd.Flags().StringSlice("transforms", nil, "Comma separated list of transforms applied to each feature |log|exp|sqrt|inv|abs|ratio|")
+	cmd.Flags().String("basis", "", "JSON file where the definition of the expanded features is written, such that it can be applied to new data")
+}
+
+// expansionRequested returns true if the features should be expanded
+func expansionRequested(cmd *cobra.Command) bool {
+	degree, _ := cmd.Flags().GetInt("poly-degree")
+	interactions, _ := cmd.Flags().GetBool("interactions")
+	transforms, _ := cmd.Flags().GetStringSlice("transforms")
+	return degree > 1 || interactions || len(transforms) > 0
+}
+
+// expandDataset adds the polynomial, interaction and transformed features given by the
+// expansion flags. The returned basis set is nil if no features are added
+func expandDataset(cmd *cobra.Command, dset *featselect.Dataset) (*featselect.Dataset, *featselect.BasisSet, error) {
+	if !expansionRequested(cmd) {
+		return dset, nil, nil
+	}
+	degree, _ := cmd.Flags().GetInt("poly-degree")
+	interactions, _ := cmd.Flags().GetBool("interactions")
+	transforms, _ := cmd.Flags().GetStringSlice("transforms")
+	basisFile, _ := cmd.Flags().GetString("basis")
+
+	terms, err := dset.TransformTerms(transforms)
+	if err != nil {
+		return nil, nil, err
+	}
+	terms = append(dset.PolynomialTerms(degree, interactions), terms...)
+
+	expanded, basis, err := dset.Expand(terms)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	_, before := dset.X.Dims()
+	_, after := expanded.X.Dims()
+	fmt.Printf("Expanded %d features to %d\n", before, after)
+
+	if basisFile != "" {
+		if err := basis.Save(basisFile); err != nil {
+			return nil, nil, err
+		}
+		fmt.Printf("Feature expansion written to %s\n", basisFile)
+	}
+	return expanded, basis, nil
+}
+
+// readDataset reads the dataset given by the csv, target and the dataset flags. Files
+// with extension .npy and .npz are read as NumPy arrays. If allowKeep is true, missing
+// values can be kept as NaN
+func readDataset(cmd *cobra.Command, allowKeep bool) (*featselect.Dataset, error) {
+	csvfile, _ := cmd.Flags().GetString("csv")
+	include, _ := cmd.Flags().GetStringSlice("include")
+	exclude, _ := cmd.Flags().GetStringSlice("exclude")
+
+	params, targetCol, err := csvParams(cmd, allowKeep)
+	if err != nil {
+		return nil, err
+	}
+
+	if ext := strings.ToLower(filepath.Ext(csvfile)); ext == ".npy" || ext == ".npz" {
+		dset, err := readNumpyDataset(csvfile, targetCol, params.TargetName)
+		if err != nil {
+			return nil, err
+		}
+		return selectFeatures(dset, include, exclude)
+	}
+
+	dset, err := featselect.ReadCSVWithParams(csvfile, targetCol, params)
+	if err != nil {
+		return nil, err
+	}
+	return selectFeatures(dset, include, exclude)
+}
+
+// readStats streams the CSV file given by the csv flag and returns the sufficient
+// statistics. Feature selection with include and exclude is not supported
+func readStats(cmd *cobra.Command) (*featselect.SufficientStats, error) {
+	csvfile, _ := cmd.Flags().GetString("csv")
+	include, _ := cmd.Flags().GetStringSlice("include")
+	exclude, _ := cmd.Flags().GetStringSlice("exclude")
+
+	if len(include) > 0 || len(exclude) > 0 {
+		return nil, fmt.Errorf("include and exclude can not be combined with stream")
+	}
+
+	if expansionRequested(cmd) {
+		return nil, fmt.Errorf("poly-degree, interactions and transforms can not be combined with stream")
+	}
+
+	params, targetCol, err := csvParams(cmd, false)
+	if err != nil {
+		return nil, err
+	}
+	return featselect.ReadCSVStats(csvfile, targetCol, params)
+}
+
+// csvParams returns the parameters and the target column given by the target and dataset flags
+func csvParams(cmd *cobra.Command, allowKeep bool) (*featselect.CSVOptParams, int, error) {
+	target, _ := cmd.Flags().GetString("target")
+	delimiter, _ := cmd.Flags().GetString("delimiter")
+	missing, _ := cmd.Flags().GetString("missing")
+	categorical, _ := cmd.Flags().GetStringSlice("categorical")
+	autoCat, _ := cmd.Flags().GetBool("auto-categorical")
+	encoding, _ := cmd.Flags().GetString("encoding")
+	weights, _ := cmd.Flags().GetString("weights")
+
+	params := featselect.NewCSVOptParams()
+	targetCol, err := strconv.Atoi(target)
+	if err != nil {
+		params.TargetName = target
+	}
+
+	params.Delimiter, err = featselect.ParseDelimiter(delimiter)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	params.Missing, err = missingPolicy(missing, allowKeep)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	params.Encoding, err = featselect.ParseCategoricalEncoding(encoding)
+	if err != nil {
+		return nil, 0, err
+	}
+	params.Categorical = categorical
+	params.AutoCategorical = autoCat
+	params.WeightName = weights
+	return params, targetCol, nil
+}
+
+// missingPolicy returns the missing value policy with the given name. Missing values are
+// only kept (as NaN) if allowKeep is true, since the fitting routines can not handle them
+func missingPolicy(name string, allowKeep bool) (featselect.MissingPolicy, error) {
+	if allowKeep && strings.ToLower(name) == "keep" {
+		return featselect.MissingKeep, nil
+	}
+	return featselect.ParseMissingPolicy(name)
+}
+
+// readNumpyDataset reads a dataset from a .npy or .npz file. A npz archive stores
+// its own target column, which is only changed if targetName is given
+func readNumpyDataset(fname string, targetCol int, targetName string) (*featselect.Dataset, error) {
+	if strings.ToLower(filepath.Ext(fname)) == ".npy" {
+		if targetName != "" {
+			return nil, fmt.Errorf("columns in a npy file have no names, the target must be given as an index")
+		}
+		return featselect.ReadNpy(fname, targetCol)
+	}
+
+	dset, err := featselect.ReadNpz(fname)
+	if err != nil {
+		return nil, err
+	}
+
+	if targetName != "" {
+		if err := dset.SetTargetByName(targetName); err != nil {
+			return nil, err
+		}
+	}
+	return dset, nil
+}
+
+// selectFeatures applies the include and exclude patterns
+func selectFeatures(dset *featselect.Dataset, include []string, exclude []string) (*featselect.Dataset, error) {
+	if len(include) == 0 && len(exclude) == 0 {
+		return dset, nil
+	}
+	return dset.SelectFeatures(include, exclude)
+}
@@ -0,0 +1,91 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+
+	"github.com/davidkleiven/goselect/featselect"
+	"github.com/spf13/cobra"
+)
+
+// describeCmd represents the describe command
+var describeCmd = &cobra.Command{
+	Use:   "describe",
+	Short: "Prints summary statistics of a dataset",
+	Long: `Prints the mean, standard deviation, min, max and number of missing values of each column,
+flags constant columns and lists the correlation of each feature with the target together with the
+most highly correlated feature pairs.
+
+Example:
+goselect describe --csv mydataset.csv --target -1
+	`,
+	SilenceUsage: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		dset, err := readDataset(cmd, true)
+		if err != nil {
+			return err
+		}
+
+		numPairs, _ := cmd.Flags().GetInt("pairs")
+		asJSON, _ := cmd.Flags().GetBool("json")
+
+		profile := dset.Describe(numPairs)
+		if asJSON {
+			js, err := json.MarshalIndent(profile, "", "  ")
+			if err != nil {
+				return err
+			}
+			fmt.Printf("%s\n", js)
+			return nil
+		}
+		printProfile(profile)
+		return nil
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(describeCmd)
+
+	describeCmd.Flags().String("csv", "", "CSV file containing the data. Files ending with .npy or .npz are read as NumPy arrays")
+	describeCmd.Flags().String("target", "-1", "Name or index of the column in the CSV file with the target data. If negative it wraps around.")
+	describeCmd.Flags().Int("pairs", 10, "Number of most highly correlated feature pairs to list")
+	describeCmd.Flags().Bool("json", false, "Print the profile as JSON instead of a table")
+	describeCmd.Flags().String("missing", "keep", "How to handle missing values |keep|error|drop|mean|median|indicator|. With keep, missing values are counted")
+	addReadFlags(describeCmd)
+}
+
+func printProfile(profile *featselect.DatasetProfile) {
+	fmt.Printf("Number of rows: %d\n", profile.NumRows)
+	fmt.Printf("-------------------------------------------------------------------------------------------------------------\n")
+	fmt.Printf("| %-24s | %11s | %11s | %11s | %11s | %7s | %5s | %8s |\n", "Name", "Mean", "Std", "Min", "Max", "Missing", "Const", "Corr(y)")
+	fmt.Printf("-------------------------------------------------------------------------------------------------------------\n")
+	for _, c := range append([]featselect.ColumnProfile{profile.Target}, profile.Features...) {
+		constant := ""
+		if c.Constant {
+			constant = "yes"
+		}
+		fmt.Printf("| %-24s | %11.4e | %11.4e | %11.4e | %11.4e | %7d | %5s | %8s |\n", c.Name, c.Mean, c.Std, c.Min, c.Max, c.Missing, constant, formatCorr(c.TargetCorrelation))
+	}
+	fmt.Printf("-------------------------------------------------------------------------------------------------------------\n")
+
+	if len(profile.TopPairs) == 0 {
+		return
+	}
+
+	fmt.Printf("\nMost highly correlated feature pairs\n")
+	fmt.Printf("-----------------------------------------------------------------\n")
+	fmt.Printf("| %-24s | %-24s | %7s |\n", "First", "Second", "Corr")
+	fmt.Printf("-----------------------------------------------------------------\n")
+	for _, p := range profile.TopPairs {
+		fmt.Printf("| %-24s | %-24s | %7.4f |\n", p.First, p.Second, p.Correlation)
+	}
+	fmt.Printf("-----------------------------------------------------------------\n")
+}
+
+func formatCorr(corr float64) string {
+	if math.IsNaN(corr) {
+		return "-"
+	}
+	return fmt.Sprintf("%8.4f", corr)
+}
@@ -0,0 +1,172 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"sort"
+	"strings"
+
+	"github.com/davidkleiven/goselect/featselect"
+	"github.com/spf13/cobra"
+)
+
+// gaCmd represents the ga command
+var gaCmd = &cobra.Command{
+	Use:   "ga",
+	Short: "Performs model selection with a genetic algorithm",
+	Long: `Evolves a population of models that minimize the criterion. In each generation, parents are
+chosen by tournaments, crossed (uniform or one-point crossover) and mutated, and the best models
+are copied unchanged to the next generation. The run is reproduced by passing the seed that is
+printed at the start.
+
+Example:
+goselect ga -csv mydatafile.csv -target -1 -out result.json -generations 200 -seed 42
+	`,
+	SilenceUsage: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		out, _ := cmd.Flags().GetString("out")
+		critName, _ := cmd.Flags().GetString("criterion")
+
+		params, err := gaParams(cmd)
+		if err != nil {
+			return err
+		}
+
+		if stream, _ := cmd.Flags().GetBool("stream"); stream {
+			if holdout, _ := cmd.Flags().GetFloat64("holdout"); holdout > 0.0 {
+				return fmt.Errorf("holdout can not be combined with stream")
+			}
+
+			stats, err := readStats(cmd)
+			if err != nil {
+				return err
+			}
+
+			params.Constraints, err = readConstraints(cmd, stats.FeatureNames(), nil, nil)
+			if err != nil {
+				return err
+			}
+
+			res, err := featselect.SelectModelGAFromStats(stats, params)
+			if err != nil {
+				return err
+			}
+			res.Scores.SetNames(stats.FeatureNames())
+			return saveGAResult(res, out)
+		}
+
+		dset, err := readDataset(cmd, false)
+		if err != nil {
+			return err
+		}
+
+		dset, basis, err := expandDataset(cmd, dset)
+		if err != nil {
+			return err
+		}
+
+		train, test, err := splitDataset(cmd, dset, params.Seed)
+		if err != nil {
+			return err
+		}
+
+		params.Constraints, err = readConstraints(cmd, train.FeatureNames(), basis, train.Categorical)
+		if err != nil {
+			return err
+		}
+
+		X, y := featselect.WeightRows(train.X, train.Y, train.Weights)
+		res, err := featselect.SelectModelGA(X, y, params)
+		if err != nil {
+			return err
+		}
+		res.Scores.SetNames(train.FeatureNames())
+		if err := saveGAResult(res, out); err != nil || test == nil {
+			return err
+		}
+
+		models := []holdoutModel{}
+		for _, item := range res.Scores.Items {
+			model, err := refitHoldoutModel(train, item.Selection(), -item.Score)
+			if err != nil {
+				return err
+			}
+			models = append(models, model)
+		}
+		sort.Slice(models, func(i, j int) bool { return models[i].score < models[j].score })
+		printHoldout(test, models, strings.ToUpper(critName))
+		return nil
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(gaCmd)
+
+	gaCmd.Flags().String("csv", "", "CSV file with data. Files ending with .npy or .npz are read as NumPy arrays")
+	gaCmd.Flags().String("target", "-1", "Name or index of the target column, if negative the column is counted from the end")
+	gaCmd.Flags().String("out", "ga.json", "JSON file where the highscore list will be stored")
+	gaCmd.Flags().String("criterion", "aicc", "Selection criterion that is minimized |aic|aicc|bic|cp|")
+	gaCmd.Flags().Int("population", 50, "Number of models in each generation")
+	gaCmd.Flags().Int("generations", 100, "Number of generations")
+	gaCmd.Flags().Int("tournament", 3, "Number of models competing in each tournament when the parents are chosen")
+	gaCmd.Flags().String("crossover", "uniform", "How parents are combined |uniform|onepoint|")
+	gaCmd.Flags().Float64("crossover-rate", 0.9, "Probability that two parents are crossed")
+	gaCmd.Flags().Float64("mutation-rate", 0.0, "Probability that a feature is flipped in a child. If zero, 1/(number of features) is used")
+	gaCmd.Flags().Int("elitism", 2, "Number of best models copied unchanged to the next generation")
+	gaCmd.Flags().Int("workers", 8, "Number of models fitted in parallel")
+	gaCmd.Flags().Int("highscore", 10, "Number of models in the highscore list")
+	gaCmd.Flags().Bool("stream", false, "Read the CSV file in chunks and keep only the sufficient statistics (XᵀX, Xᵀy, yᵀy) in memory")
+	addDatasetFlags(gaCmd)
+	addHoldoutFlags(gaCmd)
+	addExpansionFlags(gaCmd)
+	addConstraintFlags(gaCmd)
+}
+
+// gaParams returns the parameters of the genetic algorithm given by the flags. The seed
+// is used both for the holdout set and the genetic algorithm. If it is not given, a seed
+// is drawn from the clock
+func gaParams(cmd *cobra.Command) (*featselect.GAParams, error) {
+	crossover, _ := cmd.Flags().GetString("crossover")
+	critName, _ := cmd.Flags().GetString("criterion")
+
+	params := featselect.NewGAParams()
+	params.PopulationSize, _ = cmd.Flags().GetInt("population")
+	params.Generations, _ = cmd.Flags().GetInt("generations")
+	params.TournamentSize, _ = cmd.Flags().GetInt("tournament")
+	params.CrossoverRate, _ = cmd.Flags().GetFloat64("crossover-rate")
+	params.MutationRate, _ = cmd.Flags().GetFloat64("mutation-rate")
+	params.Elitism, _ = cmd.Flags().GetInt("elitism")
+	params.NumWorkers, _ = cmd.Flags().GetInt("workers")
+	params.HighscoreSize, _ = cmd.Flags().GetInt("highscore")
+
+	params.Seed = searchSeed(cmd)
+	fmt.Printf("Genetic algorithm seed: %d\n", params.Seed)
+
+	var err error
+	params.Crossover, err = featselect.ParseCrossover(crossover)
+	if err != nil {
+		return nil, err
+	}
+
+	params.Criterion, err = featselect.ParseCriterion(critName)
+	if err != nil {
+		return nil, err
+	}
+	return params, nil
+}
+
+// saveGAResult writes the highscore list of the genetic algorithm to out
+func saveGAResult(res *featselect.GARes, out string) error {
+	highscoreJSON, err := json.Marshal(res.Scores)
+	if err != nil {
+		return err
+	}
+
+	if err := ioutil.WriteFile(out, highscoreJSON, 0644); err != nil {
+		return err
+	}
+	fmt.Printf("Best model after %d generations: %v\n", res.Generations, res.Selected)
+	fmt.Printf("GA highscore list written to %s\n", out)
+	return nil
+}
@@ -0,0 +1,80 @@
+package cmd
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/davidkleiven/goselect/featselect"
+	"github.com/spf13/cobra"
+)
+
+// holdoutModel holds a selected model together with its score (e.g. AICc) on the training set
+type holdoutModel struct {
+	selection []int
+	coeff     []float64
+	score     float64
+}
+
+// addHoldoutFlags adds the flags that control the train/test split
+func addHoldoutFlags(cmd *cobra.Command) {
+	cmd.Flags().Float64("holdout", 0.0, "Fraction of the data that is held out and used to report out-of-sample RMSE. If zero, all data are used for training")
+	cmd.Flags().Int64("seed", 0, "Seed used when the holdout set is drawn")
+}
+
+// holdoutSplit divides the dataset into a training and a test set according to the
+// holdout flags. If no data should be held out, the test set is nil
+func holdoutSplit(cmd *cobra.Command, dset *featselect.Dataset) (*featselect.Dataset, *featselect.Dataset, error) {
+	seed, _ := cmd.Flags().GetInt64("seed")
+	return splitDataset(cmd, dset, seed)
+}
+
+// searchSeed returns the seed given by the seed flag. If the flag is not given, a seed is
+// drawn from the clock. Randomised searches use the same seed for the holdout set, such
+// that a run is reproduced by passing the seed
+func searchSeed(cmd *cobra.Command) int64 {
+	if cmd.Flags().Changed("seed") {
+		seed, _ := cmd.Flags().GetInt64("seed")
+		return seed
+	}
+	return time.Now().UTC().UnixNano()
+}
+
+// splitDataset divides the dataset in the same way as holdoutSplit, but the holdout set
+// is drawn with the passed seed
+func splitDataset(cmd *cobra.Command, dset *featselect.Dataset, seed int64) (*featselect.Dataset, *featselect.Dataset, error) {
+	holdout, _ := cmd.Flags().GetFloat64("holdout")
+	if holdout == 0.0 {
+		return dset, nil, nil
+	}
+
+	train, test, err := dset.Split(holdout, seed)
+	if err != nil {
+		return nil, nil, err
+	}
+	fmt.Printf("Holding out %d of %d data points\n", len(test.Y), len(dset.Y))
+	return train, test, nil
+}
+
+// refitHoldoutModel fits the selected features to the training data
+func refitHoldoutModel(train *featselect.Dataset, selection []int, score float64) (holdoutModel, error) {
+	_, nc := train.X.Dims()
+	design := featselect.GetDesignMatrix(featselect.Selected2Model(selection, nc), train.X)
+	coeff, err := featselect.TryFitWeighted(design, train.Y, train.Weights)
+	if err != nil {
+		return holdoutModel{}, err
+	}
+	return holdoutModel{selection: selection, coeff: coeff, score: score}, nil
+}
+
+// printHoldout prints the score on the training data and the RMSE on the test data for
+// each model. criterion is the name of the score
+func printHoldout(test *featselect.Dataset, models []holdoutModel, criterion string) {
+	fmt.Printf("-------------------------------------------------------\n")
+	fmt.Printf("|  Rank  |    Num coeff.    | %12s |   RMSE   |\n", criterion)
+	fmt.Printf("-------------------------------------------------------\n")
+	for i, m := range models {
+		rmse := test.Rmse(m.selection, m.coeff)
+		fmt.Printf("| %6d | %16d | %12.5e | %8.2e |\n", i+1, len(m.selection), m.score, rmse)
+	}
+	fmt.Printf("-------------------------------------------------------\n")
+}
@@ -16,10 +16,10 @@ limitations under the License.
 package cmd
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"io/ioutil"
-	"os"
 
 	"github.com/davidkleiven/goselect/featselect"
 	"github.com/spf13/cobra"
@@ -28,17 +28,27 @@ import (
 
 // lassoCmd represents the lasso command
 var lassoCmd = &cobra.Command{
-	Use:   "lasso",
-	Short: "Performs Lasso fitting",
-	Long:  `Performs Lasso fitting`,
-	Run: func(cmd *cobra.Command, args []string) {
+	Use:          "lasso",
+	Short:        "Performs Lasso fitting",
+	Long: `Performs Lasso fitting with LARS or coordinate descent. LARS does not support
+features given by --force-in, so coordinate descent is used when they are given.`,
+	SilenceUsage: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
 		lassoCsv, _ := cmd.Flags().GetString("csv")
 		if lassoCsv == "" {
-			fmt.Printf("No CSV file given!\n")
-			return
+			return fmt.Errorf("no CSV file given")
+		}
+
+		dset, err := readDataset(cmd, false)
+		if err != nil {
+			return err
+		}
+
+		dset, basis, err := expandDataset(cmd, dset)
+		if err != nil {
+			return err
 		}
 
-		target, _ := cmd.Flags().GetInt("target")
 		out, _ := cmd.Flags().GetString("out")
 		lmin, _ := cmd.Flags().GetFloat64("lmin")
 		lmax, _ := cmd.Flags().GetFloat64("lmax")
@@ -47,35 +57,74 @@ var lassoCmd = &cobra.Command{
 		cov, _ := cmd.Flags().GetString("cov")
 		tol, _ := cmd.Flags().GetFloat64("tol")
 
-		lassoFit(lassoCsv, target, out, lmin, lmax, num, ltype, cov, tol)
+		train, test, err := holdoutSplit(cmd, dset)
+		if err != nil {
+			return err
+		}
+
+		constraints, err := readConstraints(cmd, train.FeatureNames(), basis, train.Categorical)
+		if err != nil {
+			return err
+		}
+
+		if ltype == "lars" && constraints != nil && len(constraints.Include) > 0 {
+			fmt.Printf("LARS does not support forced-in features. Using coordinate descent\n")
+			ltype = "cd"
+		}
+
+		path, err := lassoFit(train, out, lmin, lmax, num, ltype, cov, tol, constraints)
+		if err != nil || test == nil {
+			return err
+		}
+
+		srt := featselect.Argsort(path.Aicc)
+		if len(srt) > 20 {
+			srt = srt[:20]
+		}
+
+		models := make([]holdoutModel, len(srt))
+		for i, v := range srt {
+			node := path.LassoLarsNodes[v]
+			models[i] = holdoutModel{selection: node.Selection, coeff: node.Coeff, score: path.Aicc[v]}
+		}
+		printHoldout(test, models, "AICC")
+		return nil
 	},
 }
 
 func init() {
 	rootCmd.AddCommand(lassoCmd)
 
-	lassoCmd.Flags().String("csv", "", "CSV file with data")
-	lassoCmd.Flags().Int("target", -1, "Target column, if negative the column is counted from the end")
+	lassoCmd.Flags().String("csv", "", "CSV file with data. Files ending with .npy or .npz are read as NumPy arrays")
+	lassoCmd.Flags().String("target", "-1", "Name or index of the target column, if negative the column is counted from the end")
 	lassoCmd.Flags().String("out", "lasso.json", "JSON file where the output will be stored")
 	lassoCmd.Flags().Float64("lmin", 1e-10, "Minimum value of the regularization parameter")
 	lassoCmd.Flags().Float64("lmax", 1.0, "Maximum value of the regularization parameter")
 	lassoCmd.Flags().Int("num", 50, "Number of regularization (only with coordinate descent)")
-	lassoCmd.Flags().String("type", "lars", "Algorithm lars or cd")
+	lassoCmd.Flags().String("type", "lars", "Algorithm lars or cd. cd is always used with --force-in")
 	lassoCmd.Flags().String("cov", "empirical", "Estimator for covariance matrix")
 	lassoCmd.Flags().Float64("tol", 1e-4, "Tolerance in LASSO coordinate descent")
-
+	addDatasetFlags(lassoCmd)
+	addHoldoutFlags(lassoCmd)
+	addExpansionFlags(lassoCmd)
+	addConstraintFlags(lassoCmd)
 }
 
-func lassoFit(csvfile string, targetCol int, out string, lambMin float64, lambMax float64, num int, lassoType string, covType string, tol float64) {
-	dset := featselect.ReadCSV(csvfile, targetCol)
+func lassoFit(dset *featselect.Dataset, out string, lambMin float64, lambMax float64, num int, lassoType string, covType string, tol float64, c *featselect.Constraints) (*featselect.LassoLarsPath, error) {
 	y := make([]float64, len(dset.Y))
 	copy(y, dset.Y)
-	normDset := featselect.NewNormalizedData(mat.DenseCopyOf(dset.X), y)
+	normDset, err := featselect.TryNewWeightedNormalizedData(mat.DenseCopyOf(dset.X), y, dset.Weights)
+	if err != nil {
+		return nil, err
+	}
 
 	larspath := []*featselect.LassoLarsNode{}
 	if lassoType == "lars" {
 		var estimator featselect.MorsePenroseCD
-		larspath = featselect.LassoLars(normDset, lambMin, &estimator)
+		larspath, err = featselect.LassoLarsConstrained(normDset, lambMin, &estimator, c)
+		if err != nil {
+			return nil, err
+		}
 	} else if lassoType == "cd" {
 		var cov featselect.CovMat
 		var corr featselect.PureLasso
@@ -88,11 +137,15 @@ func lassoFit(csvfile string, targetCol int, out string, lambMin float64, lambMa
 		} else if covType == "threshold" {
 			cov = featselect.NewSparseThreshold(normDset.X)
 		} else {
-			fmt.Printf("Unknown covariance type %s\n", covType)
-			return
+			return nil, fmt.Errorf("unknown covariance type %s", covType)
 		}
 		lambs := featselect.Logspace(lambMin, lambMax, num)
-		larspath = featselect.LassoCrdDescPath(normDset, cov, lambs, 100000, tol, &corr)
+		larspath, err = featselect.LassoCrdDescPathConstrained(context.Background(), normDset, cov, lambs, 100000, tol, &corr, featselect.Budget{}, c)
+		if err != nil {
+			return nil, err
+		}
+	} else {
+		return nil, fmt.Errorf("unknown lasso type %s", lassoType)
 	}
 
 	featselect.Path2Unnormalized(normDset, larspath)
@@ -109,15 +162,13 @@ func lassoFit(csvfile string, targetCol int, out string, lambMin float64, lambMa
 	featselect.PrintHighscore(&path, aicc, bic, 20)
 
 	js, err := json.Marshal(path)
-
 	if err != nil {
-		fmt.Printf("Error: %s", err)
-		return
+		return nil, err
 	}
 
-	file, _ := os.Create(out)
-	defer file.Close()
-
-	ioutil.WriteFile(out, js, 0644)
+	if err := ioutil.WriteFile(out, js, 0644); err != nil {
+		return nil, err
+	}
 	fmt.Printf("LASSO-LARS results written to %s\n", out)
+	return &path, nil
 }
@@ -19,9 +19,9 @@ import (
 	"encoding/json"
 	"fmt"
 	"io/ioutil"
-	"log"
 	"math/rand"
 	"os"
+	"sort"
 	"time"
 
 	"github.com/davidkleiven/goselect/featselect"
@@ -37,40 +37,183 @@ corresponding AICc vlaue evaluated. The SA algorithm then minimized AICc.
 
 Example:
 goselect sasearch -csv mydatafile.csv -target -1 -out result.json -sweeps 40
+
+With --replicas N, the models are searched by parallel tempering (replica exchange) instead. N chains
+run concurrently at fixed temperatures between --min-temp and --max-temp, and neighbouring chains
+try to swap models every --swap-interval sweeps. Each chain performs --sweeps sweeps, and the
+highscore lists of the chains are merged. The run is reproduced by passing the seed that is
+printed at the start.
+goselect sasearch -csv mydatafile.csv -replicas 8 -sweeps 200 -seed 42
 	`,
-	Run: func(cmd *cobra.Command, args []string) {
-		saCsv, err := cmd.Flags().GetString("csv")
+	SilenceUsage: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		saOut, _ := cmd.Flags().GetString("out")
+		saSweeps, _ := cmd.Flags().GetInt("sweeps")
+
+		var pt *featselect.PTParams
+		if replicas, _ := cmd.Flags().GetInt("replicas"); replicas > 1 {
+			pt = ptParams(cmd, replicas, saSweeps)
+		}
+
+		if stream, _ := cmd.Flags().GetBool("stream"); stream {
+			if holdout, _ := cmd.Flags().GetFloat64("holdout"); holdout > 0.0 {
+				return fmt.Errorf("holdout can not be combined with stream")
+			}
+
+			if constraintsRequested(cmd) {
+				return fmt.Errorf("constraints can not be combined with stream")
+			}
+
+			stats, err := readStats(cmd)
+			if err != nil {
+				return err
+			}
+
+			if pt != nil {
+				res, err := featselect.SelectModelPTFromStats(stats, pt)
+				if err != nil {
+					return err
+				}
+				res.Scores.SetNames(stats.FeatureNames())
+				printReplicaStats(res.Replicas)
+				saveSAResult(res.Scores, saOut)
+				return nil
+			}
+
+			rand.Seed(time.Now().UTC().UnixNano())
+			res := featselect.SelectModelSAFromStats(stats, saSweeps, featselect.Aicc)
+			res.Scores.SetNames(stats.FeatureNames())
+			saveSAResult(res.Scores, saOut)
+			return nil
+		}
+
+		dset, err := readDataset(cmd, false)
 		if err != nil {
-			log.Print(err)
-			return
+			return err
 		}
 
-		saTarget, _ := cmd.Flags().GetInt("target")
-		saOut, _ := cmd.Flags().GetString("out")
-		saSweeps, _ := cmd.Flags().GetInt("sweeps")
+		dset, basis, err := expandDataset(cmd, dset)
+		if err != nil {
+			return err
+		}
+
+		seed, _ := cmd.Flags().GetInt64("seed")
+		if pt != nil {
+			seed = pt.Seed
+		}
 
-		saSearch(saCsv, saTarget, saOut, saSweeps)
+		train, test, err := splitDataset(cmd, dset, seed)
+		if err != nil {
+			return err
+		}
+
+		constraints, err := readConstraints(cmd, train.FeatureNames(), basis, train.Categorical)
+		if err != nil {
+			return err
+		}
+
+		var scores *featselect.SAScore
+		if pt != nil {
+			pt.Constraints = constraints
+			scores, err = ptSearch(train, saOut, pt)
+		} else {
+			scores, err = saSearch(train, saOut, saSweeps, constraints)
+		}
+		if err != nil || test == nil {
+			return err
+		}
+
+		models := []holdoutModel{}
+		for _, item := range scores.Items {
+			model, err := refitHoldoutModel(train, item.Selection(), -item.Score)
+			if err != nil {
+				return err
+			}
+			models = append(models, model)
+		}
+		sort.Slice(models, func(i, j int) bool { return models[i].score < models[j].score })
+		printHoldout(test, models, "AICC")
+		return nil
 	},
 }
 
 func init() {
 	rootCmd.AddCommand(sasearchCmd)
 
-	sasearchCmd.Flags().String("csv", "", "CSV file with data")
-	sasearchCmd.Flags().Int("target", -1, "Column where the target values are placed. If negative it is counted from the last column.")
+	sasearchCmd.Flags().String("csv", "", "CSV file with data. Files ending with .npy or .npz are read as NumPy arrays")
+	sasearchCmd.Flags().String("target", "-1", "Name or index of the column where the target values are placed. If negative it is counted from the last column.")
 	sasearchCmd.Flags().String("out", "saSearch.json", "JSON file where the final result will be stored")
 	sasearchCmd.Flags().Int("sweeps", 100, "Number of sweeps per temperature")
+	sasearchCmd.Flags().Int("replicas", 1, "Number of replicas in a parallel tempering search. If 1, simulated annealing is used")
+	sasearchCmd.Flags().Float64("min-temp", 0.5, "Lowest temperature in a parallel tempering search")
+	sasearchCmd.Flags().Float64("max-temp", 50.0, "Highest temperature in a parallel tempering search")
+	sasearchCmd.Flags().Int("swap-interval", 1, "Number of sweeps between the swap attempts in a parallel tempering search")
+	sasearchCmd.Flags().Bool("stream", false, "Read the CSV file in chunks and keep only the sufficient statistics (XᵀX, Xᵀy, yᵀy) in memory")
+	addDatasetFlags(sasearchCmd)
+	addHoldoutFlags(sasearchCmd)
+	addExpansionFlags(sasearchCmd)
+	addConstraintFlags(sasearchCmd)
 }
 
-func saSearch(csvfile string, targetCol int, out string, sweeps int) {
+func saSearch(dset *featselect.Dataset, out string, sweeps int, c *featselect.Constraints) (*featselect.SAScore, error) {
 	rand.Seed(time.Now().UTC().UnixNano())
-	dset := featselect.ReadCSV(csvfile, targetCol)
-	res := featselect.SelectModelSA(dset.X, dset.Y, sweeps, featselect.Aicc)
+	X, y := featselect.WeightRows(dset.X, dset.Y, dset.Weights)
+	res, err := featselect.SelectModelSAConstrained(X, y, sweeps, featselect.Aicc, c)
+	if err != nil {
+		return nil, err
+	}
+	res.Scores.SetNames(dset.FeatureNames())
+	saveSAResult(res.Scores, out)
+	return res.Scores, nil
+}
+
+// ptSearch selects the model by parallel tempering and saves the merged highscore list
+func ptSearch(dset *featselect.Dataset, out string, params *featselect.PTParams) (*featselect.SAScore, error) {
+	X, y := featselect.WeightRows(dset.X, dset.Y, dset.Weights)
+	res, err := featselect.SelectModelPT(X, y, params)
+	if err != nil {
+		return nil, err
+	}
+	res.Scores.SetNames(dset.FeatureNames())
+	printReplicaStats(res.Replicas)
+	saveSAResult(res.Scores, out)
+	return res.Scores, nil
+}
+
+// ptParams returns the parameters of a parallel tempering search given by the flags. The
+// seed is used both for the holdout set and the search. If it is not given, a seed is
+// drawn from the clock
+func ptParams(cmd *cobra.Command, replicas int, sweeps int) *featselect.PTParams {
+	params := featselect.NewPTParams()
+	params.NumReplicas = replicas
+	params.Sweeps = sweeps
+	params.MinTemp, _ = cmd.Flags().GetFloat64("min-temp")
+	params.MaxTemp, _ = cmd.Flags().GetFloat64("max-temp")
+	params.SwapInterval, _ = cmd.Flags().GetInt("swap-interval")
+
+	params.Seed = searchSeed(cmd)
+	fmt.Printf("Parallel tempering seed: %d\n", params.Seed)
+	return params
+}
+
+// printReplicaStats prints the acceptance and swap rates of each replica. The swap rate is
+// for swaps with the replica at the next higher temperature
+func printReplicaStats(replicas []featselect.ReplicaStats) {
+	fmt.Printf("---------------------------------------------------------------\n")
+	fmt.Printf("| Replica | Temperature | Acc. rate | Swap rate |  Best score  |\n")
+	fmt.Printf("---------------------------------------------------------------\n")
+	for i, r := range replicas {
+		fmt.Printf("| %7d | %11.3e | %9.3f | %9.3f | %12.5e |\n", i, r.Temperature, r.AcceptanceRate(), r.SwapRate(), r.BestScore)
+	}
+	fmt.Printf("---------------------------------------------------------------\n")
+}
+
+func saveSAResult(scores *featselect.SAScore, out string) {
 	file, _ := os.Open(out)
 	defer file.Close()
 
-	highscoreJSON, _ := json.Marshal(res.Scores)
+	highscoreJSON, _ := json.Marshal(scores)
 	ioutil.WriteFile(out, highscoreJSON, 0644)
 
-	fmt.Printf("SA highscore list written to %s", out)
+	fmt.Printf("SA highscore list written to %s\n", out)
 }
@@ -0,0 +1,193 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"strings"
+
+	"github.com/davidkleiven/goselect/featselect"
+	"github.com/spf13/cobra"
+)
+
+// stepwiseCmd represents the stepwise command
+var stepwiseCmd = &cobra.Command{
+	Use:   "stepwise",
+	Short: "Performs model selection by stepwise addition and removal of features",
+	Long: `Selects a model by adding or removing one feature at a time, and accepting the move that
+improves the criterion the most. The strategy is one of
+forward       start from the intercept and add features
+backward      start from all features and remove features
+bidirectional start from the intercept and add or remove features
+foba          adaptive forward-backward selection, where features are removed after each forward
+              step as long as the criterion increases by less than --foba-ratio times the
+              improvement of the forward step
+
+The best of the visited models (see --highscore) are written to the output file, and the
+accepted models are written to the array "path" in the same file.
+
+Example:
+goselect stepwise -csv mydatafile.csv -target -1 -strategy bidirectional -criterion bic
+	`,
+	SilenceUsage: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		out, _ := cmd.Flags().GetString("out")
+		critName, _ := cmd.Flags().GetString("criterion")
+		numItems, _ := cmd.Flags().GetInt("highscore")
+
+		params, err := stepwiseParams(cmd)
+		if err != nil {
+			return err
+		}
+
+		if stream, _ := cmd.Flags().GetBool("stream"); stream {
+			if holdout, _ := cmd.Flags().GetFloat64("holdout"); holdout > 0.0 {
+				return fmt.Errorf("holdout can not be combined with stream")
+			}
+
+			stats, err := readStats(cmd)
+			if err != nil {
+				return err
+			}
+
+			params.Constraints, err = readConstraints(cmd, stats.FeatureNames(), nil, nil)
+			if err != nil {
+				return err
+			}
+
+			highscore := featselect.NewHighscore(numItems)
+			highscore.Names = stats.FeatureNames()
+			res, err := featselect.StepwiseFromStats(stats, highscore, params)
+			if err != nil {
+				return err
+			}
+			return saveStepwiseResult(res, highscore, out, critName)
+		}
+
+		dset, err := readDataset(cmd, false)
+		if err != nil {
+			return err
+		}
+
+		dset, basis, err := expandDataset(cmd, dset)
+		if err != nil {
+			return err
+		}
+
+		train, test, err := holdoutSplit(cmd, dset)
+		if err != nil {
+			return err
+		}
+
+		params.Constraints, err = readConstraints(cmd, train.FeatureNames(), basis, train.Categorical)
+		if err != nil {
+			return err
+		}
+
+		highscore := featselect.NewHighscore(numItems)
+		highscore.Names = train.FeatureNames()
+		X, y := featselect.WeightRows(train.X, train.Y, train.Weights)
+		res, err := featselect.Stepwise(X, y, highscore, params)
+		if err != nil {
+			return err
+		}
+		if err := saveStepwiseResult(res, highscore, out, critName); err != nil || test == nil {
+			return err
+		}
+
+		models := []holdoutModel{}
+		for item := highscore.Items.Front(); item != nil; item = item.Next() {
+			node := item.Value.(*featselect.Node)
+			model, err := refitHoldoutModel(train, node.Model.Selected(), -node.Score)
+			if err != nil {
+				return err
+			}
+			models = append(models, model)
+		}
+		printHoldout(test, models, strings.ToUpper(critName))
+		return nil
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(stepwiseCmd)
+
+	stepwiseCmd.Flags().String("csv", "", "CSV file with data. Files ending with .npy or .npz are read as NumPy arrays")
+	stepwiseCmd.Flags().String("target", "-1", "Name or index of the target column, if negative the column is counted from the end")
+	stepwiseCmd.Flags().String("out", "stepwise.json", "JSON file where the best visited models and the accepted models will be stored")
+	stepwiseCmd.Flags().String("strategy", "forward", "Stepwise strategy |forward|backward|bidirectional|foba|")
+	stepwiseCmd.Flags().String("criterion", "aicc", "Selection criterion that is minimized |aic|aicc|bic|cp|")
+	stepwiseCmd.Flags().Float64("foba-ratio", 0.5, "A feature is removed by foba if the criterion increases by less than this fraction of the improvement of the last forward step")
+	stepwiseCmd.Flags().Int("highscore", 20, "Number of models in the output file")
+	stepwiseCmd.Flags().Bool("stream", false, "Read the CSV file in chunks and keep only the sufficient statistics (XᵀX, Xᵀy, yᵀy) in memory")
+	addDatasetFlags(stepwiseCmd)
+	addHoldoutFlags(stepwiseCmd)
+	addExpansionFlags(stepwiseCmd)
+	addConstraintFlags(stepwiseCmd)
+}
+
+// stepwiseParams returns the parameters of the stepwise selection given by the flags
+func stepwiseParams(cmd *cobra.Command) (*featselect.StepwiseParams, error) {
+	strategy, _ := cmd.Flags().GetString("strategy")
+	critName, _ := cmd.Flags().GetString("criterion")
+
+	params := featselect.NewStepwiseParams()
+	params.FoBaRatio, _ = cmd.Flags().GetFloat64("foba-ratio")
+
+	var err error
+	params.Strategy, err = featselect.ParseStepwiseStrategy(strategy)
+	if err != nil {
+		return nil, err
+	}
+
+	params.Criterion, err = featselect.ParseCriterion(critName)
+	if err != nil {
+		return nil, err
+	}
+	return params, nil
+}
+
+// saveStepwiseResult prints the accepted models and writes the highscore list to out. The
+// accepted models are added to the highscore list as the array path
+func saveStepwiseResult(res *featselect.StepwiseResult, highscore *featselect.Highscore, out string, critName string) error {
+	fmt.Printf("-------------------------------------------\n")
+	fmt.Printf("|  Step  |    Num coeff.    | %12s |\n", strings.ToUpper(critName))
+	fmt.Printf("-------------------------------------------\n")
+	for i, node := range res.Path {
+		fmt.Printf("| %6d | %16d | %12.5e |\n", i, node.Model.NumFeatures(), -node.Score)
+	}
+	fmt.Printf("-------------------------------------------\n")
+
+	best := res.Best()
+	names := make([]string, 0, best.Model.NumFeatures())
+	for _, f := range best.Model.Selected() {
+		names = append(names, highscore.Names[f])
+	}
+	fmt.Printf("Selected features: %s\n", strings.Join(names, ", "))
+
+	highscoreJSON, err := json.Marshal(highscore)
+	if err != nil {
+		return err
+	}
+
+	fields := make(map[string]json.RawMessage)
+	if err := json.Unmarshal(highscoreJSON, &fields); err != nil {
+		return err
+	}
+
+	fields["path"], err = json.Marshal(res.Path)
+	if err != nil {
+		return err
+	}
+
+	js, err := json.Marshal(fields)
+	if err != nil {
+		return err
+	}
+
+	if err := ioutil.WriteFile(out, js, 0644); err != nil {
+		return err
+	}
+	fmt.Printf("Visited models written to %s\n", out)
+	return nil
+}
@@ -2,12 +2,17 @@ package main
 
 import (
 	"flag"
+	"fmt"
 
 	"github.com/davidkleiven/goselect/featselect"
 )
 
 func cohensKappaPureLasso(csvfile string, lambMin float64, lambMax float64, numLamb int, target int, numSamples int, tol float64) {
-	dset := featselect.ReadCSV(csvfile, target)
+	dset, err := featselect.ReadCSV(csvfile, target)
+	if err != nil {
+		fmt.Printf("Error: %s\n", err)
+		return
+	}
 	normDset := featselect.NewNormalizedData(dset.X, dset.Y)
 
 	targets := make([]featselect.CohensKappaTarget, numLamb)
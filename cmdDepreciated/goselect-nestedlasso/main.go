@@ -10,7 +10,11 @@ import (
 )
 
 func nestedLasso(csvfile string, targetCol int, out string, lambMin float64, keep float64) {
-	dset := featselect.ReadCSV(csvfile, targetCol)
+	dset, err := featselect.ReadCSV(csvfile, targetCol)
+	if err != nil {
+		fmt.Printf("Error: %s\n", err)
+		return
+	}
 	var estimator featselect.MorsePenroseCD
 	res := featselect.NestedLasso(dset, lambMin, keep, &estimator)
 	js, err := json.Marshal(res)
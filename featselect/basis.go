@@ -0,0 +1,279 @@
+package featselect
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"math"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// ErrUnknownTransform is returned when a transform is not registered
+var ErrUnknownTransform = errors.New("unknown transform")
+
+// Transform is a function that creates a new feature from one or more existing features
+type Transform struct {
+	// NumArgs is the number of columns passed to Apply
+	NumArgs int
+
+	// Apply returns the value of the new feature given the values of the columns
+	Apply func(args []float64) float64
+
+	// Format returns the name of the new feature given the names of the columns.
+	// If nil, the name is formed as transform(a, b)
+	Format func(args []string) string
+}
+
+var (
+	transformMu sync.RWMutex
+	transforms  = map[string]Transform{
+		"log":  {NumArgs: 1, Apply: func(x []float64) float64 { return math.Log(x[0]) }},
+		"exp":  {NumArgs: 1, Apply: func(x []float64) float64 { return math.Exp(x[0]) }},
+		"sqrt": {NumArgs: 1, Apply: func(x []float64) float64 { return math.Sqrt(x[0]) }},
+		"inv": {
+			NumArgs: 1,
+			Apply:   func(x []float64) float64 { return 1.0 / x[0] },
+			Format:  func(a []string) string { return "1/" + a[0] },
+		},
+		"abs": {
+			NumArgs: 1,
+			Apply:   func(x []float64) float64 { return math.Abs(x[0]) },
+			Format:  func(a []string) string { return "|" + a[0] + "|" },
+		},
+		"ratio": {
+			NumArgs: 2,
+			Apply:   func(x []float64) float64 { return x[0] / x[1] },
+			Format:  func(a []string) string { return a[0] + "/" + a[1] },
+		},
+	}
+)
+
+// RegisterTransform makes a transform available under the given name. The built-in
+// transforms are log, exp, sqrt, inv (1/a), abs and ratio (a/b). A transform can not
+// be registered twice
+func RegisterTransform(name string, t Transform) error {
+	if name == "" || t.NumArgs < 1 || t.Apply == nil {
+		return fmt.Errorf("registertransform: a transform needs a name, at least one argument and an Apply function")
+	}
+
+	transformMu.Lock()
+	defer transformMu.Unlock()
+	if _, ok := transforms[name]; ok {
+		return fmt.Errorf("registertransform: %s is already registered", name)
+	}
+	transforms[name] = t
+	return nil
+}
+
+// TransformNames returns the names of all registered transforms in alphabetical order
+func TransformNames() []string {
+	transformMu.RLock()
+	defer transformMu.RUnlock()
+	names := make([]string, 0, len(transforms))
+	for n := range transforms {
+		names = append(names, n)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func getTransform(name string) (Transform, bool) {
+	transformMu.RLock()
+	defer transformMu.RUnlock()
+	t, ok := transforms[name]
+	return t, ok
+}
+
+// BasisTerm defines a feature that is generated from the original features. If Transform
+// is empty, the feature is the product of the powers in Power (column -> power). Otherwise,
+// the named transform is applied to the columns in Cols. Name is the name of the feature.
+type BasisTerm struct {
+	Name      string      `json:"name,omitempty"`
+	Power     map[int]int `json:"power,omitempty"`
+	Transform string      `json:"transform,omitempty"`
+	Cols      []int       `json:"cols,omitempty"`
+}
+
+// validate checks that the term can be evaluated for a matrix with numCols columns
+func (t BasisTerm) validate(numCols int) error {
+	cols := t.Cols
+	if t.Transform == "" {
+		if len(t.Power) == 0 {
+			return fmt.Errorf("basisterm: no power or transform given")
+		}
+		cols = []int{}
+		for c := range t.Power {
+			cols = append(cols, c)
+		}
+	} else {
+		transform, ok := getTransform(t.Transform)
+		if !ok {
+			return fmt.Errorf("basisterm: %s: %w", t.Transform, ErrUnknownTransform)
+		}
+
+		if len(t.Cols) != transform.NumArgs {
+			return fmt.Errorf("basisterm: %s takes %d columns, got %d", t.Transform, transform.NumArgs, len(t.Cols))
+		}
+	}
+
+	for _, c := range cols {
+		if c < 0 || c >= numCols {
+			return fmt.Errorf("basisterm: column %d out of range (matrix has %d columns)", c, numCols)
+		}
+	}
+	return nil
+}
+
+// label returns the name of the term given the names of the original columns
+func (t BasisTerm) label(names []string) string {
+	if t.Transform == "" {
+		return PowerName(names, t.Power)
+	}
+
+	args := make([]string, len(t.Cols))
+	for i, c := range t.Cols {
+		args[i] = names[c]
+	}
+
+	transform, _ := getTransform(t.Transform)
+	if transform.Format != nil {
+		return transform.Format(args)
+	}
+	return t.Transform + "(" + strings.Join(args, ", ") + ")"
+}
+
+// BasisSet describes how the features of an expanded dataset are generated from the
+// original features. It can be written to JSON and applied to new data, such that a
+// model selected on the expanded features can be evaluated on the new data
+type BasisSet struct {
+	Names []string    `json:"names"`
+	Terms []BasisTerm `json:"terms"`
+}
+
+// Apply returns a dataset with the features that were used to create the basis set,
+// in the same order. The original features are matched by name.
+func (b *BasisSet) Apply(d *Dataset) (*Dataset, error) {
+	features := make([]int, len(b.Names))
+	for i, n := range b.Names {
+		featNo, ok := d.featNo(n)
+		if !ok {
+			return nil, fmt.Errorf("basisset: %s: %w", n, ErrFeatureNotFound)
+		}
+		features[i] = featNo
+	}
+
+	expanded, _, err := d.GetSubset(features).Expand(b.Terms)
+	return expanded, err
+}
+
+// Save writes the basis set to a JSON file
+func (b *BasisSet) Save(fname string) error {
+	js, err := json.Marshal(b)
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(fname, js, 0644)
+}
+
+// ReadBasisSet reads a basis set from a JSON file
+func ReadBasisSet(fname string) (*BasisSet, error) {
+	data, err := ioutil.ReadFile(fname)
+	if err != nil {
+		return nil, err
+	}
+
+	var b BasisSet
+	if err := json.Unmarshal(data, &b); err != nil {
+		return nil, err
+	}
+	return &b, nil
+}
+
+// Expand returns a new dataset where the features defined by the terms are appended
+// to the original features, together with the basis set describing the expansion
+func (d *Dataset) Expand(terms []BasisTerm) (*Dataset, *BasisSet, error) {
+	featNames := d.FeatureNames()
+	lazy := LazyPowerMatrix{X: d.X}
+	for _, term := range terms {
+		if err := lazy.AddTerm(term); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	basis := BasisSet{Names: featNames, Terms: []BasisTerm{}}
+	expanded := d.Copy()
+	expanded.X = lazy.FullMatrix()
+	for i, term := range lazy.Terms() {
+		term.Name = lazy.ColName(len(featNames)+i, featNames)
+		expanded.Names = append(expanded.Names, term.Name)
+		basis.Terms = append(basis.Terms, term)
+	}
+	return expanded, &basis, nil
+}
+
+// TransformTerms returns the terms formed by applying each of the named transforms to
+// the features. Transforms taking two arguments (e.g. ratio) are applied to all ordered
+// pairs of different features. Constant and binary features are skipped, and so are
+// terms that are not finite for all data points (e.g. the log of a negative number)
+func (d *Dataset) TransformTerms(names []string) ([]BasisTerm, error) {
+	cols, binary := d.expandableColumns()
+	continuous := []int{}
+	for _, c := range cols {
+		if !binary[c] {
+			continuous = append(continuous, c)
+		}
+	}
+
+	lazy := LazyPowerMatrix{X: d.X}
+	terms := []BasisTerm{}
+	for _, name := range names {
+		transform, ok := getTransform(name)
+		if !ok {
+			return nil, fmt.Errorf("transformterms: %s: %w", name, ErrUnknownTransform)
+		}
+
+		candidates := [][]int{}
+		switch transform.NumArgs {
+		case 1:
+			for _, c := range continuous {
+				candidates = append(candidates, []int{c})
+			}
+		case 2:
+			for _, c1 := range continuous {
+				for _, c2 := range continuous {
+					if c1 != c2 {
+						candidates = append(candidates, []int{c1, c2})
+					}
+				}
+			}
+		default:
+			return nil, fmt.Errorf("transformterms: %s takes %d arguments, only transforms of one or two features can be applied to all features", name, transform.NumArgs)
+		}
+
+		for _, args := range candidates {
+			term := BasisTerm{Transform: name, Cols: args}
+			if allFinite(lazy.evalTerm(term)) {
+				terms = append(terms, term)
+			}
+		}
+	}
+	return terms, nil
+}
+
+// evalTerm returns the values of the column defined by the term
+func (m *LazyPowerMatrix) evalTerm(term BasisTerm) []float64 {
+	tmp := LazyPowerMatrix{X: m.X, terms: []BasisTerm{term}}
+	return tmp.GetCol(m.numOrigCols())
+}
+
+func allFinite(values []float64) bool {
+	for _, v := range values {
+		if math.IsNaN(v) || math.IsInf(v, 0) {
+			return false
+		}
+	}
+	return true
+}
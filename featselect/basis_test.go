@@ -0,0 +1,177 @@
+package featselect
+
+import (
+	"encoding/json"
+	"errors"
+	"math"
+	"testing"
+
+	"gonum.org/v1/gonum/mat"
+)
+
+func TestLazyMatrixTransforms(t *testing.T) {
+	X := mat.NewDense(2, 2, []float64{1.0, 2.0, 4.0, -8.0})
+	names := []string{"a", "b"}
+	lazy := LazyPowerMatrix{X: X}
+	lazy.AddPower(map[int]int{0: 2})
+	for _, term := range []BasisTerm{
+		{Transform: "log", Cols: []int{0}},
+		{Transform: "sqrt", Cols: []int{0}},
+		{Transform: "inv", Cols: []int{1}},
+		{Transform: "abs", Cols: []int{1}},
+		{Transform: "ratio", Cols: []int{1, 0}},
+	} {
+		if err := lazy.AddTerm(term); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	wantNames := []string{"a", "b", "a^2", "log(a)", "sqrt(a)", "1/b", "|b|", "b/a"}
+	for i, want := range wantNames {
+		if got := lazy.ColName(i, names); got != want {
+			t.Errorf("Test #%d: Expected name %s got %s", i, want, got)
+		}
+	}
+
+	want := mat.NewDense(2, 8, []float64{
+		1.0, 2.0, 1.0, 0.0, 1.0, 0.5, 2.0, 2.0,
+		4.0, -8.0, 16.0, math.Log(4.0), 2.0, -0.125, 8.0, -2.0,
+	})
+	if got := lazy.FullMatrix(); !mat.EqualApprox(got, want, 1e-10) {
+		t.Errorf("Expected\n%v\nGot\n%v\n", mat.Formatted(want), mat.Formatted(got))
+	}
+}
+
+func TestAddTermErrors(t *testing.T) {
+	lazy := LazyPowerMatrix{X: mat.NewDense(2, 2, nil)}
+	for i, test := range []struct {
+		term    BasisTerm
+		unknown bool
+	}{
+		{term: BasisTerm{Transform: "sinh", Cols: []int{0}}, unknown: true},
+		{term: BasisTerm{Transform: "log", Cols: []int{0, 1}}},
+		{term: BasisTerm{Transform: "log", Cols: []int{2}}},
+		{term: BasisTerm{Power: map[int]int{3: 1}}},
+		{term: BasisTerm{}},
+	} {
+		err := lazy.AddTerm(test.term)
+		if err == nil {
+			t.Errorf("Test #%d: Expected error", i)
+		}
+
+		if errors.Is(err, ErrUnknownTransform) != test.unknown {
+			t.Errorf("Test #%d: Unexpected error %v", i, err)
+		}
+	}
+
+	if lazy.NumCols() != 2 {
+		t.Errorf("Invalid terms should not be added")
+	}
+}
+
+func TestRegisterTransform(t *testing.T) {
+	cube := Transform{
+		NumArgs: 2,
+		Apply:   func(x []float64) float64 { return x[0] * x[1] * x[1] },
+	}
+	if err := RegisterTransform("testMulSq", cube); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := RegisterTransform("testMulSq", cube); err == nil {
+		t.Errorf("Expected error when registering a transform twice")
+	}
+
+	if err := RegisterTransform("", cube); err == nil {
+		t.Errorf("Expected error when the name is empty")
+	}
+
+	found := false
+	for _, n := range TransformNames() {
+		found = found || n == "testMulSq"
+	}
+	if !found {
+		t.Errorf("Registered transform not listed in %v", TransformNames())
+	}
+
+	lazy := LazyPowerMatrix{X: mat.NewDense(1, 2, []float64{2.0, 3.0})}
+	if err := lazy.AddTransform("testMulSq", 0, 1); err != nil {
+		t.Fatal(err)
+	}
+
+	if got := lazy.ColName(2, []string{"a", "b"}); got != "testMulSq(a, b)" {
+		t.Errorf("Unexpected name %s", got)
+	}
+
+	if got := lazy.GetCol(2)[0]; got != 18.0 {
+		t.Errorf("Expected 18 got %f", got)
+	}
+}
+
+func TestTransformTerms(t *testing.T) {
+	// Columns: a (positive), b (negative values), c (binary)
+	dset := Dataset{
+		X:         mat.NewDense(3, 3, []float64{1.0, -1.0, 0.0, 2.0, 1.0, 1.0, 3.0, 2.0, 1.0}),
+		Y:         []float64{1.0, 2.0, 3.0},
+		Names:     []string{"a", "b", "c", "y"},
+		TargetCol: 3,
+	}
+
+	terms, err := dset.TransformTerms([]string{"log", "ratio"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expanded, basis, err := dset.Expand(terms)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []string{"a", "b", "c", "log(a)", "a/b", "b/a"}
+	if got := expanded.FeatureNames(); !strArrayEqual(got, want) {
+		t.Errorf("Expected %v got %v", want, got)
+	}
+
+	if _, err := dset.TransformTerms([]string{"sinh"}); !errors.Is(err, ErrUnknownTransform) {
+		t.Errorf("Expected ErrUnknownTransform got %v", err)
+	}
+
+	// Apply the basis set to new data with the columns in a different order
+	js, err := json.Marshal(basis)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var decoded BasisSet
+	if err := json.Unmarshal(js, &decoded); err != nil {
+		t.Fatal(err)
+	}
+
+	newData := Dataset{
+		X:         mat.NewDense(1, 3, []float64{4.0, 1.0, 2.0}),
+		Y:         []float64{1.0},
+		Names:     []string{"y", "c", "a", "b"},
+		TargetCol: 0,
+	}
+
+	applied, err := decoded.Apply(&newData)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got := applied.FeatureNames(); !strArrayEqual(got, want) {
+		t.Errorf("Expected %v got %v", want, got)
+	}
+
+	wantRow := []float64{1.0, 2.0, 4.0, 0.0, 0.5, 2.0}
+	for j, v := range wantRow {
+		if got := applied.X.At(0, j); math.Abs(got-v) > 1e-10 {
+			t.Errorf("Column %d: Expected %f got %f", j, v, got)
+		}
+	}
+
+	newData.Names[2] = "d"
+	if _, err := decoded.Apply(&newData); !errors.Is(err, ErrFeatureNotFound) {
+		t.Errorf("Expected ErrFeatureNotFound got %v", err)
+	}
+}
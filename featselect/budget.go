@@ -0,0 +1,76 @@
+package featselect
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// ErrBudgetExhausted is returned when a selection algorithm is stopped because its
+// budget is used up. The best result found so far is still returned
+var ErrBudgetExhausted = errors.New("budget exhausted")
+
+// ErrInterrupted is returned when a search is stopped via SelectModelOptParams.Interrupt
+var ErrInterrupted = errors.New("search interrupted")
+
+// Budget limits the work done by a selection algorithm. Zero values mean no limit.
+// MaxTime is the wall-clock time. MaxNodes is the number of models scored by branch
+// and bound. MaxIterations is the number of Monte Carlo steps in simulated annealing
+// (per replica in parallel tempering), the number of regularisation values solved in
+// a lasso path, the number of targets evaluated in a Cohen's kappa sequence and the
+// number of generations in the genetic algorithm.
+type Budget struct {
+	MaxTime       time.Duration
+	MaxNodes      int
+	MaxIterations int
+}
+
+// budgetTracker checks if an algorithm should stop, either because the context is
+// cancelled or the budget is used up
+type budgetTracker struct {
+	parent context.Context
+	ctx    context.Context
+	cancel context.CancelFunc
+	budget Budget
+}
+
+func newBudgetTracker(ctx context.Context, budget Budget) *budgetTracker {
+	t := budgetTracker{parent: ctx, budget: budget}
+	if budget.MaxTime > 0 {
+		t.ctx, t.cancel = context.WithTimeout(ctx, budget.MaxTime)
+	} else {
+		t.ctx, t.cancel = context.WithCancel(ctx)
+	}
+	return &t
+}
+
+// Done returns a channel that is closed when the context is cancelled or the time
+// budget is used up
+func (t *budgetTracker) Done() <-chan struct{} {
+	return t.ctx.Done()
+}
+
+// err returns the reason for stopping, or nil if the algorithm can continue
+func (t *budgetTracker) err(nodes int, iterations int) error {
+	if err := t.parent.Err(); err != nil {
+		return err
+	}
+
+	if t.ctx.Err() != nil {
+		return ErrBudgetExhausted
+	}
+
+	if t.budget.MaxNodes > 0 && nodes >= t.budget.MaxNodes {
+		return ErrBudgetExhausted
+	}
+
+	if t.budget.MaxIterations > 0 && iterations >= t.budget.MaxIterations {
+		return ErrBudgetExhausted
+	}
+	return nil
+}
+
+// stop releases the resources of the tracker
+func (t *budgetTracker) stop() {
+	t.cancel()
+}
@@ -0,0 +1,158 @@
+package featselect
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/davidkleiven/goselect/featselect/testfeatselect"
+	"gonum.org/v1/gonum/mat"
+)
+
+func TestBudgetTracker(t *testing.T) {
+	for i, test := range []struct {
+		budget     Budget
+		nodes      int
+		iterations int
+		want       error
+	}{
+		{budget: Budget{}, nodes: 100, iterations: 100, want: nil},
+		{budget: Budget{MaxNodes: 10}, nodes: 9, want: nil},
+		{budget: Budget{MaxNodes: 10}, nodes: 10, want: ErrBudgetExhausted},
+		{budget: Budget{MaxIterations: 5}, iterations: 5, want: ErrBudgetExhausted},
+		{budget: Budget{MaxTime: time.Hour}, nodes: 1000, want: nil},
+	} {
+		tracker := newBudgetTracker(context.Background(), test.budget)
+		if err := tracker.err(test.nodes, test.iterations); err != test.want {
+			t.Errorf("Test #%d: Expected %v got %v", i, test.want, err)
+		}
+		tracker.stop()
+	}
+
+	tracker := newBudgetTracker(context.Background(), Budget{MaxTime: time.Nanosecond})
+	defer tracker.stop()
+	<-tracker.Done()
+	if err := tracker.err(0, 0); err != ErrBudgetExhausted {
+		t.Errorf("Expected ErrBudgetExhausted when the time is used got %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	tracker = newBudgetTracker(ctx, Budget{MaxTime: time.Hour})
+	defer tracker.stop()
+	if err := tracker.err(0, 0); err != context.Canceled {
+		t.Errorf("Expected context.Canceled got %v", err)
+	}
+}
+
+func budgetTestData() (*mat.Dense, []float64) {
+	X := mat.NewDense(10, 6, []float64{
+		1.0, 0.0, 0.0, 0.0, 0.3, 1.0,
+		1.0, 1.0, 1.0, 1.0, -0.2, 3.0,
+		1.0, 2.0, 4.0, 8.0, 0.5, -1.0,
+		1.0, 3.0, 9.0, 15.0, 0.1, 2.0,
+		1.0, 4.0, 9.0, 30.0, 0.9, 0.0,
+		1.0, 2.0, 3.0, 6.0, -0.4, 1.0,
+		1.0, -2.0, 5.0, 4.0, 0.0, 5.0,
+		1.0, 5.0, -1.0, 2.0, 0.2, 2.0,
+		1.0, 1.0, 2.0, 3.0, 0.6, -2.0,
+		1.0, 0.0, 1.0, 7.0, -0.8, 4.0})
+	y := []float64{1.0, 2.0, 5.0, 7.0, 10.0, 8.0, 15.0, 3.0, 4.0, 6.0}
+	return X, y
+}
+
+func TestSelectModelContext(t *testing.T) {
+	X, y := budgetTestData()
+	var sp SearchProgress
+
+	highscore := NewHighscore(10)
+	if err := SelectModelContext(context.Background(), X, y, highscore, &sp, nil); err != nil {
+		t.Errorf("Expected the full search to finish got %v", err)
+	}
+
+	params := NewSelectModelOptParams()
+	params.Budget.MaxNodes = 5
+	limited := NewHighscore(10)
+	err := SelectModelContext(context.Background(), X, y, limited, &sp, params)
+	if !errors.Is(err, ErrBudgetExhausted) {
+		t.Errorf("Expected ErrBudgetExhausted got %v", err)
+	}
+
+	if limited.Len() == 0 || limited.Len() >= highscore.Len() {
+		t.Errorf("Expected a partial highscore list. Got %d items (full search %d)", limited.Len(), highscore.Len())
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := SelectModelContext(ctx, X, y, NewHighscore(10), &sp, nil); !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected context.Canceled got %v", err)
+	}
+}
+
+func TestSelectModelSAContext(t *testing.T) {
+	X, y := budgetTestData()
+	res, err := SelectModelSAContext(context.Background(), X, y, 10, Aicc, Budget{MaxIterations: 20})
+	if !errors.Is(err, ErrBudgetExhausted) {
+		t.Errorf("Expected ErrBudgetExhausted got %v", err)
+	}
+
+	if res == nil || len(res.Selected) == 0 || len(res.Scores.Items) == 0 {
+		t.Errorf("Expected the best model found so far got %+v", res)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := SelectModelSAContext(ctx, X, y, 10, Aicc, Budget{}); !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected context.Canceled got %v", err)
+	}
+}
+
+func TestLassoCrdDescPathContext(t *testing.T) {
+	X, y := testfeatselect.GetExampleXY()
+	data := NewNormalizedData(X, y)
+	lambs := Logspace(1e-8, 1e-4, 20)
+	var cov Empirical
+	var correction PureLasso
+
+	full, err := LassoCrdDescPathContext(context.Background(), data, &cov, lambs, 100000, 1e-10, &correction, Budget{})
+	if err != nil || len(full) != len(lambs) {
+		t.Errorf("Expected %d nodes without error got %d (%v)", len(lambs), len(full), err)
+	}
+
+	partial, err := LassoCrdDescPathContext(context.Background(), data, &cov, lambs, 100000, 1e-10, &correction, Budget{MaxIterations: 4})
+	if !errors.Is(err, ErrBudgetExhausted) {
+		t.Errorf("Expected ErrBudgetExhausted got %v", err)
+	}
+
+	if len(partial) != 4 {
+		t.Errorf("Expected 4 nodes got %d", len(partial))
+	}
+
+	for i, n := range partial {
+		if n.Lamb != full[i].Lamb {
+			t.Errorf("Node #%d: Expected lambda %e got %e", i, full[i].Lamb, n.Lamb)
+		}
+	}
+}
+
+func TestCalculateCohenSequenceContext(t *testing.T) {
+	targets := []CohensKappaTarget{
+		&testfeatselect.MockCohenTarget{Mode: 0},
+		&testfeatselect.MockCohenTarget{Mode: 1},
+		&testfeatselect.MockCohenTarget{Mode: 2},
+	}
+
+	hyper, err := CalculateCohenSequenceContext(context.Background(), 20, targets, Budget{MaxIterations: 1})
+	if !errors.Is(err, ErrBudgetExhausted) {
+		t.Errorf("Expected ErrBudgetExhausted got %v", err)
+	}
+
+	if len(hyper) == 0 || hyper["mode"] != 0 {
+		t.Errorf("Expected the hyper parameters of the only evaluated target got %v", hyper)
+	}
+
+	if _, err := CalculateCohenSequenceContext(context.Background(), 20, targets, Budget{}); err != nil {
+		t.Errorf("Expected no error got %v", err)
+	}
+}
@@ -0,0 +1,195 @@
+package featselect
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// CategoricalEncoding determines how a categorical column is expanded into numerical columns
+type CategoricalEncoding int
+
+const (
+	// OneHot adds one column per level
+	OneHot CategoricalEncoding = iota
+
+	// Dummy adds one column per level except for the first (reference) level
+	Dummy
+)
+
+// ParseCategoricalEncoding returns the encoding corresponding to the passed name.
+// Valid names are onehot and dummy
+func ParseCategoricalEncoding(name string) (CategoricalEncoding, error) {
+	switch strings.ToLower(name) {
+	case "onehot":
+		return OneHot, nil
+	case "dummy":
+		return Dummy, nil
+	}
+	return OneHot, fmt.Errorf("unknown categorical encoding %s", name)
+}
+
+// CategoricalGroup holds the columns in the design matrix that originate from
+// the same categorical variable. Features are column indices in X, and Levels
+// are the corresponding category values
+type CategoricalGroup struct {
+	Name     string
+	Levels   []string
+	Features []int
+}
+
+// CategoricalColumnName returns the name of the column that is 1 when the categorical
+// variable name takes the passed value
+func CategoricalColumnName(name string, level string) string {
+	return name + "=" + level
+}
+
+// CategoricalGroupOf returns the index in d.Categorical of the group that feature
+// belongs to. If the feature does not originate from a categorical variable, -1 is returned
+func (d *Dataset) CategoricalGroupOf(feature int) int {
+	for i, g := range d.Categorical {
+		if ExistInt(g.Features, feature) {
+			return i
+		}
+	}
+	return -1
+}
+
+// ExpandGroups returns the selected features where all columns of a categorical
+// variable are added if at least one of them is selected. The result is sorted.
+func (d *Dataset) ExpandGroups(selected []int) []int {
+	res := make([]int, len(selected))
+	copy(res, selected)
+	for _, feat := range selected {
+		if group := d.CategoricalGroupOf(feat); group >= 0 {
+			res = UnionInt(res, d.Categorical[group].Features)
+		}
+	}
+	sort.Ints(res)
+	return res
+}
+
+// categoricalColumns returns the columns in the table that should be treated
+// as categorical. The target column is never auto detected.
+func (t *csvTable) categoricalColumns(names []string, auto bool, targetCol int) ([]int, error) {
+	cols := []int{}
+	for _, name := range names {
+		found := false
+		for i, n := range t.names {
+			if n == name {
+				cols = append(cols, i)
+				found = true
+				break
+			}
+		}
+
+		if !found {
+			return nil, fmt.Errorf("categorical column %s does not exist", name)
+		}
+	}
+
+	if auto {
+		for col := range t.names {
+			if col == targetCol || ExistInt(cols, col) {
+				continue
+			}
+
+			for _, row := range t.rows {
+				if IsMissing(row[col]) {
+					continue
+				}
+
+				if _, err := strconv.ParseFloat(row[col], 64); err != nil {
+					cols = append(cols, col)
+					break
+				}
+			}
+		}
+	}
+
+	if ExistInt(cols, targetCol) {
+		return nil, fmt.Errorf("the target column %s can not be categorical", t.names[targetCol])
+	}
+	sort.Ints(cols)
+	return cols, nil
+}
+
+// expandCategorical replaces all the passed columns with one numerical column per level.
+// The new target column and the groups of expanded columns are returned. The feature
+// indices of the groups refer to the table columns
+func (t *csvTable) expandCategorical(cols []int, encoding CategoricalEncoding, targetCol int) (int, []CategoricalGroup) {
+	if len(cols) == 0 {
+		return targetCol, nil
+	}
+
+	levels := make(map[int][]string)
+	for _, col := range cols {
+		unique := []string{}
+		seen := make(map[string]bool)
+		for _, row := range t.rows {
+			v := row[col]
+			if !IsMissing(v) && !seen[v] {
+				seen[v] = true
+				unique = append(unique, v)
+			}
+		}
+		sort.Strings(unique)
+
+		if encoding == Dummy && len(unique) > 0 {
+			unique = unique[1:]
+		}
+		levels[col] = unique
+	}
+
+	names := []string{}
+	origCols := []int{}
+	groups := []CategoricalGroup{}
+	newTarget := targetCol
+	for col, name := range t.names {
+		if col == targetCol {
+			newTarget = len(names)
+		}
+
+		lv, isCat := levels[col]
+		if !isCat {
+			names = append(names, name)
+			origCols = append(origCols, t.column(col))
+			continue
+		}
+
+		group := CategoricalGroup{Name: name, Levels: lv}
+		for _, level := range lv {
+			group.Features = append(group.Features, len(names))
+			names = append(names, CategoricalColumnName(name, level))
+			origCols = append(origCols, t.column(col))
+		}
+		groups = append(groups, group)
+	}
+
+	for i, row := range t.rows {
+		newRow := make([]string, 0, len(names))
+		for col, v := range row {
+			lv, isCat := levels[col]
+			if !isCat {
+				newRow = append(newRow, v)
+				continue
+			}
+
+			for _, level := range lv {
+				switch {
+				case IsMissing(v):
+					newRow = append(newRow, v)
+				case v == level:
+					newRow = append(newRow, "1")
+				default:
+					newRow = append(newRow, "0")
+				}
+			}
+		}
+		t.rows[i] = newRow
+	}
+	t.names = names
+	t.origCols = origCols
+	return newTarget, groups
+}
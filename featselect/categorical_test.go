@@ -0,0 +1,135 @@
+package featselect
+
+import (
+	"strings"
+	"testing"
+
+	"gonum.org/v1/gonum/mat"
+)
+
+func TestParseCategorical(t *testing.T) {
+	strData := "class,x,batch,y\nb,1.0,B1,2.0\na,2.0,B2,3.0\nc,3.0,B1,4.0\n"
+	for i, test := range []struct {
+		params       *CSVOptParams
+		expectX      *mat.Dense
+		expectNames  []string
+		expectGroups []CategoricalGroup
+	}{
+		{
+			params: &CSVOptParams{Categorical: []string{"class", "batch"}, Encoding: OneHot},
+			expectX: mat.NewDense(3, 6, []float64{
+				0.0, 1.0, 0.0, 1.0, 1.0, 0.0,
+				1.0, 0.0, 0.0, 2.0, 0.0, 1.0,
+				0.0, 0.0, 1.0, 3.0, 1.0, 0.0}),
+			expectNames: []string{"class=a", "class=b", "class=c", "x", "batch=B1", "batch=B2", "y"},
+			expectGroups: []CategoricalGroup{
+				{Name: "class", Levels: []string{"a", "b", "c"}, Features: []int{0, 1, 2}},
+				{Name: "batch", Levels: []string{"B1", "B2"}, Features: []int{4, 5}},
+			},
+		},
+		{
+			params: &CSVOptParams{AutoCategorical: true, Encoding: Dummy},
+			expectX: mat.NewDense(3, 4, []float64{
+				1.0, 0.0, 1.0, 0.0,
+				0.0, 0.0, 2.0, 1.0,
+				0.0, 1.0, 3.0, 0.0}),
+			expectNames: []string{"class=b", "class=c", "x", "batch=B2", "y"},
+			expectGroups: []CategoricalGroup{
+				{Name: "class", Levels: []string{"b", "c"}, Features: []int{0, 1}},
+				{Name: "batch", Levels: []string{"B2"}, Features: []int{3}},
+			},
+		},
+	} {
+		dset, err := ParseCSVWithParams(strings.NewReader(strData), -1, test.params)
+		if err != nil {
+			t.Errorf("Test #%d: unexpected error %v", i, err)
+			continue
+		}
+
+		if !mat.EqualApprox(dset.X, test.expectX, 1e-10) {
+			t.Errorf("Test #%d: Expected:\n%v\nGot:\n%v\n", i, mat.Formatted(test.expectX), mat.Formatted(dset.X))
+		}
+
+		if !strArrayEqual(dset.Names, test.expectNames) {
+			t.Errorf("Test #%d: Expected %v got %v", i, test.expectNames, dset.Names)
+		}
+
+		if len(dset.Categorical) != len(test.expectGroups) {
+			t.Errorf("Test #%d: Expected %d groups got %d", i, len(test.expectGroups), len(dset.Categorical))
+			continue
+		}
+
+		for j, g := range test.expectGroups {
+			got := dset.Categorical[j]
+			if got.Name != g.Name || !strArrayEqual(got.Levels, g.Levels) || !EqualInt(got.Features, g.Features) {
+				t.Errorf("Test #%d: Expected group %v got %v", i, g, got)
+			}
+		}
+	}
+}
+
+func TestCategoricalErrors(t *testing.T) {
+	strData := "class,x,y\nb,1.0,2.0\na,abc,3.0\n"
+
+	params := NewCSVOptParams()
+	params.Categorical = []string{"unknown"}
+	if _, err := ParseCSVWithParams(strings.NewReader(strData), -1, params); err == nil {
+		t.Errorf("Expected error for unknown categorical column")
+	}
+
+	params.Categorical = []string{"y"}
+	if _, err := ParseCSVWithParams(strings.NewReader(strData), -1, params); err == nil {
+		t.Errorf("Expected error for categorical target column")
+	}
+
+	// The column number should refer to the original file
+	params.Categorical = []string{"class"}
+	_, err := ParseCSVWithParams(strings.NewReader(strData), -1, params)
+	if csvErr, ok := err.(*CSVError); !ok || csvErr.Column != 2 || csvErr.Line != 3 {
+		t.Errorf("Expected error at line 3 column 2 got %v", err)
+	}
+}
+
+func TestExpandGroups(t *testing.T) {
+	dset := Dataset{
+		Categorical: []CategoricalGroup{
+			{Name: "class", Levels: []string{"a", "b"}, Features: []int{1, 2}},
+			{Name: "batch", Levels: []string{"B1", "B2", "B3"}, Features: []int{4, 5, 6}},
+		},
+	}
+
+	for i, test := range []struct {
+		selected []int
+		expect   []int
+	}{
+		{selected: []int{0, 3}, expect: []int{0, 3}},
+		{selected: []int{2, 0}, expect: []int{0, 1, 2}},
+		{selected: []int{5, 1}, expect: []int{1, 2, 4, 5, 6}},
+	} {
+		got := dset.ExpandGroups(test.selected)
+		if !EqualInt(got, test.expect) {
+			t.Errorf("Test #%d: Expected %v got %v", i, test.expect, got)
+		}
+	}
+
+	if dset.CategoricalGroupOf(3) != -1 || dset.CategoricalGroupOf(6) != 1 {
+		t.Errorf("Unexpected group returned by CategoricalGroupOf")
+	}
+}
+
+func TestSubsetKeepsCategorical(t *testing.T) {
+	dset := Dataset{
+		Names:     []string{"c=a", "c=b", "x", "y"},
+		TargetCol: 3,
+		Y:         []float64{1.0, 2.0},
+		X:         mat.NewDense(2, 3, []float64{1.0, 0.0, 1.0, 0.0, 1.0, 2.0}),
+		Categorical: []CategoricalGroup{
+			{Name: "c", Levels: []string{"a", "b"}, Features: []int{0, 1}},
+		},
+	}
+
+	sub := dset.GetSubset([]int{2, 1})
+	if len(sub.Categorical) != 1 || !EqualInt(sub.Categorical[0].Features, []int{1}) || sub.Categorical[0].Levels[0] != "b" {
+		t.Errorf("Unexpected categorical groups in subset %v", sub.Categorical)
+	}
+}
@@ -0,0 +1,63 @@
+package featselect
+
+import (
+	"encoding/gob"
+	"fmt"
+	"io"
+	"os"
+)
+
+// Checkpoint holds the state of a branch and bound search, such that it can be
+// resumed by passing it to SelectModel via SelectModelOptParams.Resume
+type Checkpoint struct {
+	NumFeatures int
+	Queue       []*Node
+	Highscore   []*Node
+	NumChecked  int
+	Log2Pruned  float64
+	Evicted     Eviction
+}
+
+// Write encodes the checkpoint in gob format
+func (c *Checkpoint) Write(w io.Writer) error {
+	return gob.NewEncoder(w).Encode(c)
+}
+
+// Save writes the checkpoint to a file. The checkpoint is first written to a temporary
+// file which is then renamed, such that an existing checkpoint is never left half written
+func (c *Checkpoint) Save(fname string) error {
+	tmp := fname + ".tmp"
+	file, err := os.Create(tmp)
+	if err != nil {
+		return err
+	}
+
+	if err := c.Write(file); err != nil {
+		file.Close()
+		return err
+	}
+
+	if err := file.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp, fname)
+}
+
+// ParseCheckpoint decodes a checkpoint in gob format
+func ParseCheckpoint(r io.Reader) (*Checkpoint, error) {
+	var c Checkpoint
+	if err := gob.NewDecoder(r).Decode(&c); err != nil {
+		return nil, fmt.Errorf("parsecheckpoint: %w", err)
+	}
+	return &c, nil
+}
+
+// ReadCheckpoint reads a checkpoint written by Save
+func ReadCheckpoint(fname string) (*Checkpoint, error) {
+	file, err := os.Open(fname)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	return ParseCheckpoint(file)
+}
@@ -0,0 +1,105 @@
+package featselect
+
+import (
+	"bytes"
+	"io/ioutil"
+	"math"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"gonum.org/v1/gonum/mat"
+)
+
+func TestCheckpointRoundTrip(t *testing.T) {
+	n1 := NewNode(2, []bool{true, false, true})
+	n1.Score = -3.0
+	n1.Coeff = []float64{1.0, 2.0}
+	n2 := NewNode(1, []bool{false, true, false})
+	n2.Lower = 2.0
+	n2.Upper = 5.0
+
+	cp := Checkpoint{NumFeatures: 3, Queue: []*Node{n2}, Highscore: []*Node{n1}, NumChecked: 4, Log2Pruned: 1.5}
+	buf := bytes.NewBuffer(nil)
+	if err := cp.Write(buf); err != nil {
+		t.Fatal(err)
+	}
+
+	res, err := ParseCheckpoint(buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if res.NumFeatures != 3 || res.NumChecked != 4 || res.Log2Pruned != 1.5 {
+		t.Errorf("Expected %+v got %+v", cp, res)
+	}
+
+	if len(res.Queue) != 1 || !NodesEqual(res.Queue[0], n2) {
+		t.Errorf("Queue differ")
+	}
+
+	if len(res.Highscore) != 1 || !NodesEqual(res.Highscore[0], n1) {
+		t.Errorf("Highscore differ")
+	}
+
+	if _, err := ParseCheckpoint(bytes.NewBufferString("not a checkpoint")); err == nil {
+		t.Errorf("Expected error for invalid checkpoint")
+	}
+}
+
+func TestSelectModelResume(t *testing.T) {
+	X := mat.NewDense(10, 6, []float64{
+		1.0, 0.0, 0.0, 0.0, 0.3, 1.0,
+		1.0, 1.0, 1.0, 1.0, -0.2, 3.0,
+		1.0, 2.0, 4.0, 8.0, 0.5, -1.0,
+		1.0, 3.0, 9.0, 15.0, 0.1, 2.0,
+		1.0, 4.0, 9.0, 30.0, 0.9, 0.0,
+		1.0, 2.0, 3.0, 6.0, -0.4, 1.0,
+		1.0, -2.0, 5.0, 4.0, 0.0, 5.0,
+		1.0, 5.0, -1.0, 2.0, 0.2, 2.0,
+		1.0, 1.0, 2.0, 3.0, 0.6, -2.0,
+		1.0, 0.0, 1.0, 7.0, -0.8, 4.0})
+	y := []float64{1.0, 2.0, 5.0, 7.0, 10.0, 8.0, 15.0, 3.0, 4.0, 6.0}
+
+	var sp SearchProgress
+	full := NewHighscore(10)
+	SelectModel(X, y, full, &sp, nil)
+
+	dir, err := ioutil.TempDir("", "checkpoint")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	fname := filepath.Join(dir, "bnb.checkpoint")
+
+	// Interrupt the search as soon as possible
+	interrupt := make(chan struct{})
+	close(interrupt)
+	params := NewSelectModelOptParams()
+	params.CheckpointFile = fname
+	params.Interrupt = interrupt
+	partial := NewHighscore(10)
+	SelectModel(X, y, partial, &sp, params)
+
+	cp, err := ReadCheckpoint(fname)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(cp.Queue) == 0 {
+		t.Fatalf("Expected the search to be interrupted before the queue was empty")
+	}
+
+	resumed := NewHighscore(10)
+	params = NewSelectModelOptParams()
+	params.Resume = cp
+	SelectModel(X, y, resumed, &sp, params)
+
+	if math.Abs(resumed.BestScore()-full.BestScore()) > 1e-10 {
+		t.Errorf("Expected best score %f got %f", full.BestScore(), resumed.BestScore())
+	}
+
+	if resumed.Len() != full.Len() {
+		t.Errorf("Expected %d items in the highscore list got %d", full.Len(), resumed.Len())
+	}
+}
@@ -1,6 +1,7 @@
 package featselect
 
 import (
+	"context"
 	"fmt"
 	"math/rand"
 
@@ -60,14 +61,32 @@ func CohensKappaWorker(work <-chan CohensKappaWorkload, res chan<- CohensKappaWo
 
 // CalculateCohenSequence calculates cohens kappa for a collection of values
 func CalculateCohenSequence(numSamples int, targets []CohensKappaTarget) map[string]float64 {
+	bestHyper, _ := CalculateCohenSequenceContext(context.Background(), numSamples, targets, Budget{})
+	return bestHyper
+}
+
+// CalculateCohenSequenceContext calculates cohens kappa in the same way as CalculateCohenSequence,
+// but no new targets are evaluated once ctx is cancelled or the budget is used up. The hyper
+// parameters of the best target evaluated so far are then returned together with ctx.Err() or
+// ErrBudgetExhausted.
+func CalculateCohenSequenceContext(ctx context.Context, numSamples int, targets []CohensKappaTarget, budget Budget) (map[string]float64, error) {
+	tracker := newBudgetTracker(ctx, budget)
+	defer tracker.stop()
+
 	numWorkers := 16
 	workChannel := make(chan CohensKappaWorkload)
 	resChannel := make(chan CohensKappaWorkload)
+	defer close(workChannel)
 	nextTarget := 0
+	var stopErr error
 	for i := 0; i < numWorkers; i++ {
 		go CohensKappaWorker(workChannel, resChannel)
 
-		if nextTarget < len(targets) {
+		if stopErr == nil && nextTarget < len(targets) {
+			stopErr = tracker.err(0, nextTarget)
+		}
+
+		if stopErr == nil && nextTarget < len(targets) {
 			work := CohensKappaWorkload{
 				numSamples: numSamples,
 				target:     targets[nextTarget],
@@ -84,7 +103,7 @@ func CalculateCohenSequence(numSamples int, targets []CohensKappaTarget) map[str
 	allKappas := make([]float64, len(targets))
 	allStrRep := make([]string, len(targets))
 
-	for numReceives < len(targets) {
+	for numReceives < nextTarget {
 		res := <-resChannel
 
 		allKappas[numReceives] = res.kappa
@@ -99,7 +118,11 @@ func CalculateCohenSequence(numSamples int, targets []CohensKappaTarget) map[str
 			bestHyper = hyper
 		}
 
-		if nextTarget < len(targets) {
+		if stopErr == nil && nextTarget < len(targets) {
+			stopErr = tracker.err(0, nextTarget)
+		}
+
+		if stopErr == nil && nextTarget < len(targets) {
 			work := CohensKappaWorkload{
 				numSamples: numSamples,
 				target:     targets[nextTarget],
@@ -108,6 +131,8 @@ func CalculateCohenSequence(numSamples int, targets []CohensKappaTarget) map[str
 			workChannel <- work
 		}
 	}
+	allKappas = allKappas[:numReceives]
+	allStrRep = allStrRep[:numReceives]
 
 	srt := Argsort(allKappas)
 	fmt.Printf("-------------------------------------------------------------------\n")
@@ -117,5 +142,5 @@ func CalculateCohenSequence(numSamples int, targets []CohensKappaTarget) map[str
 		fmt.Printf("%54s Kappa: %3.3f\n", allStrRep[srt[i]], allKappas[srt[i]])
 	}
 	fmt.Printf("-------------------------------------------------------------------\n")
-	return bestHyper
+	return bestHyper, stopErr
 }
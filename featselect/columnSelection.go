@@ -0,0 +1,154 @@
+package featselect
+
+import (
+	"errors"
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+
+	"gonum.org/v1/gonum/mat"
+)
+
+// ErrFeatureNotFound is returned when a name does not match any of the features
+var ErrFeatureNotFound = errors.New("feature not found")
+
+// SetTargetByName makes the column with the passed name the target. The current
+// target column is moved into the design matrix.
+func (d *Dataset) SetTargetByName(name string) error {
+	if d.Names[d.TargetCol] == name {
+		return nil
+	}
+
+	feat, ok := d.featNo(name)
+	if !ok {
+		return fmt.Errorf("settarget: %s: %w", name, ErrFeatureNotFound)
+	}
+
+	if d.CategoricalGroupOf(feat) >= 0 {
+		return fmt.Errorf("settarget: %s is part of a categorical variable", name)
+	}
+
+	// Column indices in Names of the old and new target
+	oldTarget := d.TargetCol
+	newTarget := feat
+	if feat >= oldTarget {
+		newTarget = feat + 1
+	}
+
+	nr, nc := d.X.Dims()
+	X := mat.NewDense(nr, nc, nil)
+	y := make([]float64, nr)
+	for r := 0; r < nr; r++ {
+		for c := 0; c < nc+1; c++ {
+			var value float64
+			if c == oldTarget {
+				value = d.Y[r]
+			} else {
+				value = d.X.At(r, nameIdx2Feat(c, oldTarget))
+			}
+
+			if c == newTarget {
+				y[r] = value
+			} else {
+				X.Set(r, nameIdx2Feat(c, newTarget), value)
+			}
+		}
+	}
+
+	for _, g := range d.Categorical {
+		for i, f := range g.Features {
+			c := f
+			if f >= oldTarget {
+				c = f + 1
+			}
+			g.Features[i] = nameIdx2Feat(c, newTarget)
+		}
+	}
+
+	d.X = X
+	d.Y = y
+	d.TargetCol = newTarget
+	return nil
+}
+
+// nameIdx2Feat converts an index in the Names slice to a column in the design matrix
+func nameIdx2Feat(idx int, targetCol int) int {
+	if idx > targetCol {
+		return idx - 1
+	}
+	return idx
+}
+
+// FeaturesMatching returns the sorted feature numbers of all features whose name match at least one of
+// the passed patterns. The patterns are glob patterns (see path.Match). A pattern that is
+// equal to the name of a categorical variable matches all columns of that variable.
+// If a pattern without any special characters does not match a feature, ErrFeatureNotFound
+// is returned
+func (d *Dataset) FeaturesMatching(patterns []string) ([]int, error) {
+	_, nc := d.X.Dims()
+	matches := []int{}
+	for _, pattern := range patterns {
+		numMatches := 0
+		for feat := 0; feat < nc; feat++ {
+			ok, err := path.Match(pattern, d.GetFeatName(feat))
+			if err != nil {
+				return nil, fmt.Errorf("pattern %s: %w", pattern, err)
+			}
+
+			if ok {
+				matches = UnionInt(matches, []int{feat})
+				numMatches++
+			}
+		}
+
+		for _, g := range d.Categorical {
+			if g.Name == pattern {
+				matches = UnionInt(matches, g.Features)
+				numMatches++
+			}
+		}
+
+		if numMatches == 0 && !strings.ContainsAny(pattern, "*?[\\") {
+			return nil, fmt.Errorf("%s: %w", pattern, ErrFeatureNotFound)
+		}
+	}
+	sort.Ints(matches)
+	return matches, nil
+}
+
+// SelectFeatures returns a new dataset with the features that match at least one of the
+// include patterns and none of the exclude patterns. If include is empty, all features
+// are included. See FeaturesMatching for a description of the patterns.
+func (d *Dataset) SelectFeatures(include []string, exclude []string) (*Dataset, error) {
+	_, nc := d.X.Dims()
+	selected := make([]int, nc)
+	for i := range selected {
+		selected[i] = i
+	}
+
+	var err error
+	if len(include) > 0 {
+		selected, err = d.FeaturesMatching(include)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	excluded, err := d.FeaturesMatching(exclude)
+	if err != nil {
+		return nil, err
+	}
+
+	keep := []int{}
+	for _, feat := range selected {
+		if !ExistInt(excluded, feat) {
+			keep = append(keep, feat)
+		}
+	}
+
+	if len(keep) == 0 {
+		return nil, fmt.Errorf("selectfeatures: no features left after applying include and exclude patterns")
+	}
+	return d.GetSubset(keep), nil
+}
@@ -0,0 +1,103 @@
+package featselect
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"gonum.org/v1/gonum/floats"
+	"gonum.org/v1/gonum/mat"
+)
+
+func TestSetTargetByName(t *testing.T) {
+	dset := Dataset{
+		Names:     []string{"a", "y", "b", "c"},
+		TargetCol: 1,
+		Y:         []float64{1.0, 2.0},
+		X:         mat.NewDense(2, 3, []float64{3.0, 4.0, 5.0, 6.0, 7.0, 8.0}),
+	}
+
+	if err := dset.SetTargetByName("c"); err != nil {
+		t.Errorf("Unexpected error %v", err)
+	}
+
+	expectX := mat.NewDense(2, 3, []float64{3.0, 1.0, 4.0, 6.0, 2.0, 7.0})
+	if !mat.EqualApprox(dset.X, expectX, 1e-10) {
+		t.Errorf("Expected:\n%v\nGot:\n%v\n", mat.Formatted(expectX), mat.Formatted(dset.X))
+	}
+
+	if !floats.EqualApprox(dset.Y, []float64{5.0, 8.0}, 1e-10) || dset.TargetCol != 3 {
+		t.Errorf("Unexpected target %v in column %d", dset.Y, dset.TargetCol)
+	}
+
+	if err := dset.SetTargetByName("unknown"); !errors.Is(err, ErrFeatureNotFound) {
+		t.Errorf("Expected ErrFeatureNotFound got %v", err)
+	}
+}
+
+func TestParseCSVTargetName(t *testing.T) {
+	params := NewCSVOptParams()
+	params.TargetName = "price"
+	dset, err := ParseCSVWithParams(strings.NewReader("id,price,x\n1,2.0,3.0\n2,4.0,5.0\n"), -1, params)
+	if err != nil {
+		t.Errorf("Unexpected error %v", err)
+		return
+	}
+
+	if dset.TargetCol != 1 || !floats.EqualApprox(dset.Y, []float64{2.0, 4.0}, 1e-10) {
+		t.Errorf("Unexpected target %v in column %d", dset.Y, dset.TargetCol)
+	}
+
+	params.TargetName = "cost"
+	if _, err := ParseCSVWithParams(strings.NewReader("id,price,x\n1,2.0,3.0\n"), -1, params); !errors.Is(err, ErrFeatureNotFound) {
+		t.Errorf("Expected ErrFeatureNotFound got %v", err)
+	}
+}
+
+func TestSelectFeatures(t *testing.T) {
+	dset := Dataset{
+		Names:     []string{"id", "temp_1", "temp_2", "c=a", "c=b", "y"},
+		TargetCol: 5,
+		Y:         []float64{1.0},
+		X:         mat.NewDense(1, 5, []float64{1.0, 2.0, 3.0, 4.0, 5.0}),
+		Categorical: []CategoricalGroup{
+			{Name: "c", Levels: []string{"a", "b"}, Features: []int{3, 4}},
+		},
+	}
+
+	for i, test := range []struct {
+		include     []string
+		exclude     []string
+		expectNames []string
+		isErr       bool
+	}{
+		{
+			exclude:     []string{"id"},
+			expectNames: []string{"temp_1", "temp_2", "c=a", "c=b", "y"},
+		},
+		{
+			include:     []string{"temp_*", "c"},
+			exclude:     []string{"temp_2"},
+			expectNames: []string{"temp_1", "c=a", "c=b", "y"},
+		},
+		{
+			include: []string{"pressure"},
+			isErr:   true,
+		},
+		{
+			include: []string{"id"},
+			exclude: []string{"*"},
+			isErr:   true,
+		},
+	} {
+		sub, err := dset.SelectFeatures(test.include, test.exclude)
+		if (err != nil) != test.isErr {
+			t.Errorf("Test #%d: unexpected error state %v", i, err)
+			continue
+		}
+
+		if err == nil && !strArrayEqual(sub.Names, test.expectNames) {
+			t.Errorf("Test #%d: Expected %v got %v", i, test.expectNames, sub.Names)
+		}
+	}
+}
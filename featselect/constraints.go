@@ -0,0 +1,393 @@
+package featselect
+
+import (
+	"fmt"
+	"math"
+)
+
+// Constraints restricts the models considered by the selection algorithms. Features are
+// given by their column number. Include holds features that are part of every model and
+// Exclude features that are never used. Each group in Exclusive holds features of which
+// at most one can be selected. MinFeatures and MaxFeatures limit the number of selected
+// features (there is no upper limit if MaxFeatures is zero). Each group in Together holds
+// features that are either all selected or not selected at all (e.g. the columns of a
+// categorical variable). If Heredity is given, features are only selected together with
+// their parents. A nil *Constraints allows all models.
+type Constraints struct {
+	Include     []int
+	Exclude     []int
+	MinFeatures int
+	MaxFeatures int
+	Exclusive   [][]int
+	Together    [][]int
+	Heredity    *Heredity
+}
+
+// Validate checks that the constraints are consistent and that all features are in the
+// range [0, numFeatures). The returned error wraps ErrInvalidConstraints
+func (c *Constraints) Validate(numFeatures int) error {
+	if c == nil {
+		return nil
+	}
+
+	groups := append([][]int{c.Include, c.Exclude}, c.Exclusive...)
+	for _, g := range append(groups, c.Together...) {
+		for _, f := range g {
+			if f < 0 || f >= numFeatures {
+				return fmt.Errorf("constraints: feature %d out of range (%d features): %w", f, numFeatures, ErrInvalidConstraints)
+			}
+		}
+	}
+
+	included := make([]bool, numFeatures)
+	for _, f := range c.Include {
+		included[f] = true
+	}
+	c.addTogether(included)
+
+	for _, f := range c.Exclude {
+		if included[f] {
+			return fmt.Errorf("constraints: feature %d is both included and excluded: %w", f, ErrInvalidConstraints)
+		}
+	}
+
+	for _, g := range c.Exclusive {
+		numIncluded := 0
+		for _, f := range g {
+			if included[f] {
+				numIncluded++
+			}
+		}
+
+		if numIncluded > 1 {
+			return fmt.Errorf("constraints: more than one included feature in the exclusive group %v: %w", g, ErrInvalidConstraints)
+		}
+	}
+
+	if c.MinFeatures < 0 || c.MaxFeatures < 0 {
+		return fmt.Errorf("constraints: negative number of features: %w", ErrInvalidConstraints)
+	}
+
+	if c.MaxFeatures > 0 && (c.MinFeatures > c.MaxFeatures || NumFeatures(included) > c.MaxFeatures) {
+		return fmt.Errorf("constraints: at most %d features can be selected, but %d are required: %w", c.MaxFeatures,
+			MaxInt([]int{c.MinFeatures, NumFeatures(included)}), ErrInvalidConstraints)
+	}
+
+	if c.MinFeatures > numFeatures-len(c.Exclude) {
+		return fmt.Errorf("constraints: %d features required, but only %d are allowed: %w", c.MinFeatures, numFeatures-len(c.Exclude), ErrInvalidConstraints)
+	}
+
+	if c.Heredity == nil {
+		return nil
+	}
+
+	if err := c.Heredity.validate(numFeatures); err != nil {
+		return err
+	}
+
+	c.Heredity.addParents(included)
+	for _, f := range c.Exclude {
+		if included[f] {
+			return fmt.Errorf("constraints: feature %d is excluded, but required by the included features: %w", f, ErrInvalidConstraints)
+		}
+	}
+	return nil
+}
+
+// Satisfied returns true if the model fulfils all the constraints
+func (c *Constraints) Satisfied(model []bool) bool {
+	return c.feasible(model, len(model))
+}
+
+// feasible returns true if the features before start in model can be completed to a
+// model that satisfies the constraints. It may return true for some infeasible models,
+// but never false for a feasible model
+func (c *Constraints) feasible(model []bool, start int) bool {
+	if c == nil {
+		return true
+	}
+
+	for _, f := range c.Include {
+		if f < start && !model[f] {
+			return false
+		}
+	}
+
+	for _, f := range c.Exclude {
+		if f < start && model[f] {
+			return false
+		}
+	}
+
+	for _, g := range c.Exclusive {
+		if numSelected(g, model, start) > 1 {
+			return false
+		}
+	}
+
+	for _, g := range c.Together {
+		if num := numSelected(g, model, start); num > 0 && num < numFixed(g, start) {
+			return false
+		}
+	}
+
+	gcs, lcs := c.boundModels(model, start)
+	for f, v := range lcs {
+		if v && (!gcs[f] || !c.Heredity.allowed(gcs, f)) {
+			return false
+		}
+	}
+
+	if c.MaxFeatures > 0 && NumFeatures(lcs) > c.MaxFeatures {
+		return false
+	}
+	return NumFeatures(gcs) >= c.MinFeatures
+}
+
+// numSelected returns the number of features in the group that are selected among the
+// first start features of model
+func numSelected(group []int, model []bool, start int) int {
+	num := 0
+	for _, f := range group {
+		if f < start && model[f] {
+			num++
+		}
+	}
+	return num
+}
+
+// numFixed returns the number of features in the group that are before start
+func numFixed(group []int, start int) int {
+	num := 0
+	for _, f := range group {
+		if f < start {
+			num++
+		}
+	}
+	return num
+}
+
+// boundModels returns the largest and smallest model that contain all feasible models
+// where the features before start are as in model. The largest model is the greatest
+// common model without excluded features, features in exclusive groups that already have
+// a selected feature, features in Together groups with a feature that is not selected and
+// features whose parents can not be selected. The smallest is the least common model with
+// the included features, the Together groups that have a selected feature and the parents
+// they require.
+func (c *Constraints) boundModels(model []bool, start int) ([]bool, []bool) {
+	gcs := Gcs(model, start)
+	lcs := Lcs(model, start)
+	if c == nil {
+		return gcs, lcs
+	}
+
+	for _, f := range c.Include {
+		if f >= start {
+			lcs[f] = true
+		}
+	}
+
+	for _, f := range c.Exclude {
+		if f >= start {
+			gcs[f] = false
+		}
+	}
+
+	for _, g := range c.Exclusive {
+		if numSelected(g, model, start) == 0 {
+			continue
+		}
+
+		for _, f := range g {
+			if f >= start {
+				gcs[f] = false
+			}
+		}
+	}
+
+	for _, g := range c.Together {
+		for _, f := range g {
+			if f >= start {
+				lcs[f] = lcs[f] || numSelected(g, lcs, len(lcs)) > 0
+				gcs[f] = gcs[f] && numSelected(g, gcs, len(gcs)) == len(g)
+			}
+		}
+	}
+	c.Heredity.restrict(gcs, lcs, start)
+	return gcs, lcs
+}
+
+// canAdd returns true if feature f, and the features that are selected together with it,
+// can be added to model without violating the excluded features, the exclusive groups,
+// the maximum number of features or the heredity
+func (c *Constraints) canAdd(model []bool, f int) bool {
+	allowed := Constraints{Exclude: c.Exclude, MaxFeatures: c.MaxFeatures, Exclusive: c.Exclusive, Together: c.Together, Heredity: c.Heredity}
+	trial := make([]bool, len(model))
+	copy(trial, model)
+	for _, g := range c.together(f) {
+		trial[g] = true
+	}
+	return allowed.Satisfied(trial)
+}
+
+// together returns the features that are selected or removed together with f, including f
+func (c *Constraints) together(f int) []int {
+	if c != nil {
+		for _, g := range c.Together {
+			if ExistInt(g, f) {
+				return g
+			}
+		}
+	}
+	return []int{f}
+}
+
+// addTogether selects all features in the Together groups that have a selected feature
+func (c *Constraints) addTogether(model []bool) {
+	for _, g := range c.Together {
+		if numSelected(g, model, len(model)) > 0 {
+			setAll(model, g, true)
+		}
+	}
+}
+
+// extendMove returns the features that are flipped when the features in moved are flipped
+// in model, and the Together groups and the heredity are restored. The model is not altered
+func (c *Constraints) extendMove(model []bool, moved []int) []int {
+	if c == nil {
+		return moved
+	}
+	return c.togetherMove(model, c.Heredity.extendMove(model, c.togetherMove(model, moved)))
+}
+
+// togetherMove adds the features that are flipped together with the moved features. A
+// feature in the group of a moved feature is added if it has the same state in model
+func (c *Constraints) togetherMove(model []bool, moved []int) []int {
+	res := make([]int, len(moved))
+	copy(res, moved)
+	for _, f := range moved {
+		for _, g := range c.together(f) {
+			if model[g] == model[f] && !ExistInt(res, g) {
+				res = append(res, g)
+			}
+		}
+	}
+	return res
+}
+
+// initialModel returns a model satisfying the constraints with the included features,
+// and the parents they require, and features added in the order of their column number
+// until MinFeatures is reached.
+// The model has at least one feature, and feature 0 is preferred since it is usually the
+// intercept
+func (c *Constraints) initialModel(numFeatures int) ([]bool, error) {
+	model := make([]bool, numFeatures)
+	if c == nil {
+		model[0] = true
+		return model, nil
+	}
+
+	for _, f := range c.Include {
+		model[f] = true
+	}
+	c.addTogether(model)
+	c.Heredity.addParents(model)
+
+	for f := 0; f < numFeatures; f++ {
+		if (f == 0 || NumFeatures(model) < MaxInt([]int{c.MinFeatures, 1})) && !model[f] && c.canAdd(model, f) {
+			setAll(model, c.together(f), true)
+		}
+	}
+
+	if !c.Satisfied(model) || NumFeatures(model) == 0 {
+		return nil, fmt.Errorf("constraints: could not find a model satisfying the constraints: %w", ErrInvalidConstraints)
+	}
+	return model, nil
+}
+
+// LassoPenalty returns the factor that multiplies the regularisation parameter for each
+// feature. Included features are not penalised (factor 0) and excluded features have an
+// infinite penalty, such that their coefficients are always zero
+func (c *Constraints) LassoPenalty(numFeatures int) []float64 {
+	penalty := make([]float64, numFeatures)
+	for i := range penalty {
+		penalty[i] = 1.0
+	}
+
+	if c == nil {
+		return penalty
+	}
+
+	for _, f := range c.Include {
+		penalty[f] = 0.0
+	}
+
+	for _, f := range c.Exclude {
+		penalty[f] = math.Inf(1)
+	}
+	return penalty
+}
+
+// heredity returns the heredity constraints, or nil if there are none
+func (c *Constraints) heredity() *Heredity {
+	if c == nil {
+		return nil
+	}
+	return c.Heredity
+}
+
+// filterPath returns the nodes in a lasso path where the selection satisfies the number
+// of features, the exclusive and Together groups and the heredity. The included and excluded features
+// are handled by the penalty, and are not checked
+func (c *Constraints) filterPath(path []*LassoLarsNode, numFeatures int) []*LassoLarsNode {
+	if c == nil {
+		return path
+	}
+
+	sizeAndGroups := Constraints{MinFeatures: c.MinFeatures, MaxFeatures: c.MaxFeatures, Exclusive: c.Exclusive, Together: c.Together, Heredity: c.Heredity}
+	filtered := []*LassoLarsNode{}
+	for _, node := range path {
+		if sizeAndGroups.Satisfied(Selected2Model(node.Selection, numFeatures)) {
+			filtered = append(filtered, node)
+		}
+	}
+	return filtered
+}
+
+// constrainedCriterion computes the bounds of a criterion from the largest and smallest
+// models that contain all sub-models satisfying the constraints. The bounds are valid
+// for criteria that increase with the number of features and the residual sum of squares
+type constrainedCriterion struct {
+	Criterion
+	c *Constraints
+}
+
+// Bounds returns a lower and upper bound of the score of all sub-models that satisfy
+// the constraints
+func (cc *constrainedCriterion) Bounds(model []bool, start int, sys LinearSystem) (float64, float64) {
+	gcsMod, lcsMod := cc.c.boundModels(model, start)
+	kGcs := NumFeatures(gcsMod)
+	kLcs := NumFeatures(lcsMod)
+
+	kMin := kLcs
+	if cc.c.MinFeatures > kMin {
+		kMin = cc.c.MinFeatures
+	}
+
+	kMax := kGcs
+	if cc.c.MaxFeatures > 0 && cc.c.MaxFeatures < kMax {
+		kMax = cc.c.MaxFeatures
+	}
+
+	rssLcs := math.MaxFloat64
+	if kLcs > 0 {
+		_, rssLcs = sys.FitModel(lcsMod)
+	}
+
+	rssGcs := RssTol
+	nr, _ := sys.Dims()
+	if kGcs > 0 && kGcs < nr {
+		_, rssGcs = sys.FitModel(gcsMod)
+	}
+	return cc.Score(kMin, nr, rssGcs), cc.Score(kMax, nr, rssLcs)
+}
@@ -0,0 +1,282 @@
+package featselect
+
+import (
+	"context"
+	"errors"
+	"math"
+	"testing"
+
+	"github.com/davidkleiven/goselect/featselect/testfeatselect"
+	"gonum.org/v1/gonum/mat"
+)
+
+func TestConstraintsValidate(t *testing.T) {
+	for i, test := range []struct {
+		c     *Constraints
+		valid bool
+	}{
+		{c: nil, valid: true},
+		{c: &Constraints{Include: []int{0}, Exclude: []int{3}, MaxFeatures: 2, Exclusive: [][]int{{1, 2}}}, valid: true},
+		{c: &Constraints{Include: []int{5}}, valid: false},
+		{c: &Constraints{Include: []int{1}, Exclude: []int{1}}, valid: false},
+		{c: &Constraints{Include: []int{1, 2}, Exclusive: [][]int{{1, 2}}}, valid: false},
+		{c: &Constraints{MinFeatures: 3, MaxFeatures: 2}, valid: false},
+		{c: &Constraints{Include: []int{0, 1, 2}, MaxFeatures: 2}, valid: false},
+		{c: &Constraints{Exclude: []int{0, 1}, MinFeatures: 3}, valid: false},
+		{c: &Constraints{MinFeatures: -1}, valid: false},
+		{c: &Constraints{Include: []int{1}, Together: [][]int{{1, 2}}, MaxFeatures: 2}, valid: true},
+		{c: &Constraints{Include: []int{1}, Exclude: []int{2}, Together: [][]int{{1, 2}}}, valid: false},
+		{c: &Constraints{Together: [][]int{{1, 4}}}, valid: false},
+	} {
+		err := test.c.Validate(4)
+		if (err == nil) != test.valid {
+			t.Errorf("Test #%d: Unexpected result %v", i, err)
+		}
+
+		if err != nil && !errors.Is(err, ErrInvalidConstraints) {
+			t.Errorf("Test #%d: Expected ErrInvalidConstraints got %v", i, err)
+		}
+	}
+}
+
+func TestConstraintsFeasible(t *testing.T) {
+	c := &Constraints{Include: []int{0}, Exclude: []int{4}, MinFeatures: 2, MaxFeatures: 3, Exclusive: [][]int{{1, 2}}}
+	for i, test := range []struct {
+		model     []bool
+		start     int
+		feasible  bool
+		satisfied bool
+	}{
+		{model: []bool{true, true, false, true, false}, start: 5, feasible: true, satisfied: true},
+		{model: []bool{false, true, false, true, false}, start: 1, feasible: false, satisfied: false},
+		{model: []bool{true, true, true, false, false}, start: 3, feasible: false, satisfied: false},
+		{model: []bool{true, true, true, false, false}, start: 2, feasible: true, satisfied: false},
+		{model: []bool{true, false, false, false, true}, start: 4, feasible: false, satisfied: false},
+		{model: []bool{true, false, false, true, false}, start: 4, feasible: true, satisfied: true},
+		{model: []bool{true, false, true, false, false}, start: 3, feasible: true, satisfied: true},
+		{model: []bool{true, true, false, true, true}, start: 3, feasible: true, satisfied: false},
+	} {
+		if got := c.feasible(test.model, test.start); got != test.feasible {
+			t.Errorf("Test #%d: Expected feasible %v got %v", i, test.feasible, got)
+		}
+
+		if got := c.Satisfied(test.model); got != test.satisfied {
+			t.Errorf("Test #%d: Expected satisfied %v got %v", i, test.satisfied, got)
+		}
+	}
+}
+
+func TestTogetherConstraints(t *testing.T) {
+	c := &Constraints{Together: [][]int{{1, 3}}}
+	for i, test := range []struct {
+		model     []bool
+		start     int
+		feasible  bool
+		satisfied bool
+	}{
+		{model: []bool{true, true, false, true}, start: 4, feasible: true, satisfied: true},
+		{model: []bool{true, true, false, false}, start: 2, feasible: true, satisfied: false},
+		{model: []bool{true, true, false, false}, start: 4, feasible: false, satisfied: false},
+		{model: []bool{true, false, false, true}, start: 2, feasible: true, satisfied: false},
+		{model: []bool{true, false, false, false}, start: 4, feasible: true, satisfied: true},
+	} {
+		if got := c.feasible(test.model, test.start); got != test.feasible {
+			t.Errorf("Test #%d: Expected feasible %v got %v", i, test.feasible, got)
+		}
+
+		if got := c.Satisfied(test.model); got != test.satisfied {
+			t.Errorf("Test #%d: Expected satisfied %v got %v", i, test.satisfied, got)
+		}
+	}
+
+	// The group is moved as a whole, and the bounds contain the whole group
+	model := []bool{true, false, false, false}
+	if moved := c.extendMove(model, []int{3}); !EqualInt(moved, []int{3, 1}) {
+		t.Errorf("Expected the move [3 1] got %v", moved)
+	}
+
+	if !c.canAdd(model, 1) || (&Constraints{Together: c.Together, MaxFeatures: 2}).canAdd(model, 1) {
+		t.Errorf("Expected the group to be added as a whole")
+	}
+
+	gcs, lcs := c.boundModels([]bool{true, true, false, false}, 2)
+	if !boolArrayEqual(gcs, []bool{true, true, true, true}) || !boolArrayEqual(lcs, []bool{true, true, false, true}) {
+		t.Errorf("Unexpected bounds %v and %v", gcs, lcs)
+	}
+}
+
+func TestInitialModel(t *testing.T) {
+	for i, test := range []struct {
+		c    *Constraints
+		want []bool
+	}{
+		{c: nil, want: []bool{true, false, false, false}},
+		{c: &Constraints{Exclude: []int{0}}, want: []bool{false, true, false, false}},
+		{c: &Constraints{Include: []int{3}, MinFeatures: 3, Exclusive: [][]int{{0, 1}}}, want: []bool{true, false, true, true}},
+		{c: &Constraints{Include: []int{3}, Together: [][]int{{0, 2}, {1, 3}}}, want: []bool{true, true, true, true}},
+	} {
+		model, err := test.c.initialModel(4)
+		if err != nil {
+			t.Errorf("Test #%d: %v", i, err)
+		}
+
+		if !boolArrayEqual(model, test.want) {
+			t.Errorf("Test #%d: Expected %v got %v", i, test.want, model)
+		}
+	}
+}
+
+// constraintsExample returns a dataset where the best unconstrained model uses the
+// features 0, 1 and 2
+func constraintsExample() (*mat.Dense, []float64) {
+	X := mat.NewDense(30, 6, nil)
+	y := make([]float64, 30)
+	for i := 0; i < 30; i++ {
+		x := 0.1 * float64(i)
+		X.Set(i, 0, 1.0)
+		X.Set(i, 1, x)
+		X.Set(i, 2, x*x)
+		X.Set(i, 3, math.Sin(3.0*x))
+		X.Set(i, 4, math.Cos(2.0*x))
+		X.Set(i, 5, float64(i%4))
+		y[i] = 1.0 + 2.0*x - 0.5*x*x + 0.05*math.Sin(7.0*x)
+	}
+	return X, y
+}
+
+func TestSelectModelConstraints(t *testing.T) {
+	X, y := constraintsExample()
+	sys := &DenseSystem{X: X, Y: y}
+	for i, c := range []*Constraints{
+		{Include: []int{5}},
+		{Exclude: []int{2}},
+		{MaxFeatures: 2},
+		{MinFeatures: 5},
+		{Exclusive: [][]int{{1, 2}}},
+		{Include: []int{3}, Exclusive: [][]int{{1, 2, 4}}, MaxFeatures: 3},
+		{Together: [][]int{{1, 2}}},
+		{Include: []int{3}, Together: [][]int{{2, 4, 5}}, MaxFeatures: 4},
+	} {
+		want := math.MaxFloat64
+		for _, model := range allModels(6) {
+			if NumFeatures(model) == 0 || !c.Satisfied(model) {
+				continue
+			}
+			_, rss := sys.FitModel(model)
+			want = math.Min(want, Aicc(NumFeatures(model), 30, math.Max(rss, RssTol)))
+		}
+
+		var sp SearchProgress
+		params := NewSelectModelOptParams()
+		params.Constraints = c
+		highscore := NewHighscore(20)
+		SelectModel(X, y, highscore, &sp, params)
+
+		if math.Abs(highscore.BestScore()+want) > 1e-8 {
+			t.Errorf("Test #%d: Expected best score %f got %f", i, -want, highscore.BestScore())
+		}
+
+		for item := highscore.Items.Front(); item != nil; item = item.Next() {
+			if model := item.Value.(*Node).Model.ToBools(); !c.Satisfied(model) {
+				t.Errorf("Test #%d: Model %v does not satisfy the constraints", i, model)
+			}
+		}
+	}
+}
+
+func TestSelectModelSAConstrained(t *testing.T) {
+	X, y := constraintsExample()
+	c := &Constraints{Include: []int{3}, Exclude: []int{2}, MinFeatures: 3, MaxFeatures: 3}
+	res, err := SelectModelSAConstrained(X, y, 2, Aicc, c)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, item := range res.Scores.Items {
+		if !c.Satisfied(item.Model.ToBools()) {
+			t.Errorf("Selection %v does not satisfy the constraints", item.Selection())
+		}
+	}
+
+	if _, err := SelectModelSAConstrained(X, y, 2, Aicc, &Constraints{Include: []int{7}}); !errors.Is(err, ErrInvalidConstraints) {
+		t.Errorf("Expected ErrInvalidConstraints got %v", err)
+	}
+
+	stats := NewSufficientStats(6)
+	stats.AddRows(X, y, nil)
+	res, err = SelectModelSAConstrainedFromStats(stats, 2, Aicc, c)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, item := range res.Scores.Items {
+		if !c.Satisfied(item.Model.ToBools()) {
+			t.Errorf("Selection %v from statistics does not satisfy the constraints", item.Selection())
+		}
+	}
+}
+
+func TestOmpConstrained(t *testing.T) {
+	X, y := constraintsExample()
+	c := &Constraints{Include: []int{5}, Exclude: []int{1}, MaxFeatures: 3}
+	res, err := OmpConstrained(X, y, 1e-10, c)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(res.Order) == 0 || res.Order[0] != 5 {
+		t.Errorf("The included feature should be added first. Got order %v", res.Order)
+	}
+
+	if len(res.Order) > 3 {
+		t.Errorf("Expected at most 3 features got %v", res.Order)
+	}
+
+	if res.Coeff[1] != 0.0 || ExistInt(res.Order, 1) {
+		t.Errorf("Feature 1 is excluded but was selected. Order %v", res.Order)
+	}
+
+	if _, err := OmpConstrained(X, y, 1e-10, &Constraints{Together: [][]int{{1, 2}}}); !errors.Is(err, ErrInvalidConstraints) {
+		t.Errorf("Expected ErrInvalidConstraints got %v", err)
+	}
+}
+
+func TestLassoConstrained(t *testing.T) {
+	X, y := testfeatselect.GetExampleXY()
+	data := NewNormalizedData(X, y)
+	c := &Constraints{Include: []int{4}, Exclude: []int{2}}
+	var corr PureLasso
+	var cov Empirical
+	path, err := LassoCrdDescPathConstrained(context.Background(), data, &cov, Logspace(1e-4, 1.0, 10), 10000, 1e-6, &corr, Budget{}, c)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(path) == 0 {
+		t.Fatalf("Expected a non-empty path")
+	}
+
+	for i, node := range path {
+		if !ExistInt(node.Selection, 4) || ExistInt(node.Selection, 2) {
+			t.Errorf("Node #%d: Selection %v does not satisfy the constraints", i, node.Selection)
+		}
+	}
+
+	X, y = testfeatselect.GetExampleXY()
+	data = NewNormalizedData(X, y)
+	var estimator MorsePenroseCD
+	larsPath, err := LassoLarsConstrained(data, 1e-10, &estimator, &Constraints{Exclude: []int{2}, MaxFeatures: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for i, node := range larsPath {
+		if ExistInt(node.Selection, 2) || len(node.Selection) > 2 {
+			t.Errorf("Node #%d: Selection %v does not satisfy the constraints", i, node.Selection)
+		}
+	}
+
+	if _, err := LassoLarsConstrained(data, 1e-10, &estimator, c); !errors.Is(err, ErrInvalidConstraints) {
+		t.Errorf("Expected ErrInvalidConstraints for included features in LARS got %v", err)
+	}
+}
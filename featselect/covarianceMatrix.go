@@ -11,6 +11,12 @@ type CovMat interface {
 	Get(X mat.Matrix) mat.Matrix
 }
 
+// GramCovMat is implemented by covariance matrices that can be computed from XᵀX and
+// the number of data points alone
+type GramCovMat interface {
+	FromGram(gram mat.Symmetric, numRows int) mat.Matrix
+}
+
 // Empirical returns the empirical covariance matrix
 type Empirical struct{}
 
@@ -23,6 +29,14 @@ func (e *Empirical) Get(X mat.Matrix) mat.Matrix {
 	return cov
 }
 
+// FromGram returns the empirical covariance matrix given XᵀX
+func (e *Empirical) FromGram(gram mat.Symmetric, numRows int) mat.Matrix {
+	nc := gram.Symmetric()
+	cov := mat.NewDense(nc, nc, nil)
+	cov.Scale(1.0/float64(numRows), gram)
+	return cov
+}
+
 // Identity use returns the identity matrix as the covariance
 type Identity struct{}
 
@@ -37,6 +51,11 @@ func (i *Identity) Get(X mat.Matrix) mat.Matrix {
 	return res
 }
 
+// FromGram returns the identity matrix
+func (i *Identity) FromGram(gram mat.Symmetric, numRows int) mat.Matrix {
+	return i.Get(gram)
+}
+
 // SparseThresholded is a type that uses a sparse threshold algorithm to
 // make a sparse approximation of the covariance matrix
 type SparseThresholded struct {
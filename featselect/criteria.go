@@ -1,7 +1,9 @@
 package featselect
 
 import (
+	"fmt"
 	"math"
+	"strings"
 
 	"gonum.org/v1/gonum/mat"
 )
@@ -34,11 +36,120 @@ func Bic(numFeat int, numData int, logL float64) float64 {
 	return float64(numFeat)*math.Log(float64(numData)) + float64(numData)*math.Log(logL)
 }
 
+// Criterion is a model selection criterion where lower scores are better
+type Criterion interface {
+	// Score returns the value of the criterion for a model with numFeat features
+	// and residual sum of squares rss, fitted to numData data points
+	Score(numFeat int, numData int, rss float64) float64
+
+	// Bounds returns a lower and an upper bound of the score of all sub-models of
+	// model, where the features before start are fixed
+	Bounds(model []bool, start int, sys LinearSystem) (float64, float64)
+}
+
+// CritFunc is a Criterion given by a function of the number of features, the number
+// of data points and the residual sum of squares. The function must be increasing in
+// both the number of features and the residual sum of squares, such that the bounds
+// from the greatest and least common models are valid
+type CritFunc func(numFeat int, numData int, rss float64) float64
+
+// Score returns the value of the criterion
+func (f CritFunc) Score(numFeat int, numData int, rss float64) float64 {
+	return f(numFeat, numData, rss)
+}
+
+// Bounds returns a lower and upper bound of the score of all sub-models
+func (f CritFunc) Bounds(model []bool, start int, sys LinearSystem) (float64, float64) {
+	return systemBounds(model, start, sys, crit(f))
+}
+
+// Built-in criteria
+var (
+	AicCriterion  Criterion = CritFunc(Aic)
+	AiccCriterion Criterion = CritFunc(Aicc)
+	BicCriterion  Criterion = CritFunc(Bic)
+)
+
+// MallowsCp is Mallows' Cp criterion, RSS/Sigma2 - N + 2k. Sigma2 is the variance
+// of the noise. If it is zero, it is estimated from the model with all features
+// when the search starts
+type MallowsCp struct {
+	Sigma2 float64
+}
+
+// Score returns the value of Cp
+func (c *MallowsCp) Score(numFeat int, numData int, rss float64) float64 {
+	return rss/c.Sigma2 - float64(numData) + 2.0*float64(numFeat)
+}
+
+// Bounds returns a lower and upper bound of Cp for all sub-models
+func (c *MallowsCp) Bounds(model []bool, start int, sys LinearSystem) (float64, float64) {
+	return systemBounds(model, start, sys, c.Score)
+}
+
+// forSystem returns a criterion where the noise variance is set
+func (c *MallowsCp) forSystem(sys LinearSystem) (Criterion, error) {
+	if c.Sigma2 > 0.0 {
+		return c, nil
+	}
+
+	nr, nc := sys.Dims()
+	if nr <= nc {
+		return nil, fmt.Errorf("mallowscp: %d data points and %d features, more data points than features are needed to estimate the noise variance: %w", nr, nc, ErrDimensionMismatch)
+	}
+
+	full := make([]bool, nc)
+	for i := range full {
+		full[i] = true
+	}
+	_, rss := sys.FitModel(full)
+	return &MallowsCp{Sigma2: math.Max(rss, RssTol) / float64(nr-nc)}, nil
+}
+
+// systemCriterion is implemented by criteria that depend on the data, and have
+// to be prepared before the search starts
+type systemCriterion interface {
+	forSystem(sys LinearSystem) (Criterion, error)
+}
+
+// prepareCriterion returns the criterion that should be used for the passed system
+func prepareCriterion(c Criterion, sys LinearSystem) (Criterion, error) {
+	if c == nil {
+		return AiccCriterion, nil
+	}
+
+	if sc, ok := c.(systemCriterion); ok {
+		return sc.forSystem(sys)
+	}
+	return c, nil
+}
+
+// ParseCriterion returns the criterion with the given name |aic|aicc|bic|cp|
+func ParseCriterion(name string) (Criterion, error) {
+	switch strings.ToLower(name) {
+	case "aic":
+		return AicCriterion, nil
+	case "aicc":
+		return AiccCriterion, nil
+	case "bic":
+		return BicCriterion, nil
+	case "cp":
+		return &MallowsCp{}, nil
+	}
+	return nil, fmt.Errorf("unknown criterion %s. Must be one of aic, aicc, bic and cp", name)
+}
+
 // Calculata a lower and upper bound of all sub-models. The bits up until
 // start is common in all models. X is the total design matrix and y is the
 // data points. criteria is a function that calculate a cost for instance aic.
 // The function return lower_bound, upper_bound
 func bounds(model []bool, start int, X mat.Matrix, y []float64, criteria crit) (float64, float64) {
+	return systemBounds(model, start, &DenseSystem{X: X, Y: y}, criteria)
+}
+
+// systemBounds calculates the lower and upper bound of all sub-models in the
+// same way as bounds, but the models are fitted by the passed linear system
+func systemBounds(model []bool, start int, sys LinearSystem, criteria crit) (float64, float64) {
 	gcsMod := Gcs(model, start)
 	lcsMod := Lcs(model, start)
 
@@ -47,21 +158,17 @@ func bounds(model []bool, start int, X mat.Matrix, y []float64, criteria crit) (
 
 	rssLcs := math.MaxFloat64
 	if kLcs > 0 {
-		XLcs := GetDesignMatrix(lcsMod, X)
-		coeffLcs := Fit(XLcs, y)
-		rssLcs = Rss(XLcs, coeffLcs, y)
+		_, rssLcs = sys.FitModel(lcsMod)
 	}
 
 	rssGcs := RssTol
-	nr, _ := X.Dims()
+	nr, _ := sys.Dims()
 	if kGcs < nr {
-		XGcs := GetDesignMatrix(gcsMod, X)
-		coeffGcs := Fit(XGcs, y)
-		rssGcs = Rss(XGcs, coeffGcs, y)
+		_, rssGcs = sys.FitModel(gcsMod)
 	}
 
-	lower := criteria(kLcs, len(y), rssGcs)
-	upper := criteria(kGcs, len(y), rssLcs)
+	lower := criteria(kLcs, nr, rssGcs)
+	upper := criteria(kGcs, nr, rssLcs)
 	return lower, upper
 }
 
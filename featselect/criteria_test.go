@@ -1,6 +1,7 @@
 package featselect
 
 import (
+	"errors"
 	"math"
 	"testing"
 
@@ -43,3 +44,64 @@ func TestBoundsAICC(t *testing.T) {
 		t.Errorf("AICC: bounds: Upper: Expect: %v Got %v", expectUpper, upper)
 	}
 }
+
+func TestParseCriterion(t *testing.T) {
+	for i, test := range []struct {
+		name    string
+		want    float64
+		isError bool
+	}{
+		{name: "aic", want: Aic(2, 10, 3.0)},
+		{name: "AICC", want: Aicc(2, 10, 3.0)},
+		{name: "bic", want: Bic(2, 10, 3.0)},
+		{name: "gic", isError: true},
+	} {
+		c, err := ParseCriterion(test.name)
+		if test.isError {
+			if err == nil {
+				t.Errorf("Test #%d: Expected error", i)
+			}
+			continue
+		}
+
+		if err != nil {
+			t.Errorf("Test #%d: %v", i, err)
+			continue
+		}
+
+		if got := c.Score(2, 10, 3.0); math.Abs(got-test.want) > 1e-12 {
+			t.Errorf("Test #%d: Expected %f got %f", i, test.want, got)
+		}
+	}
+}
+
+func TestMallowsCp(t *testing.T) {
+	X := mat.NewDense(4, 2, []float64{1.0, 0.0, 1.0, 1.0, 1.0, 2.0, 1.0, 3.0})
+	y := []float64{2.0, 4.0, 7.0, 8.0}
+	sys := &DenseSystem{X: X, Y: y}
+
+	_, rssFull := sys.FitModel([]bool{true, true})
+	prepared, err := prepareCriterion(&MallowsCp{}, sys)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cp := prepared.(*MallowsCp)
+	if math.Abs(cp.Sigma2-rssFull/2.0) > 1e-12 {
+		t.Errorf("Expected sigma2 %f got %f", rssFull/2.0, cp.Sigma2)
+	}
+
+	// Cp of the full model is equal to the number of features
+	if got := cp.Score(2, 4, rssFull); math.Abs(got-2.0) > 1e-10 {
+		t.Errorf("Expected Cp 2 for the full model got %f", got)
+	}
+
+	prepared, _ = prepareCriterion(&MallowsCp{Sigma2: 3.0}, sys)
+	if fixed := prepared.(*MallowsCp); fixed.Sigma2 != 3.0 {
+		t.Errorf("A given noise variance should not be changed")
+	}
+
+	square := &DenseSystem{X: mat.NewDense(2, 2, []float64{1.0, 0.0, 1.0, 1.0}), Y: []float64{1.0, 2.0}}
+	if _, err := prepareCriterion(&MallowsCp{}, square); !errors.Is(err, ErrDimensionMismatch) {
+		t.Errorf("Expected ErrDimensionMismatch got %v", err)
+	}
+}
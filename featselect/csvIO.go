@@ -4,22 +4,30 @@ import (
 	"bufio"
 	"encoding/csv"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
+	"math"
 	"os"
 	"strconv"
+	"strings"
 
 	"gonum.org/v1/gonum/floats"
 
 	"gonum.org/v1/gonum/mat"
 )
 
-// Dataset is a structure that holds fitting data for linear fitting
+// Dataset is a structure that holds fitting data for linear fitting. If Weights is
+// not nil, it holds one weight per data point and the name of the weight column
+// in the original file is stored in WeightName
 type Dataset struct {
-	X         *mat.Dense
-	Y         []float64
-	Names     []string
-	TargetCol int
+	X           *mat.Dense
+	Y           []float64
+	Names       []string
+	TargetCol   int
+	Categorical []CategoricalGroup
+	Weights     []float64
+	WeightName  string
 }
 
 // GetFeatName gets the name of the feature corresponding to the i-th column in X
@@ -30,18 +38,49 @@ func (d *Dataset) GetFeatName(i int) string {
 	return d.Names[i+1]
 }
 
+// FeatureNames returns the names of all the columns in X
+func (d *Dataset) FeatureNames() []string {
+	_, nc := d.X.Dims()
+	names := make([]string, nc)
+	for i := range names {
+		names[i] = d.GetFeatName(i)
+	}
+	return names
+}
+
 // FeatNoByName returns the features number corresponding to the
 // passed name
 func (d *Dataset) FeatNoByName(name string) int {
+	featNo, err := d.TryFeatNoByName(name)
+	if err != nil {
+		panic(err)
+	}
+	return featNo
+}
+
+// TryFeatNoByName returns the feature number corresponding to the passed name, or an
+// error wrapping ErrFeatureNotFound if no feature has that name
+func (d *Dataset) TryFeatNoByName(name string) (int, error) {
+	if featNo, ok := d.featNo(name); ok {
+		return featNo, nil
+	}
+	return -1, fmt.Errorf("featnobyname: %s: %w", name, ErrFeatureNotFound)
+}
+
+// featNo returns the feature number corresponding to the passed name. The
+// second return value is false if the name is not a feature
+func (d *Dataset) featNo(name string) (int, bool) {
 	for i, n := range d.Names {
 		if n == name {
 			if i > d.TargetCol {
-				return i - 1
+				return i - 1, true
+			} else if i < d.TargetCol {
+				return i, true
 			}
-			return i
+			return -1, false
 		}
 	}
-	panic("FeatNoByName: Did not find any names that matched")
+	return -1, false
 }
 
 // GetSubset returns a new dataset consisting only of the selected
@@ -62,11 +101,33 @@ func (d *Dataset) GetSubset(features []int) *Dataset {
 
 	copy(subset.Y, d.Y)
 
+	if d.Weights != nil {
+		subset.Weights = make([]float64, nr)
+		copy(subset.Weights, d.Weights)
+		subset.WeightName = d.WeightName
+	}
+
 	for i, v := range features {
 		subset.Names[i] = d.GetFeatName(v)
 	}
 	subset.TargetCol = len(features)
 	subset.Names[len(features)] = d.Names[d.TargetCol]
+
+	for _, g := range d.Categorical {
+		group := CategoricalGroup{Name: g.Name}
+		for i, feat := range g.Features {
+			for col, v := range features {
+				if v == feat {
+					group.Features = append(group.Features, col)
+					group.Levels = append(group.Levels, g.Levels[i])
+				}
+			}
+		}
+
+		if len(group.Features) > 0 {
+			subset.Categorical = append(subset.Categorical, group)
+		}
+	}
 	return &subset
 }
 
@@ -97,60 +158,213 @@ func (d *Dataset) Copy() *Dataset {
 	cpy.Y = make([]float64, len(d.Y))
 	copy(cpy.Y, d.Y)
 	cpy.TargetCol = d.TargetCol
+
+	if d.Weights != nil {
+		cpy.Weights = make([]float64, len(d.Weights))
+		copy(cpy.Weights, d.Weights)
+		cpy.WeightName = d.WeightName
+	}
 	cpy.Names = make([]string, len(d.Names))
 	copy(cpy.Names, d.Names)
+
+	for _, g := range d.Categorical {
+		group := CategoricalGroup{Name: g.Name}
+		group.Levels = append(group.Levels, g.Levels...)
+		group.Features = append(group.Features, g.Features...)
+		cpy.Categorical = append(cpy.Categorical, group)
+	}
 	return &cpy
 }
 
+// CSVError is returned when a CSV file can not be parsed. Line and Column
+// are counted from 1 and refer to the position in the original file
+type CSVError struct {
+	Line   int
+	Column int
+	Err    error
+}
+
+// Error returns a string representation of the error
+func (e *CSVError) Error() string {
+	return fmt.Sprintf("line %d, column %d: %v", e.Line, e.Column, e.Err)
+}
+
+// Unwrap returns the underlying error
+func (e *CSVError) Unwrap() error {
+	return e.Err
+}
+
+// ErrInvalidWeight is returned when a weight is negative or not finite
+var ErrInvalidWeight = errors.New("weights must be finite and non-negative")
+
+// ErrNoData is returned when a CSV file contains a header but no data rows
+var ErrNoData = errors.New("no data rows")
+
+// CSVOptParams holds optional parameters for ParseCSVWithParams. Categorical lists the names
+// of columns that are expanded into one numerical column per level. If AutoCategorical is true,
+// all columns that contain values that are not numbers are treated as categorical. If TargetName
+// is given, it overrides the target column passed to ParseCSVWithParams. Delimiter is the
+// column separator, where 0 means that it is detected from the header. If WeightName is given,
+// that column is used as observation weights, and it is not part of the features.
+type CSVOptParams struct {
+	Delimiter       rune
+	TargetName      string
+	WeightName      string
+	Missing         MissingPolicy
+	Categorical     []string
+	AutoCategorical bool
+	Encoding        CategoricalEncoding
+}
+
+// NewCSVOptParams initialises the optional CSV parameters with the default values
+func NewCSVOptParams() *CSVOptParams {
+	var params CSVOptParams
+	params.Delimiter = 0
+	params.TargetName = ""
+	params.WeightName = ""
+	params.Missing = MissingError
+	params.Categorical = nil
+	params.AutoCategorical = false
+	params.Encoding = OneHot
+	return &params
+}
+
 // ReadCSV reads a dataset from a csv file
-func ReadCSV(fname string, targetCol int) *Dataset {
+func ReadCSV(fname string, targetCol int) (*Dataset, error) {
+	return ReadCSVWithParams(fname, targetCol, nil)
+}
+
+// ReadCSVWithParams reads a dataset from a csv file using the passed optional parameters
+func ReadCSVWithParams(fname string, targetCol int, params *CSVOptParams) (*Dataset, error) {
 	csvFile, err := os.Open(fname)
 	if err != nil {
-		fmt.Printf("Could not open file %s\n", fname)
-		return nil
+		return nil, err
 	}
 	defer csvFile.Close()
-	return ParseCSV(csvFile, targetCol)
+	return ParseCSVWithParams(csvFile, targetCol, params)
 }
 
-// ParseCSV parses data from CSV file. It is assumed that the file starts with a header
-// The values in the column targetCol is placed in y of the returned struct and the rest
+// ParseCSV parses data from CSV file. It is assumed that the file starts with a header,
+// which may start with a comment marker (#). Subsequent lines starting with # are ignored.
+// Commas, tabs, semicolons and whitespace are recognized as delimiters. The values in the column targetCol is placed in y of the returned struct and the rest
 // of the columns are placed in a matrix
-func ParseCSV(handle io.Reader, targetCol int) *Dataset {
-	reader := csv.NewReader(bufio.NewReader(handle))
-	var dset Dataset
+func ParseCSV(handle io.Reader, targetCol int) (*Dataset, error) {
+	return ParseCSVWithParams(handle, targetCol, nil)
+}
 
-	lineNo := 0
-	data := make([][]float64, 0)
-	for {
-		line, err := reader.Read()
-		if err == io.EOF {
-			break
-		} else if err != nil {
-			panic(err)
-		}
+// ParseCSVWithParams parses data from a CSV file in the same way as ParseCSV. Cells that
+// are empty or contain NA, N/A, NaN or null are treated as missing and handled according
+// to params.Missing. Rows where the target value is missing are always dropped, unless
+// the policy is MissingError or MissingKeep. Categorical columns are expanded into columns named
+// name=level, and the target column refers to the column in the file before expansion.
+func ParseCSVWithParams(handle io.Reader, targetCol int, params *CSVOptParams) (*Dataset, error) {
+	if params == nil {
+		params = NewCSVOptParams()
+	}
 
-		if lineNo == 0 {
-			dset.Names = line
-		} else {
-			row := make([]float64, len(line))
-			for i, v := range line {
-				if fl, err := strconv.ParseFloat(v, 64); err == nil {
-					row[i] = fl
-				}
+	table, err := readCSVTable(handle, params.Delimiter)
+	if err != nil {
+		return nil, err
+	}
+
+	if params.TargetName != "" {
+		targetCol = -1
+		for i, n := range table.names {
+			if n == params.TargetName {
+				targetCol = i
+				break
 			}
-			data = append(data, row)
 		}
-		lineNo++
+
+		if targetCol == -1 {
+			return nil, fmt.Errorf("parsecsv: target %s: %w", params.TargetName, ErrFeatureNotFound)
+		}
 	}
 
-	numCol := len(dset.Names)
+	numCol := len(table.names)
 	if targetCol < 0 {
 		targetCol += numCol
 	}
 
+	if targetCol < 0 || targetCol >= numCol {
+		return nil, fmt.Errorf("parsecsv: target column %d out of range (file has %d columns)", targetCol, numCol)
+	}
+
+	catCols, err := table.categoricalColumns(params.Categorical, params.AutoCategorical, targetCol)
+	if err != nil {
+		return nil, err
+	}
+	targetCol, groups := table.expandCategorical(catCols, params.Encoding, targetCol)
+
+	data, err := table.floats(params.Missing)
+	if err != nil {
+		return nil, err
+	}
+
+	weightCol := -1
+	required := []int{targetCol}
+	if params.WeightName != "" {
+		for i, n := range table.names {
+			if n == params.WeightName && i != targetCol {
+				weightCol = i
+			}
+		}
+
+		if weightCol == -1 {
+			return nil, fmt.Errorf("parsecsv: weights %s: %w", params.WeightName, ErrFeatureNotFound)
+		}
+		required = append(required, weightCol)
+
+		for i, row := range data {
+			if row[weightCol] < 0.0 || math.IsInf(row[weightCol], 0) {
+				return nil, &CSVError{Line: table.lines[i], Column: table.column(weightCol), Err: ErrInvalidWeight}
+			}
+		}
+	}
+
+	data, names, err := applyMissingPolicy(table, data, required, params.Missing)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(data) == 0 {
+		return nil, ErrNoData
+	}
+
+	var dset Dataset
+	if weightCol >= 0 {
+		dset.Weights = make([]float64, len(data))
+		for i, row := range data {
+			dset.Weights[i] = row[weightCol]
+			data[i] = append(row[:weightCol], row[weightCol+1:]...)
+		}
+		dset.WeightName = names[weightCol]
+		names = append(names[:weightCol], names[weightCol+1:]...)
+
+		if weightCol < targetCol {
+			targetCol--
+		}
+
+		for _, g := range groups {
+			for i, col := range g.Features {
+				if col > weightCol {
+					g.Features[i] = col - 1
+				}
+			}
+		}
+	}
+
+	dset.Names = names
 	dset.TargetCol = targetCol
-	dset.X = mat.NewDense(len(data), len(data[0])-1, nil)
+	for _, g := range groups {
+		for i, col := range g.Features {
+			if col > targetCol {
+				g.Features[i] = col - 1
+			}
+		}
+		dset.Categorical = append(dset.Categorical, g)
+	}
+	dset.X = mat.NewDense(len(data), len(names)-1, nil)
 	dset.Y = make([]float64, len(data))
 	for row := 0; row < len(data); row++ {
 		xcol := 0
@@ -163,7 +377,282 @@ func ParseCSV(handle io.Reader, targetCol int) *Dataset {
 			}
 		}
 	}
-	return &dset
+	return &dset, nil
+}
+
+// csvTable holds the raw content of a CSV file. lines contains the line number
+// in the original file of each row and origCols the column in the original file
+// of each column (nil if the columns have not been altered)
+type csvTable struct {
+	names    []string
+	rows     [][]string
+	lines    []int
+	origCols []int
+}
+
+// column returns the column number in the original file counted from 1
+func (t *csvTable) column(col int) int {
+	if t.origCols == nil {
+		return col + 1
+	}
+	return t.origCols[col]
+}
+
+// WhitespaceDelimiter is used to split columns on any sequence of spaces and tabs
+const WhitespaceDelimiter = ' '
+
+// CommentMarker marks a line as a comment. If the header starts with the
+// comment marker, the marker is removed
+const CommentMarker = '#'
+
+// ParseDelimiter converts a name to a delimiter. Valid names are auto, tab,
+// whitespace, space or a single character. The delimiter 0 means auto detection
+func ParseDelimiter(name string) (rune, error) {
+	switch strings.ToLower(name) {
+	case "", "auto":
+		return 0, nil
+	case "tab", "\\t", "\t":
+		return '\t', nil
+	case "whitespace", "space", " ":
+		return WhitespaceDelimiter, nil
+	}
+
+	runes := []rune(name)
+	if len(runes) != 1 || runes[0] == CommentMarker || runes[0] == '"' {
+		return 0, fmt.Errorf("invalid delimiter %s", name)
+	}
+	return runes[0], nil
+}
+
+// DetectDelimiter returns the delimiter used in the passed header line. Tab, semicolon
+// and comma are tried in that order. If none of them is present, WhitespaceDelimiter
+// is returned
+func DetectDelimiter(header string) rune {
+	for _, delim := range []rune{'\t', ';', ','} {
+		if strings.ContainsRune(header, delim) {
+			return delim
+		}
+	}
+	return WhitespaceDelimiter
+}
+
+// csvLineReader reads a delimited file record by record. Blank lines and lines starting
+// with CommentMarker are skipped, and a leading comment marker is stripped from the header.
+// Files split on whitespace are read line by line. Other files are read with one
+// csv.Reader, such that quoted fields may contain line breaks.
+type csvLineReader struct {
+	reader   *bufio.Reader
+	delim    rune
+	lineNo   int
+	numLines int
+	header   []string
+	records  *csv.Reader
+	offset   int
+	pending  string
+}
+
+// newCSVLineReader reads the header from the passed handle. If delim is 0, the delimiter
+// is detected from the header
+func newCSVLineReader(handle io.Reader, delim rune) (*csvLineReader, error) {
+	lr := &csvLineReader{reader: bufio.NewReader(handle), delim: delim}
+	for {
+		text, err := lr.readLine()
+		if err == io.EOF {
+			return nil, ErrNoData
+		} else if err != nil {
+			return nil, err
+		}
+
+		text = strings.TrimSpace(text)
+		if text == "" {
+			continue
+		}
+
+		text = strings.TrimSpace(strings.TrimPrefix(text, string(CommentMarker)))
+		if lr.delim == 0 {
+			lr.delim = DetectDelimiter(text)
+		}
+
+		lr.header, err = lr.split(text)
+		if err != nil || lr.delim == WhitespaceDelimiter {
+			return lr, err
+		}
+
+		// The csv.Reader requests one line at a time from Read, so numLines is the
+		// last line of the record that was read most recently
+		lr.offset = lr.numLines
+		lr.records = csv.NewReader(lr)
+		lr.records.Comma = lr.delim
+		lr.records.Comment = CommentMarker
+		lr.records.FieldsPerRecord = len(lr.header)
+		lr.records.TrimLeadingSpace = lr.delim != '\t'
+		return lr, nil
+	}
+}
+
+// readLine returns the next line without the line ending
+func (lr *csvLineReader) readLine() (string, error) {
+	text, err := lr.reader.ReadString('\n')
+	if err == io.EOF && text != "" {
+		err = nil
+	}
+
+	if err != nil {
+		return "", err
+	}
+	lr.numLines++
+	lr.lineNo = lr.numLines
+	return strings.TrimRight(text, "\r\n"), nil
+}
+
+// Read passes the remaining lines to the csv.Reader. At most one line is returned
+// per call
+func (lr *csvLineReader) Read(p []byte) (int, error) {
+	if lr.pending == "" {
+		text, err := lr.reader.ReadString('\n')
+		if text == "" {
+			return 0, err
+		}
+		lr.numLines++
+		lr.pending = text
+	}
+	n := copy(p, lr.pending)
+	lr.pending = lr.pending[n:]
+	return n, nil
+}
+
+// Next returns the fields of the next record that contains data. At the end
+// of the file, io.EOF is returned
+func (lr *csvLineReader) Next() ([]string, error) {
+	if lr.records != nil {
+		return lr.nextRecord()
+	}
+
+	for {
+		text, err := lr.readLine()
+		if err != nil {
+			return nil, err
+		}
+
+		trimmed := strings.TrimSpace(text)
+		if trimmed == "" || trimmed[0] == CommentMarker {
+			continue
+		}
+
+		line, err := lr.split(text)
+		if err != nil {
+			return nil, err
+		}
+
+		if len(line) != len(lr.header) {
+			return nil, lr.fieldCountError(len(line))
+		}
+		return line, nil
+	}
+}
+
+// nextRecord returns the next record from the csv.Reader. The line number is moved to the
+// first line of the record
+func (lr *csvLineReader) nextRecord() ([]string, error) {
+	line, err := lr.records.Read()
+	lr.lineNo = lr.numLines
+	for i := range line {
+		lr.lineNo -= strings.Count(line[i], "\n")
+		line[i] = strings.TrimSpace(line[i])
+	}
+
+	if err == io.EOF {
+		return nil, err
+	} else if pe, ok := err.(*csv.ParseError); ok {
+		if pe.Err == csv.ErrFieldCount {
+			return nil, lr.fieldCountError(len(line))
+		}
+		return nil, &CSVError{Line: lr.offset + pe.Line, Column: pe.Column, Err: pe.Err}
+	} else if err != nil {
+		return nil, &CSVError{Line: lr.lineNo, Column: 1, Err: err}
+	}
+	return line, nil
+}
+
+// fieldCountError returns the error reported when a line does not have one
+// field per column in the header
+func (lr *csvLineReader) fieldCountError(numFields int) error {
+	return &CSVError{
+		Line:   lr.lineNo,
+		Column: numFields,
+		Err:    fmt.Errorf("expected %d fields, got %d: %w", len(lr.header), numFields, csv.ErrFieldCount),
+	}
+}
+
+// split splits the header or a line of a whitespace delimited file into fields
+func (lr *csvLineReader) split(text string) ([]string, error) {
+	if lr.delim == WhitespaceDelimiter {
+		return strings.Fields(text), nil
+	}
+
+	reader := csv.NewReader(strings.NewReader(text))
+	reader.Comma = lr.delim
+	reader.TrimLeadingSpace = lr.delim != '\t'
+	line, err := reader.Read()
+	if err != nil {
+		if pe, ok := err.(*csv.ParseError); ok {
+			return nil, &CSVError{Line: lr.lineNo, Column: pe.Column, Err: pe.Err}
+		}
+		return nil, &CSVError{Line: lr.lineNo, Column: 1, Err: err}
+	}
+
+	for i := range line {
+		line[i] = strings.TrimSpace(line[i])
+	}
+	return line, nil
+}
+
+// readCSVTable reads the header and all rows of a CSV file without converting the values
+func readCSVTable(handle io.Reader, delim rune) (*csvTable, error) {
+	lr, err := newCSVLineReader(handle, delim)
+	if err != nil {
+		return nil, err
+	}
+
+	var table csvTable
+	table.names = lr.header
+	for {
+		line, err := lr.Next()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			return nil, err
+		}
+		table.rows = append(table.rows, line)
+		table.lines = append(table.lines, lr.lineNo)
+	}
+	return &table, nil
+}
+
+// floats converts all cells to floats. Missing values are represented by NaN. If the
+// policy is MissingError, a missing value results in an error
+func (t *csvTable) floats(policy MissingPolicy) ([][]float64, error) {
+	data := make([][]float64, len(t.rows))
+	for i, line := range t.rows {
+		row := make([]float64, len(line))
+		for j, v := range line {
+			if IsMissing(v) {
+				if policy == MissingError {
+					return nil, &CSVError{Line: t.lines[i], Column: t.column(j), Err: ErrMissingValue}
+				}
+				row[j] = math.NaN()
+				continue
+			}
+
+			fl, err := strconv.ParseFloat(v, 64)
+			if err != nil {
+				return nil, &CSVError{Line: t.lines[i], Column: t.column(j), Err: err}
+			}
+			row[j] = fl
+		}
+		data[i] = row
+	}
+	return data, nil
 }
 
 // Save dataset to a csv file
@@ -176,36 +665,63 @@ func (dset *Dataset) Save(fname string) {
 	dset.SaveHandle(csvFile)
 }
 
-// SaveHandle writes the output to a writer
+// SaveHandle writes the output to a writer. The values are written with the
+// shortest representation that reads back to the exact same number. If the
+// dataset has weights, they are written to the last column
 func (dset *Dataset) SaveHandle(handle io.Writer) {
 	writer := csv.NewWriter(bufio.NewWriter(handle))
 	nrows, ncols := dset.X.Dims()
 	values := make([]string, ncols+1)
+	names := dset.Names
+
+	if dset.Weights != nil {
+		values = append(values, "")
+		names = append(append([]string{}, dset.Names...), dset.weightColumnName())
+	}
 
-	writer.Write(dset.Names)
+	writer.Write(names)
 
 	for i := 0; i < nrows; i++ {
 		shift := 0
 		for j := 0; j < ncols+1; j++ {
 			if j == dset.TargetCol {
-				values[j] = fmt.Sprintf("%f", dset.Y[i])
+				values[j] = formatFloat(dset.Y[i])
 				shift = 1
 			} else {
-				values[j] = fmt.Sprintf("%f", dset.X.At(i, j-shift))
+				values[j] = formatFloat(dset.X.At(i, j-shift))
 			}
 		}
+
+		if dset.Weights != nil {
+			values[ncols+1] = formatFloat(dset.Weights[i])
+		}
 		writer.Write(values)
 	}
 	writer.Flush()
 }
 
+// weightColumnName returns the name used for the weight column when the dataset is saved
+func (dset *Dataset) weightColumnName() string {
+	if dset.WeightName == "" {
+		return "weight"
+	}
+	return dset.WeightName
+}
+
+func formatFloat(v float64) string {
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
+
 // JSONDataset is a type defined to be able to read/write dataset in a simple way from JSON files
 type JSONDataset struct {
-	X         []float64
-	Y         []float64
-	TargetCol int
-	Names     []string
-	Nr, Nc    int
+	X           []float64
+	Y           []float64
+	TargetCol   int
+	Names       []string
+	Nr, Nc      int
+	Categorical []CategoricalGroup `json:",omitempty"`
+	Weights     []float64          `json:",omitempty"`
+	WeightName  string             `json:",omitempty"`
 }
 
 // MarshalJSON is implemented to add the Dataset type to a JSON file
@@ -217,6 +733,9 @@ func (dset *Dataset) MarshalJSON() ([]byte, error) {
 	jData.Y = dset.Y
 	jData.TargetCol = dset.TargetCol
 	jData.Names = dset.Names
+	jData.Categorical = dset.Categorical
+	jData.Weights = dset.Weights
+	jData.WeightName = dset.WeightName
 
 	jData.X = make([]float64, nr*nc)
 	for i := 0; i < nr; i++ {
@@ -237,6 +756,9 @@ func (dset *Dataset) UnmarshalJSON(data []byte) error {
 	dset.Y = jData.Y
 	dset.Names = jData.Names
 	dset.TargetCol = jData.TargetCol
+	dset.Categorical = jData.Categorical
+	dset.Weights = jData.Weights
+	dset.WeightName = jData.WeightName
 	fmt.Printf("%v\n", jData.Nr)
 	dset.X = mat.NewDense(jData.Nr, jData.Nc, jData.X)
 	return nil
@@ -1,6 +1,8 @@
 package featselect
 
 import (
+	"errors"
+	"math"
 	"strings"
 	"testing"
 
@@ -30,7 +32,11 @@ func TestParseCSV(t *testing.T) {
 		},
 	} {
 		reader := strings.NewReader(strData)
-		dset := ParseCSV(reader, test.targCol)
+		dset, err := ParseCSV(reader, test.targCol)
+		if err != nil {
+			t.Errorf("Unexpected error: %v", err)
+			continue
+		}
 
 		if !mat.EqualApprox(dset.X, test.expectX, 1e-10) {
 			t.Errorf("Expected:\n%v\nGot:\n%v\n", mat.Formatted(test.expectX), mat.Formatted(dset.X))
@@ -46,6 +52,242 @@ func TestParseCSV(t *testing.T) {
 	}
 }
 
+func TestParseCSVErrors(t *testing.T) {
+	for i, test := range []struct {
+		data       string
+		targCol    int
+		expectLine int
+		expectCol  int
+	}{
+		{
+			data:       "Feat1,Feat2\n1.0,2.0\n3.0,abc\n",
+			targCol:    -1,
+			expectLine: 3,
+			expectCol:  2,
+		},
+		{
+			data:       "Feat1,Feat2\n1.0,2.0\n\n3.0,4.0,5.0\n",
+			targCol:    -1,
+			expectLine: 4,
+			expectCol:  3,
+		},
+		{
+			data:       "Feat1,Feat2,Feat3\n1.0,NA,2.0\n",
+			targCol:    0,
+			expectLine: 2,
+			expectCol:  2,
+		},
+		{
+			data:       "Feat1,Feat2\n\"1.0\n\",2.0\n3.0,abc\n",
+			targCol:    -1,
+			expectLine: 4,
+			expectCol:  2,
+		},
+	} {
+		_, err := ParseCSV(strings.NewReader(test.data), test.targCol)
+		var csvErr *CSVError
+		if !errors.As(err, &csvErr) {
+			t.Errorf("Test #%d: expected CSVError got %v", i, err)
+			continue
+		}
+
+		if csvErr.Line != test.expectLine || csvErr.Column != test.expectCol {
+			t.Errorf("Test #%d: expected line %d column %d got line %d column %d", i, test.expectLine, test.expectCol, csvErr.Line, csvErr.Column)
+		}
+	}
+
+	if _, err := ParseCSV(strings.NewReader("Feat1,Feat2\n"), 0); err != ErrNoData {
+		t.Errorf("Expected ErrNoData got %v", err)
+	}
+
+	if _, err := ParseCSV(strings.NewReader("Feat1,Feat2\n1.0,2.0\n"), 2); err == nil {
+		t.Errorf("Expected error when target column is out of range")
+	}
+}
+
+func TestParseCSVMissingPolicies(t *testing.T) {
+	strData := "f1,f2,y\n1.0,,1.0\n2.0,4.0,2.0\nNA,8.0,3.0\n4.0,6.0,\n5.0,10.0,5.0\n"
+	for i, test := range []struct {
+		policy      MissingPolicy
+		expectX     *mat.Dense
+		expectY     []float64
+		expectNames []string
+	}{
+		{
+			policy:      MissingDrop,
+			expectX:     mat.NewDense(2, 2, []float64{2.0, 4.0, 5.0, 10.0}),
+			expectY:     []float64{2.0, 5.0},
+			expectNames: []string{"f1", "f2", "y"},
+		},
+		{
+			policy:      MissingMean,
+			expectX:     mat.NewDense(4, 2, []float64{1.0, 22.0 / 3.0, 2.0, 4.0, 8.0 / 3.0, 8.0, 5.0, 10.0}),
+			expectY:     []float64{1.0, 2.0, 3.0, 5.0},
+			expectNames: []string{"f1", "f2", "y"},
+		},
+		{
+			policy:      MissingMedian,
+			expectX:     mat.NewDense(4, 2, []float64{1.0, 8.0, 2.0, 4.0, 2.0, 8.0, 5.0, 10.0}),
+			expectY:     []float64{1.0, 2.0, 3.0, 5.0},
+			expectNames: []string{"f1", "f2", "y"},
+		},
+		{
+			policy: MissingIndicator,
+			expectX: mat.NewDense(4, 4, []float64{
+				1.0, 22.0 / 3.0, 0.0, 1.0,
+				2.0, 4.0, 0.0, 0.0,
+				8.0 / 3.0, 8.0, 1.0, 0.0,
+				5.0, 10.0, 0.0, 0.0}),
+			expectY:     []float64{1.0, 2.0, 3.0, 5.0},
+			expectNames: []string{"f1", "f2", "y", "f1_missing", "f2_missing"},
+		},
+	} {
+		params := NewCSVOptParams()
+		params.Missing = test.policy
+		dset, err := ParseCSVWithParams(strings.NewReader(strData), 2, params)
+		if err != nil {
+			t.Errorf("Test #%d: unexpected error %v", i, err)
+			continue
+		}
+
+		if !mat.EqualApprox(dset.X, test.expectX, 1e-10) {
+			t.Errorf("Test #%d: Expected:\n%v\nGot:\n%v\n", i, mat.Formatted(test.expectX), mat.Formatted(dset.X))
+		}
+
+		if !floats.EqualApprox(dset.Y, test.expectY, 1e-10) {
+			t.Errorf("Test #%d: Expected %v got %v", i, test.expectY, dset.Y)
+		}
+
+		if !strArrayEqual(dset.Names, test.expectNames) {
+			t.Errorf("Test #%d: Expected %v got %v", i, test.expectNames, dset.Names)
+		}
+	}
+}
+
+func TestParseDelimitersAndComments(t *testing.T) {
+	expectX := mat.NewDense(3, 2, []float64{20.0, 184.0, 40.0, 192.0, 60.0, 188.0})
+	expectY := []float64{80.0, 92.0, 88.0}
+	expectNames := []string{"Age", "Height", "Weight"}
+
+	for i, test := range []struct {
+		data  string
+		delim rune
+	}{
+		{
+			data: "# Age, Height, Weight\n   20,    184,     80\n   40,    192,     92\n   60,    188,     88\n",
+		},
+		{
+			data: "#Age\tHeight\tWeight\n20\t184\t80\n# A comment\n40\t192\t92\n\n60\t188\t88",
+		},
+		{
+			data: "Age;Height;Weight\r\n20;184;80\r\n40;192;92\r\n60;188;88\r\n",
+		},
+		{
+			data: "# Age Height Weight\n20  184 80\n40 192  92\n 60\t188 88\n",
+		},
+		{
+			data:  "Age Height Weight\n20 184 80\n40 192 92\n60 188 88\n",
+			delim: WhitespaceDelimiter,
+		},
+	} {
+		params := NewCSVOptParams()
+		params.Delimiter = test.delim
+		dset, err := ParseCSVWithParams(strings.NewReader(test.data), -1, params)
+		if err != nil {
+			t.Errorf("Test #%d: unexpected error %v", i, err)
+			continue
+		}
+
+		if !mat.EqualApprox(dset.X, expectX, 1e-10) {
+			t.Errorf("Test #%d: Expected:\n%v\nGot:\n%v\n", i, mat.Formatted(expectX), mat.Formatted(dset.X))
+		}
+
+		if !floats.EqualApprox(dset.Y, expectY, 1e-10) {
+			t.Errorf("Test #%d: Expected %v got %v", i, expectY, dset.Y)
+		}
+
+		if !strArrayEqual(dset.Names, expectNames) {
+			t.Errorf("Test #%d: Expected %v got %v", i, expectNames, dset.Names)
+		}
+	}
+}
+
+func TestReadCSVTableMultiLineFields(t *testing.T) {
+	data := "name,x\n\"first\nsecond\",1.0\n# A comment\nplain,2.0\n\"a, b\",3.0\n"
+	table, err := readCSVTable(strings.NewReader(data), 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expectRows := [][]string{{"first\nsecond", "1.0"}, {"plain", "2.0"}, {"a, b", "3.0"}}
+	expectLines := []int{2, 5, 6}
+	if len(table.rows) != len(expectRows) {
+		t.Fatalf("Expected %d rows got %d", len(expectRows), len(table.rows))
+	}
+
+	for i, row := range table.rows {
+		if !strArrayEqual(row, expectRows[i]) || table.lines[i] != expectLines[i] {
+			t.Errorf("Row #%d: Expected %q on line %d got %q on line %d", i, expectRows[i], expectLines[i], row, table.lines[i])
+		}
+	}
+}
+
+func TestParseDelimiter(t *testing.T) {
+	for i, test := range []struct {
+		name   string
+		expect rune
+		isErr  bool
+	}{
+		{name: "auto", expect: 0},
+		{name: ",", expect: ','},
+		{name: ";", expect: ';'},
+		{name: "tab", expect: '\t'},
+		{name: "\\t", expect: '\t'},
+		{name: "whitespace", expect: WhitespaceDelimiter},
+		{name: "#", isErr: true},
+		{name: ",;", isErr: true},
+	} {
+		delim, err := ParseDelimiter(test.name)
+		if (err != nil) != test.isErr {
+			t.Errorf("Test #%d: unexpected error state %v", i, err)
+		}
+
+		if delim != test.expect {
+			t.Errorf("Test #%d: expected %q got %q", i, test.expect, delim)
+		}
+	}
+}
+
+func TestParseCSVWeights(t *testing.T) {
+	strData := "x,w,y\n1.0,2.0,3.0\n4.0,,6.0\n7.0,0.5,9.0\n"
+	params := NewCSVOptParams()
+	params.WeightName = "w"
+	params.Missing = MissingMean
+	dset, err := ParseCSVWithParams(strings.NewReader(strData), -1, params)
+	if err != nil {
+		t.Errorf("Unexpected error %v", err)
+		return
+	}
+
+	expectX := mat.NewDense(2, 1, []float64{1.0, 7.0})
+	if !mat.EqualApprox(dset.X, expectX, 1e-10) {
+		t.Errorf("Expected:\n%v\nGot:\n%v\n", mat.Formatted(expectX), mat.Formatted(dset.X))
+	}
+
+	if !floats.EqualApprox(dset.Weights, []float64{2.0, 0.5}, 1e-10) || !floats.EqualApprox(dset.Y, []float64{3.0, 9.0}, 1e-10) {
+		t.Errorf("Unexpected weights %v or target %v", dset.Weights, dset.Y)
+	}
+
+	if !strArrayEqual(dset.Names, []string{"x", "y"}) || dset.TargetCol != 1 || dset.WeightName != "w" {
+		t.Errorf("Unexpected names %v, target column %d or weight name %s", dset.Names, dset.TargetCol, dset.WeightName)
+	}
+
+	_, err = ParseCSVWithParams(strings.NewReader("x,w,y\n1.0,-2.0,3.0\n"), -1, params)
+	if !errors.Is(err, ErrInvalidWeight) {
+		t.Errorf("Expected ErrInvalidWeight got %v", err)
+	}
+}
+
 func TestWriteDataset(t *testing.T) {
 	var writer strings.Builder
 
@@ -57,13 +299,42 @@ func TestWriteDataset(t *testing.T) {
 
 	dset.SaveHandle(&writer)
 	str := writer.String()
-	expect := "feat1,target,feat2\n1.000000,-1.000000,2.000000\n3.000000,-3.000000,4.000000\n"
+	expect := "feat1,target,feat2\n1,-1,2\n3,-3,4\n"
 
 	if str != expect {
 		t.Errorf("\nExpected\n%v\nGot\n%v\n", expect, str)
 	}
 }
 
+func TestSaveParseRoundTrip(t *testing.T) {
+	var dset Dataset
+	dset.X = mat.NewDense(2, 2, []float64{1e-9, 1.0 / 3.0, -2.5e12, math.Pi})
+	dset.Y = []float64{4e-300, -0.1}
+	dset.TargetCol = 0
+	dset.Names = []string{"target", "feat1", "feat2"}
+	dset.Weights = []float64{0.25, 1e-7}
+	dset.WeightName = "w"
+
+	var writer strings.Builder
+	dset.SaveHandle(&writer)
+
+	params := NewCSVOptParams()
+	params.WeightName = "w"
+	res, err := ParseCSVWithParams(strings.NewReader(writer.String()), 0, params)
+	if err != nil {
+		t.Errorf("Unexpected error %v", err)
+		return
+	}
+
+	if !mat.Equal(res.X, dset.X) || !floats.Equal(res.Y, dset.Y) || !floats.Equal(res.Weights, dset.Weights) {
+		t.Errorf("Round trip is not exact.\nExpected\n%v\nGot\n%v\n", dset, res)
+	}
+
+	if res.TargetCol != 0 || !strArrayEqual(res.Names, dset.Names) {
+		t.Errorf("Expected names %v (target 0) got %v (target %d)", dset.Names, res.Names, res.TargetCol)
+	}
+}
+
 func TestGetFeatName(t *testing.T) {
 	for i, test := range []struct {
 		dset   Dataset
@@ -0,0 +1,178 @@
+package featselect
+
+import (
+	"encoding/json"
+	"math"
+	"sort"
+
+	"gonum.org/v1/gonum/mat"
+)
+
+// ColumnProfile holds summary statistics of one column in a dataset. Missing values
+// (NaN) are not included in the statistics. TargetCorrelation is the Pearson
+// correlation with the target, and NaN if it is not defined (e.g. for constant columns)
+type ColumnProfile struct {
+	Name              string
+	Mean              float64
+	Std               float64
+	Min               float64
+	Max               float64
+	Missing           int
+	Constant          bool
+	TargetCorrelation float64
+}
+
+// MarshalJSON encodes the profile as JSON. Values that are NaN are written as null
+func (c ColumnProfile) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		Name              string
+		Mean              *float64
+		Std               *float64
+		Min               *float64
+		Max               *float64
+		Missing           int
+		Constant          bool
+		TargetCorrelation *float64
+	}{
+		Name:              c.Name,
+		Mean:              jsonNumber(c.Mean),
+		Std:               jsonNumber(c.Std),
+		Min:               jsonNumber(c.Min),
+		Max:               jsonNumber(c.Max),
+		Missing:           c.Missing,
+		Constant:          c.Constant,
+		TargetCorrelation: jsonNumber(c.TargetCorrelation),
+	})
+}
+
+// FeaturePair holds the correlation between two features
+type FeaturePair struct {
+	First       string
+	Second      string
+	Correlation float64
+}
+
+// DatasetProfile is a summary of a dataset
+type DatasetProfile struct {
+	NumRows  int
+	Target   ColumnProfile
+	Features []ColumnProfile
+	TopPairs []FeaturePair
+}
+
+// Describe returns a profile of the dataset with statistics for each feature and
+// the target. TopPairs holds the (at most) numPairs feature pairs with the largest
+// absolute correlation. Weights are not taken into account.
+func (d *Dataset) Describe(numPairs int) *DatasetProfile {
+	nr, nc := d.X.Dims()
+	var profile DatasetProfile
+	profile.NumRows = nr
+	profile.Target = newColumnProfile(d.Names[d.TargetCol], d.Y, d.Y)
+	profile.Target.TargetCorrelation = math.NaN()
+
+	cols := make([][]float64, nc)
+	for j := 0; j < nc; j++ {
+		cols[j] = mat.Col(nil, j, d.X)
+		profile.Features = append(profile.Features, newColumnProfile(d.GetFeatName(j), cols[j], d.Y))
+	}
+
+	pairs := []FeaturePair{}
+	for i := 0; i < nc; i++ {
+		if profile.Features[i].Constant {
+			continue
+		}
+
+		for j := i + 1; j < nc; j++ {
+			if profile.Features[j].Constant {
+				continue
+			}
+
+			corr := Correlation(cols[i], cols[j])
+			if !math.IsNaN(corr) {
+				pairs = append(pairs, FeaturePair{First: d.GetFeatName(i), Second: d.GetFeatName(j), Correlation: corr})
+			}
+		}
+	}
+
+	sort.SliceStable(pairs, func(i, j int) bool {
+		return math.Abs(pairs[i].Correlation) > math.Abs(pairs[j].Correlation)
+	})
+
+	if len(pairs) > numPairs {
+		pairs = pairs[:numPairs]
+	}
+	profile.TopPairs = pairs
+	return &profile
+}
+
+// Correlation returns the Pearson correlation coefficient between x and y. Only
+// entries where both x and y are not NaN are used. If the correlation is not
+// defined, NaN is returned
+func Correlation(x []float64, y []float64) float64 {
+	xc := []float64{}
+	yc := []float64{}
+	for i := range x {
+		if !math.IsNaN(x[i]) && !math.IsNaN(y[i]) {
+			xc = append(xc, x[i])
+			yc = append(yc, y[i])
+		}
+	}
+
+	if len(xc) < 2 {
+		return math.NaN()
+	}
+
+	muX := Mean(xc)
+	muY := Mean(yc)
+	cov := 0.0
+	varX := 0.0
+	varY := 0.0
+	for i := range xc {
+		cov += (xc[i] - muX) * (yc[i] - muY)
+		varX += (xc[i] - muX) * (xc[i] - muX)
+		varY += (yc[i] - muY) * (yc[i] - muY)
+	}
+
+	if varX == 0.0 || varY == 0.0 {
+		return math.NaN()
+	}
+	return cov / math.Sqrt(varX*varY)
+}
+
+// newColumnProfile calculates the statistics of the passed values
+func newColumnProfile(name string, values []float64, target []float64) ColumnProfile {
+	profile := ColumnProfile{Name: name, Min: math.NaN(), Max: math.NaN()}
+	present := []float64{}
+	for _, v := range values {
+		if math.IsNaN(v) {
+			profile.Missing++
+			continue
+		}
+		present = append(present, v)
+	}
+
+	profile.Mean = math.NaN()
+	profile.Std = math.NaN()
+	if len(present) > 0 {
+		profile.Mean = Mean(present)
+		profile.Std = Std(present)
+		profile.Min = present[0]
+		profile.Max = present[0]
+		for _, v := range present {
+			profile.Min = math.Min(profile.Min, v)
+			profile.Max = math.Max(profile.Max, v)
+		}
+	}
+
+	profile.Constant = profile.Min == profile.Max || len(present) == 0
+	profile.TargetCorrelation = Correlation(values, target)
+	return profile
+}
+
+// jsonNumber returns nil if v can not be represented in JSON
+func jsonNumber(v float64) *float64 {
+	if math.IsNaN(v) || math.IsInf(v, 0) {
+		return nil
+	}
+	return &v
+}
@@ -0,0 +1,90 @@
+package featselect
+
+import (
+	"encoding/json"
+	"math"
+	"strings"
+	"testing"
+
+	"gonum.org/v1/gonum/mat"
+)
+
+func TestCorrelation(t *testing.T) {
+	for i, test := range []struct {
+		x, y   []float64
+		expect float64
+	}{
+		{x: []float64{1, 2, 3}, y: []float64{2, 4, 6}, expect: 1.0},
+		{x: []float64{1, 2, 3}, y: []float64{3, 2, 1}, expect: -1.0},
+		{x: []float64{1, 2, math.NaN(), 3}, y: []float64{2, 4, 0, 6}, expect: 1.0},
+		{x: []float64{1, 1, 1}, y: []float64{1, 2, 3}, expect: math.NaN()},
+		{x: []float64{1}, y: []float64{1}, expect: math.NaN()},
+	} {
+		corr := Correlation(test.x, test.y)
+		if math.IsNaN(test.expect) != math.IsNaN(corr) || (!math.IsNaN(corr) && math.Abs(corr-test.expect) > 1e-10) {
+			t.Errorf("Test #%d: Expected %f got %f", i, test.expect, corr)
+		}
+	}
+}
+
+func TestDescribe(t *testing.T) {
+	dset := &Dataset{
+		X: mat.NewDense(4, 3, []float64{
+			1.0, 2.0, 5.0,
+			2.0, 4.1, 5.0,
+			3.0, math.NaN(), 5.0,
+			4.0, 7.9, 5.0,
+		}),
+		Y:         []float64{1.0, 2.0, 3.0, 4.0},
+		Names:     []string{"a", "b", "c", "y"},
+		TargetCol: 3,
+	}
+
+	profile := dset.Describe(5)
+	if profile.NumRows != 4 || profile.Target.Name != "y" || len(profile.Features) != 3 {
+		t.Errorf("Unexpected profile %v", profile)
+		return
+	}
+
+	a := profile.Features[0]
+	if math.Abs(a.Mean-2.5) > 1e-10 || a.Min != 1.0 || a.Max != 4.0 || math.Abs(a.TargetCorrelation-1.0) > 1e-10 {
+		t.Errorf("Unexpected profile of a %v", a)
+	}
+
+	if b := profile.Features[1]; b.Missing != 1 || b.Constant || math.Abs(b.Mean-14.0/3.0) > 1e-10 {
+		t.Errorf("Unexpected profile of b %v", b)
+	}
+
+	if c := profile.Features[2]; !c.Constant || !math.IsNaN(c.TargetCorrelation) {
+		t.Errorf("Expected c to be constant %v", c)
+	}
+
+	if len(profile.TopPairs) != 1 || profile.TopPairs[0].First != "a" || profile.TopPairs[0].Second != "b" {
+		t.Errorf("Expected only the pair (a, b) got %v", profile.TopPairs)
+	}
+
+	js, err := json.Marshal(profile)
+	if err != nil {
+		t.Errorf("Unexpected error %v", err)
+	}
+
+	if !strings.Contains(string(js), `"TargetCorrelation":null`) {
+		t.Errorf("Expected undefined correlations to be null. Got %s", js)
+	}
+}
+
+func TestDescribeKeepsMissing(t *testing.T) {
+	strData := "f1,f2,y\n1.0,,1.0\n2.0,4.0,\nNA,8.0,3.0\n"
+	params := NewCSVOptParams()
+	params.Missing = MissingKeep
+	dset, err := ParseCSVWithParams(strings.NewReader(strData), 2, params)
+	if err != nil {
+		t.Errorf("Unexpected error %v", err)
+		return
+	}
+
+	profile := dset.Describe(10)
+	if profile.NumRows != 3 || profile.Target.Missing != 1 || profile.Features[0].Missing != 1 || profile.Features[1].Missing != 1 {
+		t.Errorf("Unexpected missing counts %v", profile)
+	}
+}
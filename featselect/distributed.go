@@ -0,0 +1,539 @@
+package featselect
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"net"
+	"net/rpc"
+	"os"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"gonum.org/v1/gonum/mat"
+)
+
+// workerPollInterval is the time a worker waits before asking for work again when the
+// coordinator has no nodes available
+const workerPollInterval = 200 * time.Millisecond
+
+// lingerTime is the maximum time the coordinator keeps answering requests after the search
+// is finished, such that idle workers are told to stop
+const lingerTime = 5 * time.Second
+
+// DistributedOptParams holds the parameters of a distributed branch and bound search.
+// Each work unit holds at most BatchSize nodes from the queue of the coordinator, and the
+// worker scores at most NodesPerUnit nodes before the unexplored nodes are sent back. A
+// unit that is not returned within LeaseTimeout is put back in the queue
+type DistributedOptParams struct {
+	BatchSize    int
+	NodesPerUnit int
+	LeaseTimeout time.Duration
+}
+
+// NewDistributedOptParams returns the default parameters
+func NewDistributedOptParams() *DistributedOptParams {
+	return &DistributedOptParams{
+		BatchSize:    64,
+		NodesPerUnit: 10000,
+		LeaseTimeout: 10 * time.Minute,
+	}
+}
+
+// WorkRequest is sent by a worker that is ready for new work
+type WorkRequest struct {
+	Worker      string
+	NumFeatures int
+}
+
+// WorkUnit is a set of subtrees handed out to a worker. Best holds the best nodes found so
+// far, which are used to prune the subtrees. If Wait is true, no nodes are available at the
+// moment, and if Finished is true the search is over
+type WorkUnit struct {
+	LeaseID       int
+	NumFeatures   int
+	Nodes         []*Node
+	Best          []*Node
+	HighscoreSize int
+	MaxNodes      int
+	Wait          bool
+	Finished      bool
+}
+
+// WorkResult is sent back by a worker when a work unit is explored. Queue holds the nodes
+// that were not explored, and Highscore the best models found in the unit. Log2Pruned is
+// -Inf if no models were pruned in the unit
+type WorkResult struct {
+	Worker     string
+	LeaseID    int
+	Queue      []*Node
+	Highscore  []*Node
+	NumChecked int
+	Log2Pruned float64
+	Evicted    Eviction
+}
+
+// lease is a work unit handed out to a worker
+type lease struct {
+	worker   string
+	nodes    []*Node
+	deadline time.Time
+}
+
+// Coordinator owns the queue and the highscore list of a branch and bound search, and
+// hands out subtrees to workers running in other processes (see RunWorker). Workers talk
+// to the coordinator via net/rpc over TCP
+type Coordinator struct {
+	mu          sync.Mutex
+	numFeatures int
+	queue       nodeQueue
+	highscore   *Highscore
+	sp          *SearchProgress
+	params      *SelectModelOptParams
+	opts        *DistributedOptParams
+	leases      map[int]*lease
+	nextLease   int
+	numChecked  int
+	log2Pruned  float64
+	evicted     Eviction
+	workers     map[string]bool
+	stopped     bool
+	changed     chan struct{}
+}
+
+// NewCoordinator prepares a search over numFeatures features. The queue, highscore list,
+// budget, checkpoints and interrupt are handled as in SelectModelContext, while the data,
+// the criterion, the cutoff and the constraints are given to the workers. If params or opts
+// are nil, the default values are used
+func NewCoordinator(numFeatures int, highscore *Highscore, sp *SearchProgress, params *SelectModelOptParams, opts *DistributedOptParams) (*Coordinator, error) {
+	if params == nil {
+		params = NewSelectModelOptParams()
+	}
+
+	if opts == nil {
+		opts = NewDistributedOptParams()
+	}
+
+	if numFeatures < 3 {
+		return nil, fmt.Errorf("newcoordinator: %d features, at least 3 are needed: %w", numFeatures, ErrTooFewFeatures)
+	}
+
+	if opts.BatchSize <= 0 || opts.NodesPerUnit <= 0 || opts.LeaseTimeout <= 0 {
+		return nil, fmt.Errorf("newcoordinator: batch size, nodes per unit and lease timeout must be positive")
+	}
+
+	c := &Coordinator{
+		numFeatures: numFeatures,
+		queue:       newNodeQueue(params.Strategy),
+		highscore:   highscore,
+		sp:          sp,
+		params:      params,
+		opts:        opts,
+		leases:      make(map[int]*lease),
+		workers:     make(map[string]bool),
+		changed:     make(chan struct{}, 1),
+	}
+
+	if params.Resume == nil {
+		root := params.RootModel
+		if root == nil {
+			root = make([]bool, numFeatures)
+		}
+
+		if len(root) != numFeatures {
+			return nil, fmt.Errorf("newcoordinator: root model has length %d, expected %d: %w", len(root), numFeatures, ErrDimensionMismatch)
+		}
+		c.queue.Push(NewNode(0, root))
+		return c, nil
+	}
+
+	if params.Resume.NumFeatures != numFeatures {
+		return nil, fmt.Errorf("newcoordinator: the checkpoint was created for %d features, the data has %d: %w", params.Resume.NumFeatures, numFeatures, ErrDimensionMismatch)
+	}
+
+	for _, n := range params.Resume.Queue {
+		c.queue.Push(n)
+	}
+
+	for _, n := range params.Resume.Highscore {
+		highscore.Insert(n)
+	}
+	c.numChecked = params.Resume.NumChecked
+	c.log2Pruned = params.Resume.Log2Pruned
+	c.evicted = params.Resume.Evicted
+	c.updateProgress()
+	return c, nil
+}
+
+// Serve answers requests from workers connecting to l until the search is finished, ctx
+// is cancelled, the budget is used up or the search is interrupted. The listener is closed
+// when Serve returns. If the search was stopped early, ctx.Err(), ErrBudgetExhausted or
+// ErrInterrupted is returned, and the nodes that were not explored are in the checkpoint
+func (c *Coordinator) Serve(ctx context.Context, l net.Listener) error {
+	server := rpc.NewServer()
+	if err := server.RegisterName("Coordinator", &coordinatorRPC{c}); err != nil {
+		return err
+	}
+	go func() {
+		for {
+			conn, err := l.Accept()
+			if err != nil {
+				return
+			}
+			go server.ServeConn(conn)
+		}
+	}()
+	defer l.Close()
+
+	var tick <-chan time.Time
+	if c.params.CheckpointFile != "" && c.params.CheckpointInterval > 0 {
+		ticker := time.NewTicker(c.params.CheckpointInterval)
+		defer ticker.Stop()
+		tick = ticker.C
+	}
+
+	leaseTicker := time.NewTicker(c.opts.LeaseTimeout / 4)
+	defer leaseTicker.Stop()
+
+	interrupt := c.params.Interrupt
+	tracker := newBudgetTracker(ctx, c.params.Budget)
+	defer tracker.stop()
+	var stopErr error
+
+	for !c.finished() {
+		select {
+		case <-c.changed:
+		case <-leaseTicker.C:
+			c.expireLeases(time.Now())
+		case <-tick:
+			c.saveCheckpoint()
+		case <-interrupt:
+			stopErr = ErrInterrupted
+		case <-tracker.Done():
+		}
+
+		if stopErr == nil {
+			c.mu.Lock()
+			stopErr = tracker.err(c.numChecked, 0)
+			c.mu.Unlock()
+		}
+
+		if stopErr != nil {
+			break
+		}
+	}
+
+	// Tell the workers to stop, and wait for the units in progress before the final
+	// checkpoint is written
+	c.mu.Lock()
+	c.stopped = true
+	c.mu.Unlock()
+	c.linger()
+
+	if stopErr != nil && c.params.CheckpointFile != "" {
+		c.saveCheckpoint()
+	}
+	return stopErr
+}
+
+// finished returns true when the queue is empty and no units are in progress
+func (c *Coordinator) finished() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.queue.Len() == 0 && len(c.leases) == 0
+}
+
+// linger keeps answering requests until no units are in progress and all workers have
+// been told to stop, or lingerTime has passed
+func (c *Coordinator) linger() {
+	deadline := time.Now().Add(lingerTime)
+	for time.Now().Before(deadline) {
+		c.mu.Lock()
+		idle := len(c.leases) == 0
+		for _, active := range c.workers {
+			idle = idle && !active
+		}
+		c.mu.Unlock()
+
+		if idle {
+			return
+		}
+		time.Sleep(workerPollInterval / 4)
+	}
+}
+
+// requestWork hands out the next work unit
+func (c *Coordinator) requestWork(req *WorkRequest, unit *WorkUnit) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	unit.NumFeatures = c.numFeatures
+	if req.NumFeatures != c.numFeatures {
+		return
+	}
+
+	if c.stopped {
+		c.workers[req.Worker] = false
+		unit.Finished = true
+		return
+	}
+	c.workers[req.Worker] = true
+
+	for c.queue.Len() > 0 && len(unit.Nodes) < c.opts.BatchSize {
+		unit.Nodes = append(unit.Nodes, c.queue.Pop())
+	}
+
+	if len(unit.Nodes) == 0 {
+		unit.Wait = true
+		return
+	}
+
+	c.nextLease++
+	unit.LeaseID = c.nextLease
+	c.leases[unit.LeaseID] = &lease{worker: req.Worker, nodes: unit.Nodes, deadline: time.Now().Add(c.opts.LeaseTimeout)}
+
+	if front := c.highscore.Items.Front(); front != nil {
+		unit.Best = []*Node{front.Value.(*Node)}
+	}
+	unit.HighscoreSize = c.highscore.MaxItems + 1
+	unit.MaxNodes = c.opts.NodesPerUnit
+}
+
+// submitWork merges the result of a work unit into the search. Results of units that have
+// expired are ignored, since their nodes are already back in the queue
+func (c *Coordinator) submitWork(res *WorkResult) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	defer c.notify()
+
+	if _, ok := c.leases[res.LeaseID]; !ok {
+		return false
+	}
+	delete(c.leases, res.LeaseID)
+
+	for _, n := range res.Highscore {
+		c.highscore.Insert(n)
+	}
+	c.numChecked += res.NumChecked
+	c.log2Pruned = NewLog2Pruned(c.log2Pruned, res.Log2Pruned)
+	c.evicted.merge(res.Evicted)
+
+	for _, n := range res.Queue {
+		c.queue.Push(n)
+	}
+
+	if c.highscore.Len() > 0 {
+		c.queue.Prune(-c.highscore.BestScore())
+	}
+	c.evicted.merge(limitQueue(c.queue, c.params))
+	c.updateProgress()
+	return true
+}
+
+// notify wakes up Serve
+func (c *Coordinator) notify() {
+	select {
+	case c.changed <- struct{}{}:
+	default:
+	}
+}
+
+// expireLeases puts the nodes of units that are not returned in time back in the queue
+func (c *Coordinator) expireLeases(now time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for id, l := range c.leases {
+		if now.After(l.deadline) {
+			fmt.Printf("No result from %s within %s. Returning %d nodes to the queue\n", l.worker, c.opts.LeaseTimeout, len(l.nodes))
+			for _, n := range l.nodes {
+				c.queue.Push(n)
+			}
+			delete(c.leases, id)
+			c.workers[l.worker] = false
+		}
+	}
+}
+
+// updateProgress sets the aggregated progress of all workers. The caller must hold the lock
+func (c *Coordinator) updateProgress() {
+	c.sp.Set(c.highscore.BestScore(), c.numChecked, c.log2Pruned)
+	c.sp.SetEvicted(c.evicted)
+
+	numWorkers := 0
+	for _, active := range c.workers {
+		if active {
+			numWorkers++
+		}
+	}
+	c.sp.SetNumWorkers(numWorkers)
+}
+
+// saveCheckpoint writes the state of the search. Nodes handed out to workers are
+// included in the queue
+func (c *Coordinator) saveCheckpoint() {
+	if c.params.CheckpointFile == "" {
+		return
+	}
+
+	c.mu.Lock()
+	cp := newCheckpoint(c.numFeatures, c.queue, c.highscore, c.numChecked, c.log2Pruned)
+	cp.Evicted = c.evicted
+	for _, l := range c.leases {
+		cp.Queue = append(cp.Queue, l.nodes...)
+	}
+	c.mu.Unlock()
+
+	if err := cp.Save(c.params.CheckpointFile); err != nil {
+		fmt.Printf("Could not write checkpoint: %s\n", err)
+	}
+}
+
+// coordinatorRPC exposes the methods of the coordinator that are called by the workers
+type coordinatorRPC struct {
+	c *Coordinator
+}
+
+// RequestWork is called by a worker that is ready for a new work unit
+func (r *coordinatorRPC) RequestWork(req *WorkRequest, unit *WorkUnit) error {
+	r.c.requestWork(req, unit)
+	return nil
+}
+
+// SubmitWork is called by a worker when a work unit is explored
+func (r *coordinatorRPC) SubmitWork(res *WorkResult, accepted *bool) error {
+	*accepted = r.c.submitWork(res)
+	return nil
+}
+
+// workerCount is used to give workers in the same process different names
+var workerCount int64
+
+// RunWorker connects to the coordinator at addr and explores the subtrees it hands out,
+// until the search is finished or ctx is cancelled. The data, criterion, cutoff and
+// constraints in params must be the same in all workers. If params.Weights is set, the
+// rows are weighted as in SelectModelContext. Checkpoints, Resume and Budget in params
+// are ignored, since they are handled by the coordinator
+func RunWorker(ctx context.Context, addr string, X mat.Matrix, y []float64, params *SelectModelOptParams) error {
+	if nr, _ := X.Dims(); nr != len(y) {
+		return fmt.Errorf("runworker: %d rows in design matrix and %d targets: %w", nr, len(y), ErrDimensionMismatch)
+	}
+
+	if params != nil && params.Weights != nil {
+		if len(params.Weights) != len(y) {
+			return fmt.Errorf("runworker: %d targets and %d weights: %w", len(y), len(params.Weights), ErrDimensionMismatch)
+		}
+		X, y = WeightRows(X, y, params.Weights)
+	}
+	return runWorker(ctx, addr, &DenseSystem{X: X, Y: y}, params)
+}
+
+// RunWorkerFromStats is the same as RunWorker, but the models are fitted from sufficient
+// statistics. Weights must be applied when the statistics are accumulated
+func RunWorkerFromStats(ctx context.Context, addr string, stats *SufficientStats, params *SelectModelOptParams) error {
+	if params != nil && params.Weights != nil {
+		return fmt.Errorf("runworkerfromstats: weights must be applied when the statistics are accumulated")
+	}
+	return runWorker(ctx, addr, stats, params)
+}
+
+func runWorker(ctx context.Context, addr string, sys LinearSystem, params *SelectModelOptParams) error {
+	if params == nil {
+		params = NewSelectModelOptParams()
+	}
+
+	client, err := rpc.Dial("tcp", addr)
+	if err != nil {
+		return fmt.Errorf("runworker: %w", err)
+	}
+	defer client.Close()
+
+	host, _ := os.Hostname()
+	name := fmt.Sprintf("%s/%d/%d", host, os.Getpid(), atomic.AddInt64(&workerCount, 1))
+	sys = newGramSystem(sys)
+	_, numFeatures := sys.Dims()
+
+	for {
+		var unit WorkUnit
+		if err := client.Call("Coordinator.RequestWork", &WorkRequest{Worker: name, NumFeatures: numFeatures}, &unit); err != nil {
+			return fmt.Errorf("runworker: %w", err)
+		}
+
+		if unit.NumFeatures != numFeatures {
+			return fmt.Errorf("runworker: the coordinator has %d features, the worker has %d: %w", unit.NumFeatures, numFeatures, ErrDimensionMismatch)
+		}
+
+		if unit.Finished {
+			return nil
+		}
+
+		if unit.Wait {
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-time.After(workerPollInterval):
+			}
+			continue
+		}
+
+		res, stopErr := exploreUnit(ctx, sys, &unit, params)
+		if res == nil {
+			return stopErr
+		}
+		res.Worker = name
+
+		var accepted bool
+		if err := client.Call("Coordinator.SubmitWork", res, &accepted); err != nil {
+			return fmt.Errorf("runworker: %w", err)
+		}
+
+		if stopErr != nil {
+			return stopErr
+		}
+	}
+}
+
+// exploreUnit runs branch and bound on the subtrees in unit. If the search is stopped because
+// ctx is cancelled, the result is still returned together with the error
+func exploreUnit(ctx context.Context, sys LinearSystem, unit *WorkUnit, params *SelectModelOptParams) (*WorkResult, error) {
+	local := *params
+	local.RootModel = nil
+	local.CheckpointFile = ""
+	local.Interrupt = nil
+	local.Budget = Budget{MaxNodes: unit.MaxNodes}
+	// The unit starts without pruned models, such that only the models pruned in the unit are counted
+	local.Resume = &Checkpoint{NumFeatures: unit.NumFeatures, Queue: unit.Nodes, Highscore: unit.Best, Log2Pruned: math.Inf(-1)}
+
+	highscore := NewHighscore(unit.HighscoreSize)
+	var sp SearchProgress
+	state, err := branchAndBound(ctx, sys, highscore, &sp, &local)
+	if state == nil {
+		return nil, err
+	}
+
+	if errors.Is(err, ErrBudgetExhausted) {
+		err = nil
+	}
+
+	// The best nodes from the coordinator are only used for pruning
+	seeds := make(map[*Node]bool)
+	for _, n := range unit.Best {
+		seeds[n] = true
+	}
+
+	res := &WorkResult{
+		LeaseID:    unit.LeaseID,
+		Queue:      state.Queue,
+		NumChecked: state.NumChecked,
+		Log2Pruned: state.Log2Pruned,
+		Evicted:    state.Evicted,
+	}
+
+	for _, n := range state.Highscore {
+		if !seeds[n] {
+			res.Highscore = append(res.Highscore, n)
+		}
+	}
+	return res, err
+}
@@ -0,0 +1,154 @@
+package featselect
+
+import (
+	"context"
+	"errors"
+	"math"
+	"net"
+	"net/rpc"
+	"testing"
+	"time"
+)
+
+func TestExploreUnitNothingPruned(t *testing.T) {
+	X, y := incrementalTestData(30, 6)
+	unit := &WorkUnit{NumFeatures: 6, Nodes: []*Node{NewNode(0, make([]bool, 6))}, HighscoreSize: 5, MaxNodes: 1}
+	res, err := exploreUnit(context.Background(), &DenseSystem{X: X, Y: y}, unit, NewSelectModelOptParams())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !math.IsInf(res.Log2Pruned, -1) {
+		t.Errorf("Expected no pruned models (-Inf) got %f", res.Log2Pruned)
+	}
+
+	if got := NewLog2Pruned(3.0, res.Log2Pruned); got != 3.0 {
+		t.Errorf("Merging a unit without pruned models should not change the count. Got %f", got)
+	}
+}
+
+// startCoordinator serves a coordinator on a random local port and returns its address
+// and a channel receiving the result of Serve
+func startCoordinator(t *testing.T, c *Coordinator) (string, <-chan error) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	done := make(chan error, 1)
+	go func() {
+		done <- c.Serve(context.Background(), l)
+	}()
+	return l.Addr().String(), done
+}
+
+func TestDistributedSearch(t *testing.T) {
+	X, y := incrementalTestData(40, 10)
+	want := NewHighscore(5)
+	var sp SearchProgress
+	SelectModel(X, y, want, &sp, nil)
+
+	highscore := NewHighscore(5)
+	var progress SearchProgress
+	opts := &DistributedOptParams{BatchSize: 4, NodesPerUnit: 20, LeaseTimeout: time.Minute}
+	c, err := NewCoordinator(10, highscore, &progress, nil, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr, done := startCoordinator(t, c)
+
+	numWorkers := 3
+	workerErr := make(chan error, numWorkers)
+	for i := 0; i < numWorkers; i++ {
+		go func() {
+			workerErr <- RunWorker(context.Background(), addr, X, y, nil)
+		}()
+	}
+
+	for i := 0; i < numWorkers; i++ {
+		if err := <-workerErr; err != nil {
+			t.Errorf("Worker #%d: %v", i, err)
+		}
+	}
+
+	if err := <-done; err != nil {
+		t.Errorf("Coordinator: %v", err)
+	}
+
+	if math.Abs(highscore.BestScore()-want.BestScore()) > 1e-8 {
+		t.Errorf("Expected best score %f got %f", want.BestScore(), highscore.BestScore())
+	}
+
+	score, numChecked, _ := progress.Get()
+	if score != highscore.BestScore() || numChecked == 0 {
+		t.Errorf("Progress not aggregated. Score %f and %d nodes checked", score, numChecked)
+	}
+}
+
+func TestDistributedExpiredLease(t *testing.T) {
+	X, y := incrementalTestData(30, 6)
+	highscore := NewHighscore(5)
+	var progress SearchProgress
+	opts := &DistributedOptParams{BatchSize: 1, NodesPerUnit: 10, LeaseTimeout: 100 * time.Millisecond}
+	c, err := NewCoordinator(6, highscore, &progress, nil, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr, done := startCoordinator(t, c)
+
+	// A worker that takes the root node and never returns it
+	client, err := rpc.Dial("tcp", addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer client.Close()
+
+	var unit WorkUnit
+	if err := client.Call("Coordinator.RequestWork", &WorkRequest{Worker: "lost", NumFeatures: 6}, &unit); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(unit.Nodes) != 1 {
+		t.Fatalf("Expected the root node got %d nodes", len(unit.Nodes))
+	}
+
+	if err := RunWorker(context.Background(), addr, X, y, nil); err != nil {
+		t.Errorf("Worker: %v", err)
+	}
+
+	if err := <-done; err != nil {
+		t.Errorf("Coordinator: %v", err)
+	}
+
+	if c.submitWork(&WorkResult{LeaseID: unit.LeaseID}) {
+		t.Errorf("Results of expired leases should be ignored")
+	}
+
+	if highscore.Len() == 0 {
+		t.Errorf("The root node should have been explored by the second worker")
+	}
+}
+
+func TestDistributedErrors(t *testing.T) {
+	X, y := incrementalTestData(30, 6)
+	var progress SearchProgress
+	if _, err := NewCoordinator(2, NewHighscore(5), &progress, nil, nil); !errors.Is(err, ErrTooFewFeatures) {
+		t.Errorf("Expected ErrTooFewFeatures got %v", err)
+	}
+
+	params := NewSelectModelOptParams()
+	params.Budget = Budget{MaxTime: 500 * time.Millisecond}
+	c, err := NewCoordinator(7, NewHighscore(5), &progress, params, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr, done := startCoordinator(t, c)
+
+	if err := RunWorker(context.Background(), addr, X, y, nil); !errors.Is(err, ErrDimensionMismatch) {
+		t.Errorf("Expected ErrDimensionMismatch got %v", err)
+	}
+
+	if err := <-done; !errors.Is(err, ErrBudgetExhausted) {
+		t.Errorf("Expected ErrBudgetExhausted got %v", err)
+	}
+}
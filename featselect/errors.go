@@ -0,0 +1,39 @@
+package featselect
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ErrDimensionMismatch is returned when the dimensions of the passed matrices and
+// vectors are inconsistent
+var ErrDimensionMismatch = errors.New("inconsistent dimensions")
+
+// ErrConstantColumn is returned when a column that has to vary is constant
+var ErrConstantColumn = errors.New("constant column")
+
+// ErrNumerical is returned when a numerical method breaks down
+var ErrNumerical = errors.New("numerical breakdown")
+
+// ErrTooFewFeatures is returned when there are too few features for a method
+var ErrTooFewFeatures = errors.New("too few features")
+
+// ErrInvalidConstraints is returned when the model constraints are inconsistent or can
+// not be satisfied
+var ErrInvalidConstraints = errors.New("invalid constraints")
+
+// ColumnError is returned when a column of the design matrix is invalid. Column is
+// counted from 0
+type ColumnError struct {
+	Column int
+	Err    error
+}
+
+func (e *ColumnError) Error() string {
+	return fmt.Sprintf("column %d: %v", e.Column, e.Err)
+}
+
+// Unwrap returns the underlying error
+func (e *ColumnError) Unwrap() error {
+	return e.Err
+}
@@ -0,0 +1,149 @@
+package featselect
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"gonum.org/v1/gonum/mat"
+)
+
+func TestColumnError(t *testing.T) {
+	err := &ColumnError{Column: 2, Err: ErrConstantColumn}
+	if err.Error() != "column 2: constant column" {
+		t.Errorf("Unexpected message %s", err.Error())
+	}
+
+	if !errors.Is(err, ErrConstantColumn) {
+		t.Errorf("ColumnError should wrap ErrConstantColumn")
+	}
+}
+
+func TestTryFit(t *testing.T) {
+	X := mat.NewDense(3, 2, []float64{1.0, 0.0, 1.0, 1.0, 1.0, 2.0})
+	for i, test := range []struct {
+		y    []float64
+		w    []float64
+		want error
+	}{
+		{y: []float64{1.0, 2.0, 3.0}, w: nil, want: nil},
+		{y: []float64{1.0, 2.0}, w: nil, want: ErrDimensionMismatch},
+		{y: []float64{1.0, 2.0, 3.0}, w: []float64{1.0, 1.0, 1.0}, want: nil},
+		{y: []float64{1.0, 2.0, 3.0}, w: []float64{1.0}, want: ErrDimensionMismatch},
+	} {
+		_, err := TryFitWeighted(X, test.y, test.w)
+		if !errors.Is(err, test.want) {
+			t.Errorf("Test #%d: Expected %v got %v", i, test.want, err)
+		}
+
+		if test.w == nil {
+			if _, err := TryFit(X, test.y); !errors.Is(err, test.want) {
+				t.Errorf("Test #%d: Expected %v got %v", i, test.want, err)
+			}
+		}
+	}
+}
+
+func TestTryNewNormalizedData(t *testing.T) {
+	for i, test := range []struct {
+		X      *mat.Dense
+		y      []float64
+		column int
+		want   error
+	}{
+		{
+			X:    mat.NewDense(3, 2, []float64{1.0, 0.0, 1.0, 1.0, 1.0, 3.0}),
+			y:    []float64{1.0, 2.0, 3.0},
+			want: nil,
+		},
+		{
+			X:    mat.NewDense(3, 2, []float64{1.0, 0.0, 1.0, 1.0, 1.0, 3.0}),
+			y:    []float64{1.0, 2.0},
+			want: ErrDimensionMismatch,
+		},
+		{
+			X:      mat.NewDense(3, 3, []float64{1.0, 0.0, 2.0, 1.0, 1.0, 2.0, 1.0, 3.0, 2.0}),
+			y:      []float64{1.0, 2.0, 3.0},
+			column: 2,
+			want:   ErrConstantColumn,
+		},
+	} {
+		orig := mat.DenseCopyOf(test.X)
+		_, err := TryNewNormalizedData(test.X, test.y)
+		if !errors.Is(err, test.want) {
+			t.Errorf("Test #%d: Expected %v got %v", i, test.want, err)
+		}
+
+		if err == nil {
+			continue
+		}
+
+		if !mat.Equal(orig, test.X) {
+			t.Errorf("Test #%d: X should not be altered when an error is returned", i)
+		}
+
+		var colErr *ColumnError
+		if errors.As(err, &colErr) && colErr.Column != test.column {
+			t.Errorf("Test #%d: Expected column %d got %d", i, test.column, colErr.Column)
+		}
+	}
+}
+
+func TestTryNewLassoLarsNode(t *testing.T) {
+	if _, err := TryNewLassoLarsNode([]float64{1.0, 2.0}, 0.1, []int{0, 1}); err != nil {
+		t.Errorf("Unexpected error %v", err)
+	}
+
+	if _, err := TryNewLassoLarsNode([]float64{1.0}, 0.1, []int{0, 1}); !errors.Is(err, ErrDimensionMismatch) {
+		t.Errorf("Expected ErrDimensionMismatch got %v", err)
+	}
+}
+
+func TestTryFeatNoByName(t *testing.T) {
+	dset := Dataset{
+		X:         mat.NewDense(1, 2, []float64{1.0, 2.0}),
+		Y:         []float64{3.0},
+		Names:     []string{"a", "y", "b"},
+		TargetCol: 1,
+	}
+
+	for i, test := range []struct {
+		name    string
+		want    int
+		wantErr error
+	}{
+		{name: "a", want: 0, wantErr: nil},
+		{name: "b", want: 1, wantErr: nil},
+		{name: "y", want: -1, wantErr: ErrFeatureNotFound},
+		{name: "c", want: -1, wantErr: ErrFeatureNotFound},
+	} {
+		got, err := dset.TryFeatNoByName(test.name)
+		if got != test.want || !errors.Is(err, test.wantErr) {
+			t.Errorf("Test #%d: Expected (%d, %v) got (%d, %v)", i, test.want, test.wantErr, got, err)
+		}
+	}
+}
+
+func TestSelectModelContextErrors(t *testing.T) {
+	X := mat.NewDense(4, 3, []float64{1, 0, 0, 1, 1, 1, 1, 2, 4, 1, 3, 9})
+	y := []float64{1.0, 2.0, 3.0, 5.0}
+
+	for i, test := range []struct {
+		X      mat.Matrix
+		y      []float64
+		params *SelectModelOptParams
+		want   error
+	}{
+		{X: X.Slice(0, 4, 0, 2), y: y, params: nil, want: ErrTooFewFeatures},
+		{X: X, y: y[:3], params: nil, want: ErrDimensionMismatch},
+		{X: X, y: y, params: &SelectModelOptParams{RootModel: []bool{true}}, want: ErrDimensionMismatch},
+		{X: X, y: y, params: &SelectModelOptParams{Weights: []float64{1.0}}, want: ErrDimensionMismatch},
+		{X: X, y: y, params: &SelectModelOptParams{Resume: &Checkpoint{NumFeatures: 5}}, want: ErrDimensionMismatch},
+	} {
+		var sp SearchProgress
+		err := SelectModelContext(context.Background(), test.X, test.y, NewHighscore(10), &sp, test.params)
+		if !errors.Is(err, test.want) {
+			t.Errorf("Test #%d: Expected %v got %v", i, test.want, err)
+		}
+	}
+}
@@ -1,25 +1,39 @@
 package featselect
 
 import (
+	"fmt"
 	"math"
 
 	"gonum.org/v1/gonum/mat"
 )
 
 // Fit adapts a linear model to a dataset. X is the design matrix,
-// y is the target data.
+// y is the target data. Fit panics if the fit fails, see TryFit.
 func Fit(X mat.Matrix, y []float64) []float64 {
+	x, err := TryFit(X, y)
+	if err != nil {
+		panic(err)
+	}
+	return x
+}
+
+// TryFit adapts a linear model to a dataset in the same way as Fit. An error wrapping
+// ErrDimensionMismatch is returned if the number of rows in X is different from the
+// length of y, and ErrNumerical if the singular value decomposition fails.
+func TryFit(X mat.Matrix, y []float64) ([]float64, error) {
 	nrows, n := X.Dims()
 
 	if nrows != len(y) {
-		panic("Fit: Inconsistent number of rows in design matrix")
+		return nil, fmt.Errorf("fit: %d rows in design matrix and %d targets: %w", nrows, len(y), ErrDimensionMismatch)
 	}
 
 	x := make([]float64, n)
 	yvec := mat.NewVecDense(len(y), y)
 
 	var svd mat.SVD
-	svd.Factorize(X, mat.SVDThin)
+	if ok := svd.Factorize(X, mat.SVDThin); !ok {
+		return nil, fmt.Errorf("fit: singular value decomposition failed: %w", ErrNumerical)
+	}
 
 	s := svd.Values(nil)
 	var u, v mat.Dense
@@ -45,7 +59,54 @@ func Fit(X mat.Matrix, y []float64) []float64 {
 	for i := 0; i < len(x); i++ {
 		x[i] = xMat.At(i, 0)
 	}
-	return x
+	return x, nil
+}
+
+// FitWeighted adapts a linear model by minimizing the weighted sum of squared
+// residuals. w holds one non-negative weight per data point
+func FitWeighted(X mat.Matrix, y []float64, w []float64) []float64 {
+	Xw, yw := WeightRows(X, y, w)
+	return Fit(Xw, yw)
+}
+
+// TryFitWeighted is the same as FitWeighted, but returns an error instead of panicking,
+// see TryFit
+func TryFitWeighted(X mat.Matrix, y []float64, w []float64) ([]float64, error) {
+	nrows, _ := X.Dims()
+	if nrows != len(y) || (w != nil && len(w) != len(y)) {
+		return nil, fmt.Errorf("fitweighted: %d rows in design matrix, %d targets and %d weights: %w", nrows, len(y), len(w), ErrDimensionMismatch)
+	}
+	Xw, yw := WeightRows(X, y, w)
+	return TryFit(Xw, yw)
+}
+
+// WeightRows returns a copy of X and y where each row is multiplied by the square root
+// of the corresponding weight. An ordinary least squares fit to the returned data is
+// equivalent to a weighted least squares fit to the original data. If w is nil, the
+// copies are not altered.
+func WeightRows(X mat.Matrix, y []float64, w []float64) (*mat.Dense, []float64) {
+	nrows, _ := X.Dims()
+	if nrows != len(y) || (w != nil && len(w) != len(y)) {
+		panic("WeightRows: Inconsistent number of rows, targets and weights")
+	}
+
+	Xw := mat.DenseCopyOf(X)
+	yw := make([]float64, len(y))
+	copy(yw, y)
+
+	if w == nil {
+		return Xw, yw
+	}
+
+	for i := 0; i < nrows; i++ {
+		sqrtW := math.Sqrt(w[i])
+		row := Xw.RawRowView(i)
+		for j := range row {
+			row[j] *= sqrtW
+		}
+		yw[i] *= sqrtW
+	}
+	return Xw, yw
 }
 
 // PredictOne predicts the value given a set of coefficients (coeff)
@@ -86,6 +147,26 @@ func Rss(X mat.Matrix, coeff []float64, data []float64) float64 {
 	return sumSq
 }
 
+// RssWeighted calculates the weighted residual sum of squares. If w is nil,
+// the result is the same as for Rss
+func RssWeighted(X mat.Matrix, coeff []float64, data []float64, w []float64) float64 {
+	if w == nil {
+		return Rss(X, coeff, data)
+	}
+
+	pred := Predict(X, coeff)
+
+	if len(data) != len(pred) || len(w) != len(pred) {
+		panic("rssweighted: Inconsistent number of data points given")
+	}
+
+	sumSq := 0.0
+	for i := 0; i < len(data); i++ {
+		sumSq += w[i] * math.Pow(pred[i]-data[i], 2)
+	}
+	return sumSq
+}
+
 // DenumMatrixGcv returns the matrix that should be passed as the first argument
 // to Gcv
 func DenumMatrixGcv(X mat.Matrix) *mat.Dense {
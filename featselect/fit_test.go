@@ -117,3 +117,30 @@ func TestGCV(t *testing.T) {
 		t.Errorf("GCV differ. Brute force %f, expected %f", cvBrute, gcv)
 	}
 }
+
+func TestFitWeighted(t *testing.T) {
+	// A weight of 2 should be equivalent to including the data point twice
+	X := mat.NewDense(4, 2, []float64{1.0, 0.0, 1.0, 1.0, 1.0, 2.0, 1.0, 3.0})
+	y := []float64{0.1, 1.2, 1.8, 3.5}
+	w := []float64{1.0, 2.0, 1.0, 2.0}
+
+	Xdup := mat.NewDense(6, 2, []float64{1.0, 0.0, 1.0, 1.0, 1.0, 1.0, 1.0, 2.0, 1.0, 3.0, 1.0, 3.0})
+	ydup := []float64{0.1, 1.2, 1.2, 1.8, 3.5, 3.5}
+
+	coeff := FitWeighted(X, y, w)
+	expect := Fit(Xdup, ydup)
+
+	if !floats.EqualApprox(coeff, expect, 1e-10) {
+		t.Errorf("Expected %v got %v", expect, coeff)
+	}
+
+	rss := RssWeighted(X, coeff, y, w)
+	expectRss := Rss(Xdup, expect, ydup)
+	if math.Abs(rss-expectRss) > 1e-10 {
+		t.Errorf("Expected %f got %f", expectRss, rss)
+	}
+
+	if math.Abs(RssWeighted(X, coeff, y, nil)-Rss(X, coeff, y)) > 1e-10 {
+		t.Errorf("RssWeighted without weights should be equal to Rss")
+	}
+}
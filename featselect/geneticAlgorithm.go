@@ -0,0 +1,370 @@
+package featselect
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"math/rand"
+	"sort"
+	"strings"
+
+	"gonum.org/v1/gonum/mat"
+)
+
+// Crossover determines how the models of two parents are combined in the genetic algorithm
+type Crossover int
+
+const (
+	// UniformCrossover takes each feature from a randomly chosen parent
+	UniformCrossover Crossover = iota
+
+	// OnePointCrossover takes the features before a random position from the first parent
+	// and the remaining features from the second parent
+	OnePointCrossover
+)
+
+// String returns the name of the crossover
+func (x Crossover) String() string {
+	switch x {
+	case UniformCrossover:
+		return "uniform"
+	case OnePointCrossover:
+		return "onepoint"
+	}
+	return fmt.Sprintf("Crossover(%d)", int(x))
+}
+
+// ParseCrossover returns the crossover with the given name |uniform|onepoint|
+func ParseCrossover(name string) (Crossover, error) {
+	for _, x := range []Crossover{UniformCrossover, OnePointCrossover} {
+		if strings.ToLower(name) == x.String() {
+			return x, nil
+		}
+	}
+	return UniformCrossover, fmt.Errorf("parsecrossover: unknown crossover %s", name)
+}
+
+// GAParams holds the parameters of the genetic algorithm. In each generation, the Elitism
+// best models are copied to the next generation, and the remaining models are children of
+// parents chosen by tournaments of TournamentSize models. Two parents are crossed with
+// probability CrossoverRate (otherwise the child is a copy of the first parent), and each
+// feature of the child is flipped with probability MutationRate (1/number of features if
+// zero). The fitness of a model is minus the criterion, and models that do not satisfy the
+// constraints have the lowest possible fitness. NumWorkers models are fitted in parallel.
+// The search is reproducible for a given Seed. HighscoreSize is the number of models in
+// the highscore list.
+type GAParams struct {
+	PopulationSize int
+	Generations    int
+	TournamentSize int
+	CrossoverRate  float64
+	MutationRate   float64
+	Elitism        int
+	Crossover      Crossover
+	Criterion      Criterion
+	Constraints    *Constraints
+	NumWorkers     int
+	HighscoreSize  int
+	Seed           int64
+	Budget         Budget
+}
+
+// NewGAParams returns the default parameters of the genetic algorithm
+func NewGAParams() *GAParams {
+	var params GAParams
+	params.PopulationSize = 50
+	params.Generations = 100
+	params.TournamentSize = 3
+	params.CrossoverRate = 0.9
+	params.Elitism = 2
+	params.Crossover = UniformCrossover
+	params.Criterion = AiccCriterion
+	params.NumWorkers = 8
+	params.HighscoreSize = 10
+	return &params
+}
+
+// validate checks that the parameters are consistent
+func (p *GAParams) validate() error {
+	if p.PopulationSize < 2 || p.Generations < 1 || p.TournamentSize < 1 || p.NumWorkers < 1 || p.HighscoreSize < 1 {
+		return fmt.Errorf("gaparams: population size (%d) must be at least 2, and the number of generations (%d), tournament size (%d), number of workers (%d) and highscore size (%d) at least 1",
+			p.PopulationSize, p.Generations, p.TournamentSize, p.NumWorkers, p.HighscoreSize)
+	}
+
+	if p.Elitism < 0 || p.Elitism >= p.PopulationSize {
+		return fmt.Errorf("gaparams: elitism must be in [0, %d), got %d", p.PopulationSize, p.Elitism)
+	}
+
+	if p.CrossoverRate < 0.0 || p.CrossoverRate > 1.0 || p.MutationRate < 0.0 || p.MutationRate > 1.0 {
+		return fmt.Errorf("gaparams: crossover rate (%f) and mutation rate (%f) must be in [0, 1]", p.CrossoverRate, p.MutationRate)
+	}
+	return nil
+}
+
+// GARes holds the result of the genetic algorithm. Selected and Coeff are the features
+// and coefficients of the best model, and Generations is the number of generations that
+// were evaluated
+type GARes struct {
+	Selected    []int
+	Coeff       []float64
+	Scores      *SAScore
+	Generations int
+}
+
+// SelectModelGA selects the model that minimizes the criterion with a genetic algorithm.
+// An error wrapping ErrInvalidConstraints is returned if the constraints are inconsistent,
+// or if no model satisfying them was found.
+func SelectModelGA(X mat.Matrix, y []float64, params *GAParams) (*GARes, error) {
+	return SelectModelGAContext(context.Background(), X, y, params)
+}
+
+// SelectModelGAContext selects the model in the same way as SelectModelGA, but stops when
+// ctx is cancelled or the budget is used up. The best models found so far are then
+// returned together with ctx.Err() or ErrBudgetExhausted. The iterations of the budget
+// are the generations.
+func SelectModelGAContext(ctx context.Context, X mat.Matrix, y []float64, params *GAParams) (*GARes, error) {
+	if nr, _ := X.Dims(); nr != len(y) {
+		return nil, fmt.Errorf("selectmodelga: %d rows in design matrix and %d targets: %w", nr, len(y), ErrDimensionMismatch)
+	}
+	return selectModelGA(ctx, &DenseSystem{X: X, Y: y}, params)
+}
+
+// SelectModelGAFromStats selects the model with a genetic algorithm, where the models are
+// fitted from sufficient statistics
+func SelectModelGAFromStats(stats *SufficientStats, params *GAParams) (*GARes, error) {
+	return selectModelGA(context.Background(), stats, params)
+}
+
+// gaIndividual is a model in the population of the genetic algorithm
+type gaIndividual struct {
+	model   []bool
+	coeff   []float64
+	fitness float64
+}
+
+func selectModelGA(ctx context.Context, sys LinearSystem, params *GAParams) (*GARes, error) {
+	if err := params.validate(); err != nil {
+		return nil, err
+	}
+
+	nr, nc := sys.Dims()
+	c := params.Constraints
+	if err := c.Validate(nc); err != nil {
+		return nil, err
+	}
+
+	criterion, err := prepareCriterion(params.Criterion, sys)
+	if err != nil {
+		return nil, err
+	}
+
+	initial, err := c.initialModel(nc)
+	if err != nil {
+		return nil, err
+	}
+
+	tracker := newBudgetTracker(ctx, params.Budget)
+	defer tracker.stop()
+
+	ga := &geneticAlgorithm{
+		params:    params,
+		rng:       rand.New(rand.NewSource(params.Seed)),
+		c:         c,
+		scores:    NewSAScore(params.HighscoreSize),
+		evaluated: make(map[string]*gaIndividual),
+		work:      make(chan *gaIndividual),
+		done:      make(chan *gaIndividual),
+	}
+	defer close(ga.work)
+
+	for i := 0; i < params.NumWorkers; i++ {
+		go gaWorker(ga.work, ga.done, sys, criterion, c)
+	}
+
+	// The population starts from the initial model of the constraints and random models
+	// with on average half of the data points as features
+	prob := math.Min(0.5, 0.5*float64(nr)/float64(nc))
+	population := []*gaIndividual{{model: initial}}
+	for len(population) < params.PopulationSize {
+		model := make([]bool, nc)
+		for f := range model {
+			model[f] = ga.rng.Float64() < prob
+		}
+		population = append(population, &gaIndividual{model: ga.repair(model)})
+	}
+	population = ga.evaluate(population)
+
+	res := &GARes{Scores: ga.scores}
+	var stopErr error
+	for res.Generations = 1; res.Generations < params.Generations; res.Generations++ {
+		if stopErr = tracker.err(0, res.Generations); stopErr != nil {
+			break
+		}
+		population = ga.evaluate(ga.nextGeneration(population))
+	}
+
+	// Without elitism the best model may not be part of the last generation, so the
+	// result is taken from the highscore list
+	best := ga.scores.BestItem
+	if best == nil {
+		return nil, fmt.Errorf("selectmodelga: no model with fewer features than data points satisfies the constraints: %w", ErrInvalidConstraints)
+	}
+	res.Selected = best.Selection()
+	res.Coeff = best.Coeff
+	return res, stopErr
+}
+
+// geneticAlgorithm holds the state of the genetic algorithm. All random numbers are drawn
+// from rng by the calling go-routine, such that the search is reproducible. evaluated holds
+// the models that have been fitted
+type geneticAlgorithm struct {
+	params    *GAParams
+	rng       *rand.Rand
+	c         *Constraints
+	scores    *SAScore
+	evaluated map[string]*gaIndividual
+	work      chan *gaIndividual
+	done      chan *gaIndividual
+}
+
+// gaWorker sets the fitness and the coefficients of the individuals
+func gaWorker(work <-chan *gaIndividual, done chan<- *gaIndividual, sys LinearSystem, criterion Criterion, c *Constraints) {
+	nr, _ := sys.Dims()
+	for ind := range work {
+		numFeat := NumFeatures(ind.model)
+		if numFeat > 0 && numFeat < nr && c.Satisfied(ind.model) {
+			var rss float64
+			ind.coeff, rss = sys.FitModel(ind.model)
+			ind.fitness = -criterion.Score(numFeat, nr, math.Max(rss, RssTol))
+		} else {
+			ind.fitness = -math.MaxFloat64
+		}
+		done <- ind
+	}
+}
+
+// evaluate fits the models that have not been evaluated before, inserts them in the
+// highscore list and returns the population sorted by decreasing fitness
+func (ga *geneticAlgorithm) evaluate(population []*gaIndividual) []*gaIndividual {
+	unique := []*gaIndividual{}
+	for i, ind := range population {
+		key := NewModelFromBools(ind.model).key()
+		if prev, ok := ga.evaluated[key]; ok {
+			population[i] = prev
+			continue
+		}
+		ga.evaluated[key] = ind
+		unique = append(unique, ind)
+	}
+
+	go func() {
+		for _, ind := range unique {
+			ga.work <- ind
+		}
+	}()
+
+	for range unique {
+		<-ga.done
+	}
+
+	// The individuals are inserted in the order they were created, such that equal
+	// scores are resolved in the same way in every run
+	for _, ind := range unique {
+		if ind.fitness > -math.MaxFloat64 {
+			item := NewSAItem(ind.model)
+			item.Score = ind.fitness
+			item.Coeff = ind.coeff
+			ga.scores.Insert(item)
+		}
+	}
+
+	sort.SliceStable(population, func(i, j int) bool { return population[i].fitness > population[j].fitness })
+	return population
+}
+
+// nextGeneration returns the elite of the population, which is sorted by decreasing
+// fitness, followed by the children
+func (ga *geneticAlgorithm) nextGeneration(population []*gaIndividual) []*gaIndividual {
+	next := make([]*gaIndividual, ga.params.Elitism, ga.params.PopulationSize)
+	copy(next, population)
+	for len(next) < ga.params.PopulationSize {
+		first := ga.tournament(population)
+		second := ga.tournament(population)
+
+		child := make([]bool, len(first.model))
+		copy(child, first.model)
+		if ga.rng.Float64() < ga.params.CrossoverRate {
+			ga.crossover(child, second.model)
+		}
+		ga.mutate(child)
+		next = append(next, &gaIndividual{model: ga.repair(child)})
+	}
+	return next
+}
+
+// tournament returns the fittest of TournamentSize randomly chosen individuals
+func (ga *geneticAlgorithm) tournament(population []*gaIndividual) *gaIndividual {
+	best := population[ga.rng.Intn(len(population))]
+	for i := 1; i < ga.params.TournamentSize; i++ {
+		if ind := population[ga.rng.Intn(len(population))]; ind.fitness > best.fitness {
+			best = ind
+		}
+	}
+	return best
+}
+
+// crossover replaces features of child by features of other
+func (ga *geneticAlgorithm) crossover(child []bool, other []bool) {
+	switch ga.params.Crossover {
+	case OnePointCrossover:
+		point := ga.rng.Intn(len(child))
+		copy(child[point:], other[point:])
+	default:
+		for f := range child {
+			if ga.rng.Intn(2) == 1 {
+				child[f] = other[f]
+			}
+		}
+	}
+}
+
+// mutate flips each feature with probability MutationRate
+func (ga *geneticAlgorithm) mutate(model []bool) {
+	rate := ga.params.MutationRate
+	if rate == 0.0 {
+		rate = 1.0 / float64(len(model))
+	}
+
+	for f := range model {
+		if ga.rng.Float64() < rate {
+			model[f] = !model[f]
+		}
+	}
+}
+
+// repair selects the included features and the parents they require, and removes the
+// excluded features. A Together group that is partly selected is completed or removed,
+// with a probability of completion equal to the fraction of its features that are selected.
+// The remaining constraints are enforced through the fitness
+func (ga *geneticAlgorithm) repair(model []bool) []bool {
+	if ga.c == nil {
+		return model
+	}
+
+	for _, g := range ga.c.Together {
+		if num := numSelected(g, model, len(model)); num > 0 && num < len(g) {
+			setAll(model, g, ga.rng.Float64()*float64(len(g)) < float64(num))
+		}
+	}
+
+	for _, f := range ga.c.Exclude {
+		model[f] = false
+	}
+
+	for _, f := range ga.c.Include {
+		model[f] = true
+	}
+	ga.c.addTogether(model)
+	ga.c.Heredity.addParents(model)
+	return model
+}
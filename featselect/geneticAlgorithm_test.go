@@ -0,0 +1,178 @@
+package featselect
+
+import (
+	"context"
+	"errors"
+	"math"
+	"testing"
+)
+
+func TestParseCrossover(t *testing.T) {
+	for i, test := range []struct {
+		name      string
+		crossover Crossover
+		ok        bool
+	}{
+		{name: "uniform", crossover: UniformCrossover, ok: true},
+		{name: "OnePoint", crossover: OnePointCrossover, ok: true},
+		{name: "twopoint", ok: false},
+	} {
+		x, err := ParseCrossover(test.name)
+		if (err == nil) != test.ok {
+			t.Errorf("Test #%d: Unexpected error %v", i, err)
+		}
+
+		if test.ok && x != test.crossover {
+			t.Errorf("Test #%d: Expected %v got %v", i, test.crossover, x)
+		}
+	}
+}
+
+func TestSelectModelGA(t *testing.T) {
+	X, y := incrementalTestData(60, 8)
+	for i := 0; i < 60; i++ {
+		X.Set(i, 0, 1.0)
+	}
+	want := BruteForceSelect(X, y).Items.Front().Value.(*Node)
+
+	for i, crossover := range []Crossover{UniformCrossover, OnePointCrossover} {
+		params := NewGAParams()
+		params.Crossover = crossover
+		params.Generations = 30
+		params.Seed = 3
+		res, err := SelectModelGA(X, y, params)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if !EqualInt(res.Selected, want.Model.Selected()) || len(res.Coeff) != len(res.Selected) {
+			t.Errorf("Test #%d: Expected selection %v got %v (%d coefficients)", i, want.Model.Selected(), res.Selected, len(res.Coeff))
+		}
+
+		if math.Abs(res.Scores.BestItem.Score-want.Score) > 1e-8 {
+			t.Errorf("Test #%d: Expected best score %f got %f", i, want.Score, res.Scores.BestItem.Score)
+		}
+
+		// The same seed gives the same highscore list
+		again, err := SelectModelGA(X, y, params)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if len(again.Scores.Items) != len(res.Scores.Items) {
+			t.Errorf("Test #%d: Expected %d items got %d", i, len(res.Scores.Items), len(again.Scores.Items))
+			continue
+		}
+
+		for j, item := range res.Scores.Items {
+			if other := again.Scores.Items[j]; !item.Model.Equal(&other.Model) || item.Score != other.Score {
+				t.Errorf("Test #%d: Item #%d differs between runs with the same seed", i, j)
+			}
+		}
+	}
+}
+
+func TestSelectModelGAConstraints(t *testing.T) {
+	X, y := incrementalTestData(40, 6)
+	for i, c := range []*Constraints{
+		{Include: []int{2}, Exclude: []int{1}},
+		{MinFeatures: 4, MaxFeatures: 4},
+		{Exclusive: [][]int{{1, 3}}},
+		{Together: [][]int{{1, 3}}},
+	} {
+		params := NewGAParams()
+		params.Generations = 10
+		params.Constraints = c
+		res, err := SelectModelGA(X, y, params)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		for _, item := range res.Scores.Items {
+			if !c.Satisfied(item.Model.ToBools()) {
+				t.Errorf("Test #%d: Selection %v does not satisfy the constraints", i, item.Selection())
+			}
+		}
+	}
+
+	params := NewGAParams()
+	params.Constraints = &Constraints{Include: []int{1}, Exclude: []int{1}}
+	if _, err := SelectModelGA(X, y, params); !errors.Is(err, ErrInvalidConstraints) {
+		t.Errorf("Expected ErrInvalidConstraints got %v", err)
+	}
+}
+
+func TestSelectModelGANoElitism(t *testing.T) {
+	X, y := incrementalTestData(40, 6)
+	params := NewGAParams()
+	params.Elitism = 0
+	params.Generations = 10
+	params.Seed = 1
+	res, err := SelectModelGA(X, y, params)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	best := res.Scores.BestItem
+	if !EqualInt(res.Selected, best.Selection()) || len(res.Coeff) != len(res.Selected) {
+		t.Errorf("Expected selection %v got %v (%d coefficients)", best.Selection(), res.Selected, len(res.Coeff))
+	}
+
+	// All models with at least four features have too few data points to be fitted
+	X, y = incrementalTestData(4, 6)
+	params.Constraints = &Constraints{MinFeatures: 4}
+	if _, err := SelectModelGA(X, y, params); !errors.Is(err, ErrInvalidConstraints) {
+		t.Errorf("Expected ErrInvalidConstraints got %v", err)
+	}
+}
+
+func TestGAParamsValidate(t *testing.T) {
+	X, y := incrementalTestData(20, 4)
+	for i, modify := range []func(p *GAParams){
+		func(p *GAParams) { p.PopulationSize = 1 },
+		func(p *GAParams) { p.Elitism = p.PopulationSize },
+		func(p *GAParams) { p.TournamentSize = 0 },
+		func(p *GAParams) { p.MutationRate = 1.5 },
+		func(p *GAParams) { p.CrossoverRate = -0.1 },
+		func(p *GAParams) { p.NumWorkers = 0 },
+	} {
+		params := NewGAParams()
+		modify(params)
+		if _, err := SelectModelGA(X, y, params); err == nil {
+			t.Errorf("Test #%d: Expected an error", i)
+		}
+	}
+}
+
+func TestSelectModelGABudget(t *testing.T) {
+	X, y := incrementalTestData(20, 4)
+	params := NewGAParams()
+	params.Budget = Budget{MaxIterations: 3}
+	res, err := SelectModelGAContext(context.Background(), X, y, params)
+	if !errors.Is(err, ErrBudgetExhausted) {
+		t.Errorf("Expected ErrBudgetExhausted got %v", err)
+	}
+
+	if res.Generations != 3 || len(res.Scores.Items) == 0 {
+		t.Errorf("Expected 3 generations and a non-empty highscore list. Got %d generations and %d items", res.Generations, len(res.Scores.Items))
+	}
+}
+
+func TestSelectModelGAFromStats(t *testing.T) {
+	X, y, w := statsTestData()
+	stats := NewSufficientStats(4)
+	stats.AddRows(X, y, w)
+	Xw, yw := WeightRows(X, y, w)
+	want := BruteForceSelect(Xw, yw).Items.Front().Value.(*Node)
+
+	params := NewGAParams()
+	params.Generations = 10
+	res, err := SelectModelGAFromStats(stats, params)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !EqualInt(res.Selected, want.Model.Selected()) {
+		t.Errorf("Expected selection %v got %v", want.Model.Selected(), res.Selected)
+	}
+}
@@ -0,0 +1,249 @@
+package featselect
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Heredity requires that interaction and power terms are only selected together with the
+// terms they are formed from. Parents[f] holds the parents of feature f. With strong
+// heredity all the parents of a selected feature must be selected, and with weak heredity
+// at least one of them. Features without parents are not restricted.
+type Heredity struct {
+	Parents [][]int
+	Strong  bool
+}
+
+// NewHeredity returns the heredity of a matrix with numOrig original columns followed by one
+// column for each of the terms. The parents of a power term are the terms where one of the
+// powers is reduced by one, e.g. a and b for a*b, a^2 and a*b for a^2*b and a for a^2. If a
+// parent is not among the columns, its own parents are used instead. Transformed features
+// have no parents.
+func NewHeredity(numOrig int, terms []BasisTerm, strong bool) *Heredity {
+	columns := make(map[string]int)
+	for i, term := range terms {
+		if term.Transform == "" {
+			columns[powerKey(term.Power)] = numOrig + i
+		}
+	}
+
+	h := &Heredity{Parents: make([][]int, numOrig+len(terms)), Strong: strong}
+	for i, term := range terms {
+		if term.Transform == "" {
+			h.Parents[numOrig+i] = powerParents(term.Power, columns)
+		}
+	}
+	return h
+}
+
+// Heredity returns the heredity of the columns in the matrix
+func (m *LazyPowerMatrix) Heredity(strong bool) *Heredity {
+	return NewHeredity(m.numOrigCols(), m.terms, strong)
+}
+
+// Heredity returns the heredity of the features in a dataset expanded by the basis set
+func (b *BasisSet) Heredity(strong bool) *Heredity {
+	return NewHeredity(len(b.Names), b.Terms, strong)
+}
+
+// powerKey returns a string that identifies a product of powers
+func powerKey(power map[int]int) string {
+	cols := make([]int, 0, len(power))
+	for c := range power {
+		cols = append(cols, c)
+	}
+	sort.Ints(cols)
+
+	factors := make([]string, len(cols))
+	for i, c := range cols {
+		factors[i] = strconv.Itoa(c) + "^" + strconv.Itoa(power[c])
+	}
+	return strings.Join(factors, "*")
+}
+
+// powerParents returns the columns of the parents of the product of powers, where columns
+// maps the keys of the power terms in the matrix to their column
+func powerParents(power map[int]int, columns map[string]int) []int {
+	found := make(map[int]bool)
+	for c := range power {
+		parent := make(map[int]int)
+		for k, v := range power {
+			parent[k] = v
+		}
+		parent[c]--
+		if parent[c] == 0 {
+			delete(parent, c)
+		}
+
+		if len(parent) == 0 {
+			continue
+		}
+
+		if powerDegree(parent) == 1 {
+			for k := range parent {
+				found[k] = true
+			}
+		} else if col, ok := columns[powerKey(parent)]; ok {
+			found[col] = true
+		} else {
+			for _, p := range powerParents(parent, columns) {
+				found[p] = true
+			}
+		}
+	}
+
+	parents := make([]int, 0, len(found))
+	for p := range found {
+		parents = append(parents, p)
+	}
+	sort.Ints(parents)
+	return parents
+}
+
+// validate checks that there is an entry for each feature, and that all parents are in
+// the range [0, numFeatures) and differ from the feature itself
+func (h *Heredity) validate(numFeatures int) error {
+	if len(h.Parents) != numFeatures {
+		return fmt.Errorf("heredity: parents given for %d features, expected %d: %w", len(h.Parents), numFeatures, ErrInvalidConstraints)
+	}
+
+	for f, parents := range h.Parents {
+		for _, p := range parents {
+			if p < 0 || p >= numFeatures || p == f {
+				return fmt.Errorf("heredity: invalid parent %d of feature %d: %w", p, f, ErrInvalidConstraints)
+			}
+		}
+	}
+	return nil
+}
+
+// allowed returns true if feature f can be selected together with the features in model
+func (h *Heredity) allowed(model []bool, f int) bool {
+	if h == nil || len(h.Parents[f]) == 0 {
+		return true
+	}
+
+	num := 0
+	for _, p := range h.Parents[f] {
+		if model[p] {
+			num++
+		}
+	}
+
+	if h.Strong {
+		return num == len(h.Parents[f])
+	}
+	return num > 0
+}
+
+// addParents selects the parents of the selected features until the heredity is satisfied.
+// With weak heredity, the first parent is selected when none of the parents are
+func (h *Heredity) addParents(model []bool) {
+	if h == nil {
+		return
+	}
+
+	for changed := true; changed; {
+		changed = false
+		for f, v := range model {
+			if !v || h.allowed(model, f) {
+				continue
+			}
+
+			parents := h.Parents[f]
+			if !h.Strong {
+				parents = parents[:1]
+			}
+
+			for _, p := range parents {
+				changed = changed || !model[p]
+				model[p] = true
+			}
+		}
+	}
+}
+
+// extendMove returns the features that are flipped when the features in moved are flipped
+// in model, and the heredity is restored. Selected features that lose a required parent
+// are removed, and the parents of the added features are added. The model is not altered
+func (h *Heredity) extendMove(model []bool, moved []int) []int {
+	if h == nil {
+		return moved
+	}
+
+	trial := make([]bool, len(model))
+	copy(trial, model)
+	flipAll(trial, moved)
+
+	for changed := true; changed; {
+		changed = false
+		for f, v := range trial {
+			if v && model[f] && !h.allowed(trial, f) {
+				trial[f] = false
+				changed = true
+			}
+		}
+	}
+	h.addParents(trial)
+
+	res := []int{}
+	for f := range trial {
+		if trial[f] != model[f] {
+			res = append(res, f)
+		}
+	}
+	return res
+}
+
+// restrict updates the greatest (gcs) and least (lcs) model bounding the models where the
+// features before start are fixed. Features from start and onwards are removed from gcs
+// if their parents can not be selected, and they are added to lcs if they are required
+// by the features in lcs.
+func (h *Heredity) restrict(gcs []bool, lcs []bool, start int) {
+	if h == nil {
+		return
+	}
+
+	for changed := true; changed; {
+		changed = false
+		for f := start; f < len(gcs); f++ {
+			if gcs[f] && !h.allowed(gcs, f) {
+				gcs[f] = false
+				changed = true
+			}
+		}
+
+		for f, v := range lcs {
+			if !v || h.allowed(lcs, f) {
+				continue
+			}
+
+			required := []int{}
+			if h.Strong {
+				required = h.Parents[f]
+			} else if candidates := h.candidates(gcs, f); len(candidates) == 1 {
+				required = candidates
+			}
+
+			for _, p := range required {
+				if p >= start && !lcs[p] {
+					lcs[p] = true
+					changed = true
+				}
+			}
+		}
+	}
+}
+
+// candidates returns the parents of f that are selected in model
+func (h *Heredity) candidates(model []bool, f int) []int {
+	res := []int{}
+	for _, p := range h.Parents[f] {
+		if model[p] {
+			res = append(res, p)
+		}
+	}
+	return res
+}
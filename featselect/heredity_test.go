@@ -0,0 +1,225 @@
+package featselect
+
+import (
+	"context"
+	"errors"
+	"math"
+	"math/rand"
+	"testing"
+
+	"gonum.org/v1/gonum/mat"
+)
+
+// heredityExample returns a dataset with an intercept, the features a and b, and the terms
+// a^2, a*b, b^2 and a^2*b. The target depends on a*b and a^2*b, but not on a and b
+func heredityExample() (*mat.Dense, []float64, *Heredity) {
+	rng := rand.New(rand.NewSource(42))
+	X := mat.NewDense(40, 3, nil)
+	y := make([]float64, 40)
+	for i := 0; i < 40; i++ {
+		a, b := rng.NormFloat64(), rng.NormFloat64()
+		X.Set(i, 0, 1.0)
+		X.Set(i, 1, a)
+		X.Set(i, 2, b)
+		y[i] = 1.0 + 2.0*a*b - a*a*b + 0.1*rng.NormFloat64()
+	}
+
+	lazy := LazyPowerMatrix{X: X}
+	for _, p := range []map[int]int{{1: 2}, {1: 1, 2: 1}, {2: 2}, {1: 2, 2: 1}} {
+		lazy.AddPower(p)
+	}
+	return lazy.FullMatrix(), y, lazy.Heredity(true)
+}
+
+func TestNewHeredity(t *testing.T) {
+	for i, test := range []struct {
+		terms []BasisTerm
+		want  [][]int
+	}{
+		{
+			terms: []BasisTerm{{Power: map[int]int{0: 2}}, {Power: map[int]int{0: 1, 1: 1}}, {Power: map[int]int{0: 2, 1: 1}}},
+			want:  [][]int{{}, {}, {0}, {0, 1}, {2, 3}},
+		},
+		{
+			terms: []BasisTerm{{Power: map[int]int{0: 2, 1: 1}}, {Transform: "log", Cols: []int{1}}},
+			want:  [][]int{{}, {}, {0, 1}, {}},
+		},
+		{
+			terms: []BasisTerm{{Power: map[int]int{0: 1, 1: 1}}, {Power: map[int]int{0: 1, 1: 1, 2: 1}}},
+			want:  [][]int{{}, {}, {}, {0, 1}, {0, 1, 2, 3}},
+		},
+	} {
+		h := NewHeredity(len(test.want)-len(test.terms), test.terms, true)
+		if len(h.Parents) != len(test.want) {
+			t.Errorf("Test #%d: Expected %d features got %d", i, len(test.want), len(h.Parents))
+			continue
+		}
+
+		for f, parents := range h.Parents {
+			if !EqualInt(parents, test.want[f]) {
+				t.Errorf("Test #%d: Expected parents %v of feature %d got %v", i, test.want[f], f, parents)
+			}
+		}
+	}
+}
+
+func TestHeredityFeasible(t *testing.T) {
+	_, _, strong := heredityExample()
+	weak := &Heredity{Parents: strong.Parents}
+	for i, test := range []struct {
+		h         *Heredity
+		model     []bool
+		start     int
+		feasible  bool
+		satisfied bool
+	}{
+		{h: strong, model: []bool{true, true, true, false, true, false, false}, start: 7, feasible: true, satisfied: true},
+		{h: strong, model: []bool{true, true, false, false, true, false, false}, start: 7, feasible: false, satisfied: false},
+		{h: weak, model: []bool{true, true, false, false, true, false, false}, start: 7, feasible: true, satisfied: true},
+		{h: weak, model: []bool{true, false, false, false, true, false, false}, start: 7, feasible: false, satisfied: false},
+		{h: strong, model: []bool{true, false, false, false, true, false, false}, start: 2, feasible: true, satisfied: false},
+		{h: strong, model: []bool{true, true, false, false, false, false, false}, start: 2, feasible: true, satisfied: true},
+		{h: strong, model: []bool{true, true, false, false, true, false, false}, start: 5, feasible: false, satisfied: false},
+		{h: weak, model: []bool{true, true, false, false, true, false, true}, start: 5, feasible: true, satisfied: true},
+	} {
+		c := &Constraints{Heredity: test.h}
+		if got := c.feasible(test.model, test.start); got != test.feasible {
+			t.Errorf("Test #%d: Expected feasible %v got %v", i, test.feasible, got)
+		}
+
+		if got := c.Satisfied(test.model); got != test.satisfied {
+			t.Errorf("Test #%d: Expected satisfied %v got %v", i, test.satisfied, got)
+		}
+	}
+
+	// Strong heredity requires both a and b when a*b is included
+	c := &Constraints{Include: []int{4}, Heredity: strong}
+	gcs, lcs := c.boundModels(make([]bool, 7), 0)
+	if !boolArrayEqual(lcs, []bool{false, true, true, false, true, false, false}) || !gcs[6] {
+		t.Errorf("Unexpected bounds %v and %v", gcs, lcs)
+	}
+
+	model, err := c.initialModel(7)
+	if err != nil || !c.Satisfied(model) {
+		t.Errorf("Initial model %v does not satisfy the constraints (%v)", model, err)
+	}
+}
+
+func TestHeredityValidate(t *testing.T) {
+	_, _, h := heredityExample()
+	for i, c := range []*Constraints{
+		{Heredity: &Heredity{Parents: make([][]int, 5)}},
+		{Heredity: &Heredity{Parents: [][]int{{}, {}, {}, {3}, {}, {}, {}}}},
+		{Heredity: &Heredity{Parents: [][]int{{}, {}, {}, {9}, {}, {}, {}}}},
+		{Include: []int{6}, Exclude: []int{1}, Heredity: h},
+	} {
+		if err := c.Validate(7); !errors.Is(err, ErrInvalidConstraints) {
+			t.Errorf("Test #%d: Expected ErrInvalidConstraints got %v", i, err)
+		}
+	}
+
+	if err := (&Constraints{Include: []int{6}, Exclude: []int{5}, Heredity: h}).Validate(7); err != nil {
+		t.Errorf("Unexpected error %v", err)
+	}
+}
+
+func TestSelectModelHeredity(t *testing.T) {
+	X, y, strong := heredityExample()
+	sys := &DenseSystem{X: X, Y: y}
+	for i, h := range []*Heredity{strong, {Parents: strong.Parents}} {
+		c := &Constraints{Heredity: h}
+		want := math.MaxFloat64
+		for _, model := range allModels(7) {
+			if NumFeatures(model) == 0 || !c.Satisfied(model) {
+				continue
+			}
+			_, rss := sys.FitModel(model)
+			want = math.Min(want, Aicc(NumFeatures(model), 40, math.Max(rss, RssTol)))
+		}
+
+		var sp SearchProgress
+		params := NewSelectModelOptParams()
+		params.Constraints = c
+		highscore := NewHighscore(20)
+		SelectModel(X, y, highscore, &sp, params)
+
+		if math.Abs(highscore.BestScore()+want) > 1e-8 {
+			t.Errorf("Test #%d: Expected best score %f got %f", i, -want, highscore.BestScore())
+		}
+
+		for item := highscore.Items.Front(); item != nil; item = item.Next() {
+			if model := item.Value.(*Node).Model.ToBools(); !c.Satisfied(model) {
+				t.Errorf("Test #%d: Model %v does not satisfy the heredity", i, model)
+			}
+		}
+	}
+}
+
+func TestSelectModelSAHeredity(t *testing.T) {
+	X, y, h := heredityExample()
+	model := []bool{true, true, true, false, true, false, false}
+	for i, test := range []struct {
+		moved []int
+		want  []int
+	}{
+		{moved: []int{6}, want: []int{3, 6}},
+		{moved: []int{1}, want: []int{1, 4}},
+		{moved: []int{2, 5}, want: []int{4, 5}},
+	} {
+		if got := h.extendMove(model, test.moved); !EqualInt(got, test.want) {
+			t.Errorf("Test #%d: Expected move %v got %v", i, test.want, got)
+		}
+	}
+
+	c := &Constraints{Heredity: h}
+	res, err := SelectModelSAConstrained(X, y, 5, Aicc, c)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, item := range res.Scores.Items {
+		if !c.Satisfied(item.Model.ToBools()) {
+			t.Errorf("Selection %v does not satisfy the heredity", item.Selection())
+		}
+	}
+}
+
+func TestOmpHeredity(t *testing.T) {
+	X, y, h := heredityExample()
+	res, err := OmpConstrained(X, y, 1e-10, &Constraints{Heredity: h})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	model := make([]bool, 7)
+	for _, f := range res.Order {
+		if !h.allowed(model, f) {
+			t.Errorf("Feature %d added before its parents. Order %v", f, res.Order)
+		}
+		model[f] = true
+	}
+}
+
+func TestHierarchicalLasso(t *testing.T) {
+	X, y, h := heredityExample()
+	for i, strong := range []bool{true, false} {
+		c := &Constraints{Heredity: &Heredity{Parents: h.Parents, Strong: strong}}
+		data := NewNormalizedData(X, y)
+		var corr PureLasso
+		var cov Empirical
+		path, err := LassoCrdDescPathConstrained(context.Background(), data, &cov, Logspace(1e-4, 1.0, 10), 10000, 1e-6, &corr, Budget{}, c)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if len(path) == 0 {
+			t.Errorf("Test #%d: Expected a non-empty path", i)
+		}
+
+		for j, node := range path {
+			if !c.Satisfied(Selected2Model(node.Selection, 7)) {
+				t.Errorf("Test #%d: Node #%d: Selection %v does not satisfy the heredity", i, j, node.Selection)
+			}
+		}
+	}
+}
@@ -9,6 +9,10 @@ import (
 type Highscore struct {
 	Items    *list.List
 	MaxItems int
+
+	// Names of the features. If set, the names of the selected features are
+	// added to each item when the list is written to JSON
+	Names []string
 }
 
 // NewHighscore creates a new highscore list with maxItems entries
@@ -76,15 +80,15 @@ func (h *Highscore) Scores() []float64 {
 
 // MarshalJSON creates a JSON representation of the highscore list
 func (h *Highscore) MarshalJSON() ([]byte, error) {
-	data := make([]*Node, h.Len())
+	data := make([]*nodeJsonified, h.Len())
 	counter := 0
 	for e := h.Items.Front(); e != nil; e = e.Next() {
-		data[counter] = e.Value.(*Node)
+		data[counter] = e.Value.(*Node).jsonified(h.Names)
 		counter++
 	}
 	return json.Marshal(&struct {
-		MaxItems int     `json:"maxItems"`
-		Items    []*Node `json:"items"`
+		MaxItems int              `json:"maxItems"`
+		Items    []*nodeJsonified `json:"items"`
 	}{
 		MaxItems: h.MaxItems,
 		Items:    data,
@@ -85,6 +85,29 @@ func TestSaveHighscore(t *testing.T) {
 	}
 }
 
+func TestHighscoreNames(t *testing.T) {
+	n1 := NewNode(0, []bool{true, false, true})
+	h := NewHighscore(10)
+	h.Insert(n1)
+	h.Names = []string{"a", "b", "a^2"}
+
+	var aux struct {
+		Items []struct {
+			Names []string `json:"names"`
+		} `json:"items"`
+	}
+	data, err := json.Marshal(h)
+	if err != nil {
+		t.Fatal(err)
+	}
+	json.Unmarshal(data, &aux)
+
+	want := []string{"a", "a^2"}
+	if len(aux.Items) != 1 || !strArrayEqual(aux.Items[0].Names, want) {
+		t.Errorf("Expected names %v got %s", want, data)
+	}
+}
+
 func getScores(h *Highscore) []float64 {
 	scores := make([]float64, h.Len())
 	i := 0
@@ -0,0 +1,317 @@
+package featselect
+
+import (
+	"math"
+	"sort"
+
+	"gonum.org/v1/gonum/floats"
+	"gonum.org/v1/gonum/mat"
+)
+
+// pivotTol is the smallest allowed ratio between the squared pivot and the diagonal element
+// of XᵀX when a column is added to a Cholesky factorisation. A smaller pivot means that the
+// column is (almost) a linear combination of the others, and the model is fitted by SVD instead
+const pivotTol = 1e-8
+
+// rssRelTol is the smallest residual sum of squares relative to yᵀy that is computed from a
+// Cholesky factorisation. Below this, the cancellation in yᵀy - zᵀz makes the result inaccurate
+// and the model is fitted by SVD instead
+const rssRelTol = 1e-10
+
+// gramProvider is implemented by linear systems that can provide XᵀX, Xᵀy and yᵀy
+type gramProvider interface {
+	gram() (*mat.SymDense, []float64, float64)
+}
+
+// gram returns XᵀX, Xᵀy and yᵀy
+func (d *DenseSystem) gram() (*mat.SymDense, []float64, float64) {
+	_, nc := d.X.Dims()
+	xtx := mat.NewSymDense(nc, nil)
+	xtx.SymOuterK(1.0, d.X.T())
+
+	var xty mat.VecDense
+	xty.MulVec(d.X.T(), mat.NewVecDense(len(d.Y), d.Y))
+	return xtx, xty.RawVector().Data, floats.Dot(d.Y, d.Y)
+}
+
+// gram returns XᵀX, Xᵀy and yᵀy
+func (s *SufficientStats) gram() (*mat.SymDense, []float64, float64) {
+	return s.XTX, s.XTy, s.YTy
+}
+
+// gramSystem fits models by Cholesky factorisations of XᵀX. Models that can not be fitted
+// reliably in this way are fitted by the wrapped system
+type gramSystem struct {
+	LinearSystem
+	xtx *mat.SymDense
+	xty []float64
+	yty float64
+}
+
+// newGramSystem returns a gramSystem if sys provides XᵀX, otherwise sys is returned.
+// A gramSystem is returned as it is
+func newGramSystem(sys LinearSystem) LinearSystem {
+	if _, ok := sys.(*gramSystem); ok {
+		return sys
+	}
+
+	gp, ok := sys.(gramProvider)
+	if !ok {
+		return sys
+	}
+	xtx, xty, yty := gp.gram()
+	return &gramSystem{LinearSystem: sys, xtx: xtx, xty: xty, yty: yty}
+}
+
+// FitModel fits the features that are true in model and returns the coefficients
+// and the residual sum of squares
+func (g *gramSystem) FitModel(model []bool) ([]float64, float64) {
+	return newFactorCache(g, nil).FitModel(model)
+}
+
+// cholFactor is the Cholesky factorisation XₛᵀXₛ = RᵀR of a subset S of the columns of
+// the design matrix, together with z = R⁻ᵀXₛᵀy. The coefficients solve Rβ = z and the
+// residual sum of squares is yᵀy - zᵀz. A factorisation is never modified after it is
+// created, such that it can be shared between nodes
+type cholFactor struct {
+	// features holds the columns in the order they appear in the factorisation
+	features []int
+
+	// r holds the upper triangular R, packed column by column
+	r []float64
+	z []float64
+}
+
+// packedIdx returns the position of R_ij (i <= j) in the packed storage
+func packedIdx(i, j int) int {
+	return j*(j+1)/2 + i
+}
+
+// add returns the factorisation where the column feature is appended. The second return
+// value is false if the column is numerically a linear combination of the others
+func (f *cholFactor) add(g *gramSystem, feature int) (*cholFactor, bool) {
+	// Solve Rᵀw = Xₛᵀx by forward substitution
+	k := len(f.features)
+	w := make([]float64, k)
+	for i, fi := range f.features {
+		v := g.xtx.At(fi, feature)
+		for m := 0; m < i; m++ {
+			v -= f.r[packedIdx(m, i)] * w[m]
+		}
+		w[i] = v / f.r[packedIdx(i, i)]
+	}
+
+	diag := g.xtx.At(feature, feature)
+	pivot := diag - floats.Dot(w, w)
+	if diag <= 0.0 || pivot <= pivotTol*diag {
+		return nil, false
+	}
+	d := math.Sqrt(pivot)
+
+	res := &cholFactor{
+		features: append(append(make([]int, 0, k+1), f.features...), feature),
+		r:        append(append(append(make([]float64, 0, len(f.r)+k+1), f.r...), w...), d),
+		z:        append(append(make([]float64, 0, k+1), f.z...), (g.xty[feature]-floats.Dot(w, f.z))/d),
+	}
+	return res, true
+}
+
+// remove returns the factorisation without the column feature. Removing a column from R
+// leaves an upper Hessenberg matrix, which is made triangular by Givens rotations. The
+// same rotations are applied to z
+func (f *cholFactor) remove(feature int) *cholFactor {
+	k := len(f.features)
+	if k == 1 {
+		return &cholFactor{}
+	}
+
+	pos := 0
+	for f.features[pos] != feature {
+		pos++
+	}
+
+	h := mat.NewDense(k, k-1, nil)
+	features := make([]int, 0, k-1)
+	for j := 0; j < k; j++ {
+		if j == pos {
+			continue
+		}
+		col := len(features)
+		for i := 0; i <= j; i++ {
+			h.Set(i, col, f.r[packedIdx(i, j)])
+		}
+		features = append(features, f.features[j])
+	}
+
+	z := make([]float64, k)
+	copy(z, f.z)
+	for j := pos; j < k-1; j++ {
+		a, b := h.At(j, j), h.At(j+1, j)
+		rho := math.Hypot(a, b)
+		c, s := a/rho, b/rho
+		for m := j; m < k-1; m++ {
+			hj, hj1 := h.At(j, m), h.At(j+1, m)
+			h.Set(j, m, c*hj+s*hj1)
+			h.Set(j+1, m, -s*hj+c*hj1)
+		}
+		z[j], z[j+1] = c*z[j]+s*z[j+1], -s*z[j]+c*z[j+1]
+	}
+
+	r := make([]float64, 0, k*(k-1)/2)
+	for j := 0; j < k-1; j++ {
+		for i := 0; i <= j; i++ {
+			r = append(r, h.At(i, j))
+		}
+	}
+	return &cholFactor{features: features, r: r, z: z[:k-1]}
+}
+
+// distance returns the number of columns that must be added or removed to obtain
+// the factorisation of model
+func (f *cholFactor) distance(model []bool) int {
+	d := NumFeatures(model)
+	for _, feat := range f.features {
+		if model[feat] {
+			d--
+		} else {
+			d++
+		}
+	}
+	return d
+}
+
+// toModel returns the factorisation of model. The second return value is false if one
+// of the added columns is numerically a linear combination of the others
+func (f *cholFactor) toModel(g *gramSystem, model []bool) (*cholFactor, bool) {
+	// Columns at the end are cheaper to remove, and removal does not change the position
+	// of the preceding columns
+	for i := len(f.features) - 1; i >= 0; i-- {
+		if !model[f.features[i]] {
+			f = f.remove(f.features[i])
+		}
+	}
+
+	present := make([]bool, len(model))
+	for _, feat := range f.features {
+		present[feat] = true
+	}
+
+	ok := true
+	for i, v := range model {
+		if v && !present[i] {
+			if f, ok = f.add(g, i); !ok {
+				return nil, false
+			}
+		}
+	}
+	return f, true
+}
+
+// solve returns the coefficients ordered by column number and the residual sum of squares
+func (f *cholFactor) solve(yty float64) ([]float64, float64) {
+	k := len(f.features)
+	beta := make([]float64, k)
+	for i := k - 1; i >= 0; i-- {
+		v := f.z[i]
+		for j := i + 1; j < k; j++ {
+			v -= f.r[packedIdx(i, j)] * beta[j]
+		}
+		beta[i] = v / f.r[packedIdx(i, i)]
+	}
+
+	order := make([]int, k)
+	for i := range order {
+		order[i] = i
+	}
+	sort.Slice(order, func(i, j int) bool { return f.features[order[i]] < f.features[order[j]] })
+
+	coeff := make([]float64, k)
+	for i, v := range order {
+		coeff[i] = beta[v]
+	}
+	return coeff, yty - floats.Dot(f.z, f.z)
+}
+
+// memory returns the estimated memory used by the factorisation in bytes
+func (f *cholFactor) memory() int {
+	return 8 * (len(f.features) + len(f.r) + len(f.z))
+}
+
+// factorCache is a LinearSystem used while a single node is processed. Models are fitted
+// by updating the known factorisation that differs from the model by the fewest columns,
+// and the new factorisations are kept such that they can be passed on to the children.
+// If the wrapped system is not a gramSystem, the models are fitted by the wrapped system
+type factorCache struct {
+	LinearSystem
+	gram   *gramSystem
+	known  []*cholFactor
+	fitted []*cholFactor
+}
+
+// newFactorCache returns a cache where known holds the factorisations that are available
+// as starting points. Nil entries are ignored
+func newFactorCache(sys LinearSystem, known []*cholFactor) *factorCache {
+	g, _ := sys.(*gramSystem)
+	return &factorCache{LinearSystem: sys, gram: g, known: known}
+}
+
+// FitModel fits the features that are true in model and returns the coefficients
+// and the residual sum of squares
+func (c *factorCache) FitModel(model []bool) ([]float64, float64) {
+	if c.gram == nil {
+		return c.LinearSystem.FitModel(model)
+	}
+
+	closest := &cholFactor{}
+	dist := NumFeatures(model)
+	for _, candidates := range [][]*cholFactor{c.known, c.fitted} {
+		for _, f := range candidates {
+			if f == nil {
+				continue
+			}
+
+			if d := f.distance(model); d < dist {
+				closest, dist = f, d
+			}
+		}
+	}
+
+	if f, ok := closest.toModel(c.gram, model); ok {
+		coeff, rss := f.solve(c.gram.yty)
+		if rss >= rssRelTol*c.gram.yty {
+			c.fitted = append(c.fitted, f)
+			return coeff, rss
+		}
+	}
+	return c.gram.LinearSystem.FitModel(model)
+}
+
+// last returns the most recent factorisation, or nil if no models were fitted from a
+// factorisation
+func (c *factorCache) last() *cholFactor {
+	if len(c.fitted) == 0 {
+		return nil
+	}
+	return c.fitted[len(c.fitted)-1]
+}
+
+// nodeFactors holds the factorisation of the model of a node. It is computed when the node
+// is taken from the queue, and is the starting point when the bounds of the children are
+// fitted. The children do not keep it, such that the nodes in the queue hold no
+// factorisations
+type nodeFactors struct {
+	model *cholFactor
+}
+
+// all returns all the factorisations, where missing ones are nil
+func (nf nodeFactors) all() []*cholFactor {
+	return []*cholFactor{nf.model}
+}
+
+// memory returns the estimated memory used by the factorisations in bytes
+func (nf nodeFactors) memory() int {
+	if nf.model == nil {
+		return 0
+	}
+	return nf.model.memory()
+}
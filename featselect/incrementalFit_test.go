@@ -0,0 +1,136 @@
+package featselect
+
+import (
+	"context"
+	"math"
+	"math/rand"
+	"testing"
+
+	"gonum.org/v1/gonum/floats"
+	"gonum.org/v1/gonum/mat"
+)
+
+func incrementalTestData(nr int, nc int) (*mat.Dense, []float64) {
+	rng := rand.New(rand.NewSource(42))
+	X := mat.NewDense(nr, nc, nil)
+	y := make([]float64, nr)
+	for i := 0; i < nr; i++ {
+		for j := 0; j < nc; j++ {
+			X.Set(i, j, rng.NormFloat64())
+		}
+		y[i] = 1.0 + 2.0*X.At(i, 1) - X.At(i, 3) + 0.5*rng.NormFloat64()
+	}
+	return X, y
+}
+
+func TestCholFactorUpdates(t *testing.T) {
+	X, y := incrementalTestData(30, 6)
+	dense := &DenseSystem{X: X, Y: y}
+	g := newGramSystem(dense).(*gramSystem)
+
+	start := &cholFactor{}
+	for _, feat := range []int{4, 0, 2, 5} {
+		var ok bool
+		if start, ok = start.add(g, feat); !ok {
+			t.Fatalf("Could not add feature %d", feat)
+		}
+	}
+
+	for i, model := range [][]bool{
+		{true, false, true, false, true, true},
+		{true, false, false, false, true, true},
+		{false, false, true, false, false, false},
+		{true, true, true, true, false, false},
+		{false, true, false, true, false, false},
+	} {
+		f, ok := start.toModel(g, model)
+		if !ok {
+			t.Errorf("Test #%d: Update failed", i)
+			continue
+		}
+
+		if f.distance(model) != 0 {
+			t.Errorf("Test #%d: Expected the factorisation of %v got features %v", i, model, f.features)
+		}
+
+		coeff, rss := f.solve(g.yty)
+		wantCoeff, wantRss := dense.FitModel(model)
+		if !floats.EqualApprox(coeff, wantCoeff, 1e-8) || math.Abs(rss-wantRss) > 1e-8*wantRss {
+			t.Errorf("Test #%d: Expected (%v, %f) got (%v, %f)", i, wantCoeff, wantRss, coeff, rss)
+		}
+	}
+}
+
+func TestGramSystemFallback(t *testing.T) {
+	X, y := incrementalTestData(20, 4)
+
+	// Column 3 is a copy of column 1, and y is fitted exactly by columns 0 and 2
+	for i := 0; i < 20; i++ {
+		X.Set(i, 3, X.At(i, 1))
+		y[i] = 2.0*X.At(i, 0) - X.At(i, 2)
+	}
+	dense := &DenseSystem{X: X, Y: y}
+	g := newGramSystem(dense)
+
+	for i, model := range [][]bool{
+		{true, true, false, true},
+		{true, false, true, false},
+		{true, true, true, false},
+	} {
+		cache := newFactorCache(g, nil)
+		coeff, rss := cache.FitModel(model)
+		wantCoeff, wantRss := dense.FitModel(model)
+		if !floats.EqualApprox(coeff, wantCoeff, 1e-8) || math.Abs(rss-wantRss) > 1e-8 {
+			t.Errorf("Test #%d: Expected (%v, %e) got (%v, %e)", i, wantCoeff, wantRss, coeff, rss)
+		}
+
+		if cache.last() != nil {
+			t.Errorf("Test #%d: Model should have been fitted by SVD", i)
+		}
+	}
+}
+
+func TestFactorCacheReuse(t *testing.T) {
+	X, y := incrementalTestData(30, 5)
+	g := newGramSystem(&DenseSystem{X: X, Y: y})
+
+	parent := newFactorCache(g, nil)
+	parent.FitModel([]bool{true, true, false, true, false})
+	child := newFactorCache(g, []*cholFactor{parent.last()})
+	child.FitModel([]bool{true, true, false, true, true})
+
+	if len(child.fitted) != 1 || child.last().distance([]bool{true, true, false, true, true}) != 0 {
+		t.Errorf("Expected one factorisation of the child model")
+	}
+
+	// The parent factorisation is updated by appending the new column
+	if !floats.Equal(child.last().r[:len(parent.last().r)], parent.last().r) {
+		t.Errorf("The factorisation of the parent should be the first part of the child factorisation")
+	}
+
+	if _, ok := newGramSystem(&hiddenGram{g}).(*gramSystem); ok {
+		t.Errorf("Only systems providing XᵀX should be wrapped")
+	}
+}
+
+// hiddenGram is a linear system that does not provide XᵀX
+type hiddenGram struct {
+	LinearSystem
+}
+
+func TestSelectModelIncremental(t *testing.T) {
+	X, y := incrementalTestData(40, 12)
+	var best []float64
+	for _, sys := range []LinearSystem{&DenseSystem{X: X, Y: y}, &hiddenGram{&DenseSystem{X: X, Y: y}}} {
+		highscore := NewHighscore(5)
+		var sp SearchProgress
+		if err := selectModel(context.Background(), sys, highscore, &sp, nil); err != nil {
+			t.Fatal(err)
+		}
+		best = append(best, highscore.BestScore())
+	}
+
+	if math.Abs(best[0]-best[1]) > 1e-8 {
+		t.Errorf("Incremental fits give best score %f, SVD gives %f", best[0], best[1])
+	}
+}
@@ -1,6 +1,7 @@
 package featselect
 
 import (
+	"context"
 	"fmt"
 	"math"
 
@@ -12,40 +13,77 @@ const lassoCrdDescZero = 1e-16
 // CorrectableLasso is a function that implements the lasso method with corrections
 type CorrectableLasso = func(dset *NormalizedData, lamb float64, cov CovMat, x0 []float64, maxIter int, tol float64, corr LassoCorrection) []float64
 
-// LassoCrdDesc solves the lasso problem via coordinate descent
+// LassoCrdDesc solves the lasso problem via coordinate descent. Observation weights are
+// taken into account by constructing dset with NewWeightedNormalizedData. If dset is
+// created from sufficient statistics, cov must implement GramCovMat
 func LassoCrdDesc(dset *NormalizedData, lamb float64, cov CovMat, x0 []float64, maxIter int, tol float64, corr LassoCorrection) []float64 {
-	nr, nFeat := dset.X.Dims()
+	beta, _ := lassoCrdDesc(context.Background(), dset, lamb, cov, x0, maxIter, tol, corr, nil, nil)
+	return beta
+}
+
+// lassoCrdDesc solves the lasso problem as LassoCrdDesc. The regularisation of feature j
+// is lamb*penalty[j] (lamb for all features if penalty is nil). If heredity is given, the
+// coefficient of a feature is kept at zero while its parents are inactive, and it is
+// updated again once they become active. The iterations
+// stop early if ctx is cancelled, in which case the second return value is false
+func lassoCrdDesc(ctx context.Context, dset *NormalizedData, lamb float64, cov CovMat, x0 []float64, maxIter int, tol float64, corr LassoCorrection, penalty []float64, heredity *Heredity) ([]float64, bool) {
+	nr, nFeat := dset.Dims()
+	if penalty == nil {
+		penalty = (*Constraints)(nil).LassoPenalty(nFeat)
+	}
 	if x0 == nil {
 		x0 = make([]float64, nFeat)
 	}
 
-	covMat := cov.Get(dset.X)
-
 	// Precalcuations
-	yVec := mat.NewVecDense(len(dset.y), dset.y)
+	var covMat mat.Matrix
 	XTy := mat.NewVecDense(nFeat, nil)
-	XTy.MulVec(dset.X.T(), yVec)
+	if dset.X == nil {
+		gramCov, ok := cov.(GramCovMat)
+		if !ok {
+			panic("lassocrddesc: The covariance matrix can not be calculated from sufficient statistics")
+		}
+		covMat = gramCov.FromGram(dset.gram, nr)
+		XTy.CopyVec(mat.NewVecDense(nFeat, dset.xTy))
+	} else {
+		covMat = cov.Get(dset.X)
+		yVec := mat.NewVecDense(len(dset.y), dset.y)
+		XTy.MulVec(dset.X.T(), yVec)
+	}
 
-	iterIndices := make([]int, nFeat-1)
+	iterIndices := make([]int, 0, nFeat-1)
 	for i := 1; i < nFeat; i++ {
-		iterIndices[i-1] = i
+		if !math.IsInf(penalty[i], 1) {
+			iterIndices = append(iterIndices, i)
+		}
 	}
 
 	betaOld := make([]float64, nFeat)
 	copy(x0, betaOld)
 	beta := make([]float64, nFeat)
+	active := make([]bool, nFeat)
+	blocked := make([]bool, nFeat)
 	covDotBeta := MulSlice(covMat, betaOld)
 	converged := false
 	for iter := 0; iter < maxIter; iter++ {
+		if ctx.Err() != nil {
+			return beta, false
+		}
+
 		for _, j := range iterIndices {
 			covDiag := covMat.At(j, j)
 			oldCoeff := betaOld[j]
 			covDotBetaNoDiag := covDotBeta[j] - betaOld[j]*covDiag
 			newCoeff := XTy.AtVec(j)/float64(nr) - covDotBetaNoDiag - corr.Deriv(betaOld, j)
-			newCoeff = SoftThreshold(newCoeff, lamb) / covDiag
+			newCoeff = SoftThreshold(newCoeff, lamb*penalty[j]) / covDiag
+			if !heredity.allowed(active, j) {
+				blocked[j] = newCoeff != 0.0
+				newCoeff = 0.0
+			}
 
 			UpdateCovDotBeta(covMat, covDotBeta, j, oldCoeff, newCoeff)
 			beta[j] = newCoeff
+			active[j] = math.Abs(newCoeff) > lassoCrdDescZero
 		}
 		corr.Update(beta)
 
@@ -76,7 +114,16 @@ func LassoCrdDesc(dset *NormalizedData, lamb float64, cov CovMat, x0 []float64,
 		copy(betaOld, beta)
 
 		if converged {
-			unsatisfied := UnsatisfiedKKTConditions(XTy, covDotBeta, beta, lamb, corr)
+			unsatisfied := unsatisfiedKKTConditions(XTy, covDotBeta, beta, lamb, corr, penalty)
+
+			// Features held at zero by the heredity are tried again when their parents are active
+			for j, v := range blocked {
+				if v && heredity.allowed(active, j) {
+					blocked[j] = false
+					unsatisfied = append(unsatisfied, j)
+				}
+			}
+
 			if len(unsatisfied) == 0 {
 				break
 			} else {
@@ -88,7 +135,7 @@ func LassoCrdDesc(dset *NormalizedData, lamb float64, cov CovMat, x0 []float64,
 	if !converged {
 		fmt.Printf("Warning! Lasso coordinate descent did not converge within the given number of iterations\n")
 	}
-	return beta
+	return beta, true
 }
 
 // SoftThreshold applyes a soft threshold to the value
@@ -103,11 +150,22 @@ func SoftThreshold(x float64, threshold float64) float64 {
 
 // UnsatisfiedKKTConditions returns the indices where KKT conditions are not met
 func UnsatisfiedKKTConditions(Xy mat.Vector, covDotBeta []float64, coeff []float64, lamb float64, correction LassoCorrection) []int {
+	return unsatisfiedKKTConditions(Xy, covDotBeta, coeff, lamb, correction, nil)
+}
+
+// unsatisfiedKKTConditions returns the indices where the KKT conditions are not met when
+// the regularisation of feature i is lamb*penalty[i] (lamb if penalty is nil)
+func unsatisfiedKKTConditions(Xy mat.Vector, covDotBeta []float64, coeff []float64, lamb float64, correction LassoCorrection, penalty []float64) []int {
 	unsatisfied := []int{}
 	nr := Xy.Len()
 	for i := 1; i < len(covDotBeta); i++ {
+		featLamb := lamb
+		if penalty != nil {
+			featLamb *= penalty[i]
+		}
+
 		value := math.Abs(Xy.AtVec(i) - covDotBeta[i]*float64(nr) - correction.Deriv(coeff, i))
-		if value < lamb && math.Abs(coeff[i]) > lassoCrdDescZero {
+		if value < featLamb && math.Abs(coeff[i]) > lassoCrdDescZero {
 			unsatisfied = append(unsatisfied, i)
 		}
 	}
@@ -140,26 +198,34 @@ func UpdateCovDotBeta(cov mat.Matrix, covDotBeta []float64, coeffNo int, oldCoef
 
 // LassoCrdWorkload is a struct holder information to carry out a lasso coordinate descent path
 type LassoCrdWorkload struct {
-	x0      []float64
-	lamb    float64
-	dset    *NormalizedData
-	cov     CovMat
-	maxIter int
-	lambIdx int
-	tol     float64
-	corr    LassoCorrection
+	ctx      context.Context
+	x0       []float64
+	lamb     float64
+	dset     *NormalizedData
+	cov      CovMat
+	maxIter  int
+	lambIdx  int
+	tol      float64
+	corr     LassoCorrection
+	penalty  []float64
+	heredity *Heredity
 }
 
 // LassoRes is a structure used to return the result
 type LassoRes struct {
-	node    *LassoLarsNode
-	lambIdx int
+	node     *LassoLarsNode
+	lambIdx  int
+	complete bool
 }
 
 // PerformLassoCrd listens to the workload channel and passes its result to res
 func PerformLassoCrd(workload <-chan LassoCrdWorkload, res chan<- LassoRes) {
 	for wrk := range workload {
-		coeff := LassoCrdDesc(wrk.dset, wrk.lamb, wrk.cov, wrk.x0, wrk.maxIter, wrk.tol, wrk.corr)
+		ctx := wrk.ctx
+		if ctx == nil {
+			ctx = context.Background()
+		}
+		coeff, complete := lassoCrdDesc(ctx, wrk.dset, wrk.lamb, wrk.cov, wrk.x0, wrk.maxIter, wrk.tol, wrk.corr, wrk.penalty, wrk.heredity)
 		selection := []int{}
 		selectedCoeff := []float64{}
 		for j := range coeff {
@@ -172,13 +238,42 @@ func PerformLassoCrd(workload <-chan LassoCrdWorkload, res chan<- LassoRes) {
 		var resStruct LassoRes
 		resStruct.node = node
 		resStruct.lambIdx = wrk.lambIdx
+		resStruct.complete = complete
 		res <- resStruct
 	}
 }
 
 // LassoCrdDescPath calculates a set of lasso solutions along equi-logspaced set of lambda values
 func LassoCrdDescPath(dset *NormalizedData, cov CovMat, lambs []float64, maxIter int, tol float64, correction LassoCorrection) []*LassoLarsNode {
-	_, nFeat := dset.X.Dims()
+	nodes, _ := LassoCrdDescPathContext(context.Background(), dset, cov, lambs, maxIter, tol, correction, Budget{})
+	return nodes
+}
+
+// LassoCrdDescPathContext calculates the lasso path in the same way as LassoCrdDescPath.
+// The path is solved from the largest to the smallest lambda. If ctx is cancelled or the
+// budget is used up, the solutions for the largest values of lambda found so far are
+// returned together with ctx.Err() or ErrBudgetExhausted.
+func LassoCrdDescPathContext(ctx context.Context, dset *NormalizedData, cov CovMat, lambs []float64, maxIter int, tol float64, correction LassoCorrection, budget Budget) ([]*LassoLarsNode, error) {
+	return LassoCrdDescPathConstrained(ctx, dset, cov, lambs, maxIter, tol, correction, budget, nil)
+}
+
+// LassoCrdDescPathConstrained calculates the lasso path in the same way as
+// LassoCrdDescPathContext. Included features are not penalised and excluded features are
+// never selected. With heredity constraints, this is a hierarchical LASSO where a feature
+// only gets a non-zero coefficient while its parents are active. Solutions that do not
+// satisfy the number of features, the exclusive groups or the heredity are removed from the
+// returned path. An error wrapping ErrInvalidConstraints is returned if the constraints are
+// inconsistent.
+func LassoCrdDescPathConstrained(ctx context.Context, dset *NormalizedData, cov CovMat, lambs []float64, maxIter int, tol float64, correction LassoCorrection, budget Budget, c *Constraints) ([]*LassoLarsNode, error) {
+	_, nFeat := dset.Dims()
+	if err := c.Validate(nFeat); err != nil {
+		return nil, err
+	}
+	penalty := c.LassoPenalty(nFeat)
+
+	tracker := newBudgetTracker(ctx, budget)
+	defer tracker.stop()
+
 	x0 := make([]float64, nFeat)
 
 	nodes := make([]*LassoLarsNode, len(lambs))
@@ -195,10 +290,18 @@ func LassoCrdDescPath(dset *NormalizedData, cov CovMat, lambs []float64, maxIter
 
 	workChan := make(chan LassoCrdWorkload)
 	resChan := make(chan LassoRes)
+	defer close(workChan)
+	numDispatched := 0
+	var stopErr error
 	for i := 0; i < numWorkers; i++ {
 		go PerformLassoCrd(workChan, resChan)
 
+		if stopErr = tracker.err(0, numDispatched); stopErr != nil {
+			break
+		}
+
 		var wrk LassoCrdWorkload
+		wrk.ctx = tracker.ctx
 		wrk.x0 = x0
 		wrk.lamb = lambs[availableLambs[0]]
 		wrk.lambIdx = availableLambs[0]
@@ -208,26 +311,35 @@ func LassoCrdDescPath(dset *NormalizedData, cov CovMat, lambs []float64, maxIter
 		wrk.maxIter = maxIter
 		wrk.tol = tol
 		wrk.corr = correction
+		wrk.penalty = penalty
+		wrk.heredity = c.heredity()
 		workChan <- wrk
+		numDispatched++
 	}
 
 	numReceive := 0
 	highestInserted := 0
-	for numReceive < len(lambs) {
+	for numReceive < numDispatched {
 		result := <-resChan
-		node := result.node
-		pos := len(lambs) - result.lambIdx - 1
-		nodes[pos] = node
+		numReceive++
+		if result.complete {
+			node := result.node
+			pos := len(lambs) - result.lambIdx - 1
+			nodes[pos] = node
 
-		if result.lambIdx > highestInserted {
-			highestInserted = pos
+			if result.lambIdx > highestInserted {
+				highestInserted = pos
+			}
+			fmt.Printf("Lamb: %6.1e Num coeff. %5d\n", node.Lamb, len(node.Selection))
 		}
 
-		fmt.Printf("Lamb: %6.1e Num coeff. %5d\n", node.Lamb, len(node.Selection))
-		numReceive++
+		if stopErr == nil && len(availableLambs) > 0 {
+			stopErr = tracker.err(0, numDispatched)
+		}
 
-		if len(availableLambs) > 0 {
+		if stopErr == nil && len(availableLambs) > 0 {
 			var wrk LassoCrdWorkload
+			wrk.ctx = tracker.ctx
 			wrk.x0 = make([]float64, nFeat)
 			hnode := nodes[highestInserted]
 			for i := range hnode.Selection {
@@ -241,10 +353,25 @@ func LassoCrdDescPath(dset *NormalizedData, cov CovMat, lambs []float64, maxIter
 			wrk.maxIter = maxIter
 			wrk.tol = tol
 			wrk.corr = correction
+			wrk.penalty = penalty
+			wrk.heredity = c.heredity()
 			workChan <- wrk
+			numDispatched++
+		}
+	}
+
+	// Only keep the solutions up to the first one that is missing
+	for i := range nodes {
+		if nodes[i] == nil {
+			nodes = nodes[:i]
+			break
 		}
 	}
 
+	if len(nodes) < len(lambs) && stopErr == nil {
+		stopErr = tracker.err(0, 0)
+	}
+
 	firstModelWithFeatures := 0
 	for i := range nodes {
 		if len(nodes[i].Selection) > 0 {
@@ -252,7 +379,7 @@ func LassoCrdDescPath(dset *NormalizedData, cov CovMat, lambs []float64, maxIter
 			break
 		}
 	}
-	return nodes[firstModelWithFeatures:]
+	return c.filterPath(nodes[firstModelWithFeatures:], nFeat), stopErr
 }
 
 // PureLassoCohen is a type that is used to calculate the Cohen's kappa value
@@ -126,7 +126,7 @@ func (p *LassoLarsPath) GetCriteria(criteria crit) []float64 {
 	for i, n := range p.LassoLarsNodes {
 		model := Selected2Model(n.Selection, nFeat)
 		design := GetDesignMatrix(model, p.Dset.X)
-		rss := Rss(design, n.Coeff, p.Dset.Y)
+		rss := RssWeighted(design, n.Coeff, p.Dset.Y, p.Dset.Weights)
 		num := NumFeatures(model)
 		values[i] = criteria(num, nData, rss)
 	}
@@ -171,7 +171,7 @@ func (p *LassoLarsPath) PlotDeviations() *plot.Plot {
 	for i, n := range p.LassoLarsNodes {
 		model := Selected2Model(n.Selection, nFeat)
 		design := GetDesignMatrix(model, p.Dset.X)
-		rss := Rss(design, n.Coeff, p.Dset.Y)
+		rss := RssWeighted(design, n.Coeff, p.Dset.Y, p.Dset.Weights)
 		rmse := math.Sqrt(rss / float64(nData))
 		rmseVals[i] = plotter.XY{X: math.Log10(n.Lamb), Y: math.Log10(rmse)}
 	}
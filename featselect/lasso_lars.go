@@ -19,9 +19,18 @@ type LassoLarsNode struct {
 
 // NewLassoLarsNode creates a new lasso-lars node
 func NewLassoLarsNode(coeff []float64, lamb float64, selection []int) *LassoLarsNode {
+	l, err := TryNewLassoLarsNode(coeff, lamb, selection)
+	if err != nil {
+		panic(err)
+	}
+	return l
+}
 
+// TryNewLassoLarsNode creates a new lasso-lars node. An error wrapping ErrDimensionMismatch
+// is returned if the number of coefficients differs from the number of selected features
+func TryNewLassoLarsNode(coeff []float64, lamb float64, selection []int) (*LassoLarsNode, error) {
 	if len(coeff) != len(selection) {
-		panic("lassolars: inconsistent lengths")
+		return nil, fmt.Errorf("lassolars: %d coefficients and %d selected features: %w", len(coeff), len(selection), ErrDimensionMismatch)
 	}
 	var l LassoLarsNode
 	l.Coeff = make([]float64, len(coeff))
@@ -29,7 +38,7 @@ func NewLassoLarsNode(coeff []float64, lamb float64, selection []int) *LassoLars
 	l.Lamb = lamb
 	l.Selection = make([]int, len(selection))
 	copy(l.Selection, selection)
-	return &l
+	return &l, nil
 }
 
 // LassoLarsParams is a convenience struct defined to hold the variable c and d
@@ -46,9 +55,45 @@ type LastZeroed struct {
 	active bool
 }
 
-// LassoLars computes the LASSO solution wiith the LARS algorithm
+// LassoLars computes the LASSO solution wiith the LARS algorithm. LassoLars panics if
+// the algorithm breaks down, see TryLassoLars
 func LassoLars(data *NormalizedData, lambMin float64, estimator CDParam) []*LassoLarsNode {
+	res, err := TryLassoLars(data, lambMin, estimator)
+	if err != nil {
+		panic(err)
+	}
+	return res
+}
+
+// TryLassoLars computes the LASSO solution in the same way as LassoLars. An error
+// wrapping ErrNumerical is returned if the active set becomes empty or the joining
+// time of a feature can not be determined.
+func TryLassoLars(data *NormalizedData, lambMin float64, estimator CDParam) ([]*LassoLarsNode, error) {
+	return LassoLarsConstrained(data, lambMin, estimator, nil)
+}
+
+// LassoLarsConstrained computes the LASSO solution with the LARS algorithm, where the
+// excluded features never join the active set. Solutions that do not satisfy the number
+// of features, the exclusive groups or the heredity are removed from the returned path. Included
+// features are not supported by LARS (use LassoCrdDescPathConstrained), and an error
+// wrapping ErrInvalidConstraints is returned if any are given.
+func LassoLarsConstrained(data *NormalizedData, lambMin float64, estimator CDParam, c *Constraints) ([]*LassoLarsNode, error) {
 	nr, nc := data.X.Dims()
+	if err := c.Validate(nc); err != nil {
+		return nil, err
+	}
+
+	excluded := make([]bool, nc)
+	if c != nil {
+		if len(c.Include) > 0 {
+			return nil, fmt.Errorf("lassolars: included features are not supported: %w", ErrInvalidConstraints)
+		}
+
+		for _, f := range c.Exclude {
+			excluded[f] = true
+		}
+	}
+
 	allSigns := mat.NewVecDense(nc, nil)
 	yVec := mat.NewVecDense(nr, data.y)
 
@@ -59,7 +104,7 @@ func LassoLars(data *NormalizedData, lambMin float64, estimator CDParam) []*Lass
 	feat := 0
 	for i := 0; i < lambJoin.Len(); i++ {
 		v := math.Abs(lambJoin.AtVec(i))
-		if v > maxTime {
+		if v > maxTime && !excluded[i] {
 			maxTime = v
 			feat = i
 		}
@@ -82,7 +127,7 @@ func LassoLars(data *NormalizedData, lambMin float64, estimator CDParam) []*Lass
 	estimator.SetX(data.X)
 	for lamb > lambMin {
 		if len(activeSet) == 0 {
-			panic("lassolars: Active set is empty!")
+			return nil, fmt.Errorf("lassolars: active set is empty: %w", ErrNumerical)
 		}
 
 		// To make it consistent with how the design matrix works, we keep the activeSet
@@ -97,7 +142,10 @@ func LassoLars(data *NormalizedData, lambMin float64, estimator CDParam) []*Lass
 		llp.c = estimator.C(yVec)
 		llp.d = estimator.D(signs)
 
-		joinTimes := tJoin(data.X, &Xe, yVec, &llp, lamb, activeSet, last)
+		joinTimes, err := tJoin(data.X, &Xe, yVec, &llp, lamb, activeSet, last, excluded)
+		if err != nil {
+			return nil, err
+		}
 		crossTimes := tCross(&llp, lamb)
 
 		jt, jFeat := maxJoinTime(joinTimes, activeSet)
@@ -129,7 +177,7 @@ func LassoLars(data *NormalizedData, lambMin float64, estimator CDParam) []*Lass
 			lamb = ct
 		}
 	}
-	return res
+	return c.filterPath(res, nc), nil
 }
 
 func maxJoinTime(v *mat.VecDense, active []int) (float64, int) {
@@ -162,7 +210,7 @@ func maxCrossTime(v *mat.VecDense) (float64, int) {
 }
 
 // tJoin calculates the joining time for all features
-func tJoin(X mat.Matrix, Xe mat.Matrix, y mat.Vector, llp *LassoLarsParams, lamb float64, active []int, last LastZeroed) *mat.VecDense {
+func tJoin(X mat.Matrix, Xe mat.Matrix, y mat.Vector, llp *LassoLarsParams, lamb float64, active []int, last LastZeroed, excluded []bool) (*mat.VecDense, error) {
 	_, nc := X.Dims()
 	joinTime := mat.NewVecDense(nc, nil)
 
@@ -187,6 +235,11 @@ func tJoin(X mat.Matrix, Xe mat.Matrix, y mat.Vector, llp *LassoLarsParams, lamb
 	}
 
 	for i := 0; i < nc; i++ {
+		if excluded[i] {
+			joinTime.SetVec(i, -1.0)
+			continue
+		}
+
 		tpluss := (Xy.AtVec(i) - XXeC.AtVec(i)) / (1.0 - XXeD.AtVec(i))
 		tminus := (Xy.AtVec(i) - XXeC.AtVec(i)) / (-1.0 - XXeD.AtVec(i))
 
@@ -202,11 +255,10 @@ func tJoin(X mat.Matrix, Xe mat.Matrix, y mat.Vector, llp *LassoLarsParams, lamb
 		} else if tminus >= -lassoTol && tminus <= lamb+lassoTol {
 			joinTime.SetVec(i, tminus)
 		} else {
-			fmt.Printf("%e, %e, %e\n", tminus, tpluss, lamb)
-			panic("larslasso: Feature never included")
+			return nil, fmt.Errorf("lassolars: feature %d never included (t+ = %e, t- = %e, lambda = %e): %w", i, tpluss, tminus, lamb, ErrNumerical)
 		}
 	}
-	return joinTime
+	return joinTime, nil
 }
 
 // tCross calculates the crossing times
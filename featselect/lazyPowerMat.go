@@ -7,10 +7,11 @@ import (
 )
 
 // LazyPowerMatrix is a matrix that can have arbitrary additional columns
-// formed by taking powers of the existing columns
+// formed by taking powers of the existing columns or by applying transforms
+// (see RegisterTransform) to them. The columns are only evaluated when requested
 type LazyPowerMatrix struct {
-	X      *mat.Dense
-	powers []map[int]int
+	X     *mat.Dense
+	terms []BasisTerm
 }
 
 func (m *LazyPowerMatrix) numOrigCols() int {
@@ -21,7 +22,7 @@ func (m *LazyPowerMatrix) numOrigCols() int {
 // NumCols return the total number of columns in the matrix
 func (m *LazyPowerMatrix) NumCols() int {
 	orig := m.numOrigCols()
-	return orig + len(m.powers)
+	return orig + len(m.terms)
 }
 
 func (m *LazyPowerMatrix) numRows() int {
@@ -38,9 +39,22 @@ func (m *LazyPowerMatrix) GetCol(col int) []float64 {
 			res[i] = v.AtVec(i)
 		}
 	} else {
+		term := m.terms[col-m.numOrigCols()]
+		if term.Transform != "" {
+			transform, _ := getTransform(term.Transform)
+			args := make([]float64, len(term.Cols))
+			for row := 0; row < m.numRows(); row++ {
+				for i, c := range term.Cols {
+					args[i] = m.X.At(row, c)
+				}
+				res[row] = transform.Apply(args)
+			}
+			return res
+		}
+
 		for row := 0; row < m.numRows(); row++ {
 			res[row] = 1.0
-			for col, power := range m.powers[col-m.numOrigCols()] {
+			for col, power := range term.Power {
 				res[row] *= math.Pow(m.X.At(row, col), float64(power))
 			}
 		}
@@ -50,7 +64,27 @@ func (m *LazyPowerMatrix) GetCol(col int) []float64 {
 
 // Add a set of power to the matrix
 func (m *LazyPowerMatrix) AddPower(power map[int]int) {
-	m.powers = append(m.powers, power)
+	m.terms = append(m.terms, BasisTerm{Power: power})
+}
+
+// AddTransform adds a column formed by applying the named transform to the listed columns
+func (m *LazyPowerMatrix) AddTransform(name string, cols ...int) error {
+	return m.AddTerm(BasisTerm{Transform: name, Cols: cols})
+}
+
+// AddTerm adds a column defined by the passed term. An error is returned if the
+// transform is not registered or the columns are out of range
+func (m *LazyPowerMatrix) AddTerm(term BasisTerm) error {
+	if err := term.validate(m.numOrigCols()); err != nil {
+		return err
+	}
+	m.terms = append(m.terms, term)
+	return nil
+}
+
+// Terms returns the definitions of the additional columns
+func (m *LazyPowerMatrix) Terms() []BasisTerm {
+	return m.terms
 }
 
 // NewLazyMatrix creates a new matrix with all powers up to maxPower
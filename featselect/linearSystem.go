@@ -0,0 +1,34 @@
+package featselect
+
+import (
+	"gonum.org/v1/gonum/mat"
+)
+
+// LinearSystem provides the data needed to fit linear models to subsets of the features
+type LinearSystem interface {
+	// Dims returns the number of data points and the number of features
+	Dims() (int, int)
+
+	// FitModel fits the features that are true in model and returns the coefficients
+	// and the residual sum of squares
+	FitModel(model []bool) ([]float64, float64)
+}
+
+// DenseSystem is a LinearSystem where the full design matrix is kept in memory
+type DenseSystem struct {
+	X mat.Matrix
+	Y []float64
+}
+
+// Dims returns the number of data points and the number of features
+func (d *DenseSystem) Dims() (int, int) {
+	return d.X.Dims()
+}
+
+// FitModel fits the features that are true in model and returns the coefficients
+// and the residual sum of squares
+func (d *DenseSystem) FitModel(model []bool) ([]float64, float64) {
+	design := GetDesignMatrix(model, d.X)
+	coeff := Fit(design, d.Y)
+	return coeff, Rss(design, coeff, d.Y)
+}
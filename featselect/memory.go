@@ -0,0 +1,130 @@
+package featselect
+
+import (
+	"container/list"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// queueOverhead is the memory used by a node in the branch and bound queue in addition
+// to EstimateMemory: the model and coefficient headers, padding and the list element
+const queueOverhead = 112
+
+// evictFraction is the fraction of the queue limits that is kept when nodes are evicted,
+// such that the queue does not have to be pruned again after the next insertion
+const evictFraction = 0.9
+
+// Eviction summarises the nodes that were removed from the branch and bound queue without
+// being explored because the queue reached its size or memory limit. MinLower and MaxLower
+// give the range of the lower bounds of the evicted nodes
+type Eviction struct {
+	NumNodes int
+	MinLower float64
+	MaxLower float64
+}
+
+// add includes the node in the summary
+func (e *Eviction) add(n *Node) {
+	if e.NumNodes == 0 {
+		e.MinLower = n.Lower
+		e.MaxLower = n.Lower
+	}
+	e.MinLower = math.Min(e.MinLower, n.Lower)
+	e.MaxLower = math.Max(e.MaxLower, n.Lower)
+	e.NumNodes++
+}
+
+// merge includes the nodes summarised in other
+func (e *Eviction) merge(other Eviction) {
+	if other.NumNodes == 0 {
+		return
+	}
+
+	if e.NumNodes == 0 {
+		*e = other
+		return
+	}
+	e.MinLower = math.Min(e.MinLower, other.MinLower)
+	e.MaxLower = math.Max(e.MaxLower, other.MaxLower)
+	e.NumNodes += other.NumNodes
+}
+
+// Optimal returns true if none of the evicted nodes could lead to a model with a lower
+// criterion value than best. In that case, the search result is still exact
+func (e Eviction) Optimal(best float64) bool {
+	return e.NumNodes == 0 || e.MinLower >= best
+}
+
+// queueBytes returns the memory used by a node in the branch and bound queue
+func queueBytes(n *Node) int64 {
+	return int64(n.EstimateMemory() + queueOverhead)
+}
+
+// evictLeastPromising removes the nodes with the highest lower bounds until at most
+// maxNodes nodes using at most maxBytes bytes are left. If maxBytes is zero, the memory is
+// not limited. The order of the remaining nodes is preserved. It returns a summary of the
+// removed nodes and the number of bytes they used
+func evictLeastPromising(q *list.List, maxNodes int, maxBytes int64) (Eviction, int64) {
+	items := make([]*list.Element, 0, q.Len())
+	for item := q.Front(); item != nil; item = item.Next() {
+		items = append(items, item)
+	}
+	sort.SliceStable(items, func(i, j int) bool {
+		return items[i].Value.(*Node).Lower < items[j].Value.(*Node).Lower
+	})
+
+	sorted := make([]*Node, len(items))
+	for i, item := range items {
+		sorted[i] = item.Value.(*Node)
+	}
+
+	var evicted Eviction
+	var removed int64
+	for i := numToKeep(sorted, maxNodes, maxBytes); i < len(items); i++ {
+		evicted.add(sorted[i])
+		removed += queueBytes(sorted[i])
+		q.Remove(items[i])
+	}
+	return evicted, removed
+}
+
+// memoryUnits maps the suffixes accepted by ParseMemorySize to their size in bytes
+var memoryUnits = map[string]float64{
+	"":    1,
+	"b":   1,
+	"kb":  1e3,
+	"mb":  1e6,
+	"gb":  1e9,
+	"tb":  1e12,
+	"kib": 1 << 10,
+	"mib": 1 << 20,
+	"gib": 1 << 30,
+	"tib": 1 << 40,
+}
+
+// ParseMemorySize converts a memory size such as 16GB, 512MiB or 1.5gb to bytes. Units
+// with an i (KiB, MiB, GiB, TiB) are powers of 1024, the others are powers of 1000.
+// A number without unit is a number of bytes
+func ParseMemorySize(s string) (int64, error) {
+	s = strings.ToLower(strings.TrimSpace(s))
+	split := strings.IndexFunc(s, func(r rune) bool {
+		return (r < '0' || r > '9') && r != '.'
+	})
+	if split < 0 {
+		split = len(s)
+	}
+
+	value, err := strconv.ParseFloat(s[:split], 64)
+	if err != nil || value < 0.0 {
+		return 0, fmt.Errorf("parsememorysize: invalid size %q", s)
+	}
+
+	unit, ok := memoryUnits[strings.TrimSpace(s[split:])]
+	if !ok {
+		return 0, fmt.Errorf("parsememorysize: unknown unit in %q", s)
+	}
+	return int64(value * unit), nil
+}
@@ -0,0 +1,119 @@
+package featselect
+
+import (
+	"container/list"
+	"testing"
+
+	"gonum.org/v1/gonum/floats"
+	"gonum.org/v1/gonum/mat"
+)
+
+func TestParseMemorySize(t *testing.T) {
+	for i, test := range []struct {
+		size    string
+		want    int64
+		wantErr bool
+	}{
+		{size: "100", want: 100},
+		{size: "16GB", want: 16000000000},
+		{size: "512MiB", want: 512 << 20},
+		{size: "1.5gb", want: 1500000000},
+		{size: " 2 KB ", want: 2000},
+		{size: "GB", wantErr: true},
+		{size: "16XB", wantErr: true},
+		{size: "", wantErr: true},
+	} {
+		got, err := ParseMemorySize(test.size)
+		if (err != nil) != test.wantErr {
+			t.Errorf("Test #%d: Unexpected error %v", i, err)
+		}
+
+		if got != test.want {
+			t.Errorf("Test #%d: Expected %d got %d", i, test.want, got)
+		}
+	}
+}
+
+func TestEvictLeastPromising(t *testing.T) {
+	lower := []float64{-1.0, -2.0, -4.0, -0.5, -3.0}
+	nodeSize := queueBytes(NewNode(0, []bool{false, true, false}))
+	for i, test := range []struct {
+		maxNodes int
+		maxBytes int64
+		want     []float64
+		evicted  Eviction
+	}{
+		{maxNodes: 10, maxBytes: 0, want: []float64{-1.0, -2.0, -4.0, -0.5, -3.0}},
+		{maxNodes: 3, maxBytes: 0, want: []float64{-2.0, -4.0, -3.0}, evicted: Eviction{NumNodes: 2, MinLower: -1.0, MaxLower: -0.5}},
+		{maxNodes: 10, maxBytes: 2 * nodeSize, want: []float64{-4.0, -3.0}, evicted: Eviction{NumNodes: 3, MinLower: -2.0, MaxLower: -0.5}},
+	} {
+		queue := list.New()
+		for _, v := range lower {
+			n := NewNode(0, []bool{false, true, false})
+			n.Lower = v
+			queue.PushBack(n)
+		}
+
+		evicted, removed := evictLeastPromising(queue, test.maxNodes, test.maxBytes)
+		got := []float64{}
+		for item := queue.Front(); item != nil; item = item.Next() {
+			got = append(got, item.Value.(*Node).Lower)
+		}
+
+		if !floats.Equal(got, test.want) {
+			t.Errorf("Test #%d: Expected %v got %v", i, test.want, got)
+		}
+
+		if evicted != test.evicted {
+			t.Errorf("Test #%d: Expected %v got %v", i, test.evicted, evicted)
+		}
+
+		if removed != int64(test.evicted.NumNodes)*nodeSize {
+			t.Errorf("Test #%d: Expected %d bytes removed got %d", i, int64(test.evicted.NumNodes)*nodeSize, removed)
+		}
+	}
+}
+
+func TestEvictionOptimal(t *testing.T) {
+	var e Eviction
+	if !e.Optimal(-10.0) {
+		t.Errorf("No evicted nodes should be optimal")
+	}
+
+	e.merge(Eviction{NumNodes: 2, MinLower: -3.0, MaxLower: 1.0})
+	e.merge(Eviction{NumNodes: 1, MinLower: -5.0, MaxLower: -5.0})
+	want := Eviction{NumNodes: 3, MinLower: -5.0, MaxLower: 1.0}
+	if e != want {
+		t.Errorf("Expected %v got %v", want, e)
+	}
+
+	if e.Optimal(-4.0) || !e.Optimal(-6.0) {
+		t.Errorf("Optimal should compare the lowest evicted bound with the best score")
+	}
+}
+
+func TestSelectModelMaxMemory(t *testing.T) {
+	X := mat.NewDense(20, 8, nil)
+	y := make([]float64, 20)
+	for i := 0; i < 20; i++ {
+		for j := 0; j < 8; j++ {
+			X.Set(i, j, float64((i*(j+3)+j*j)%7)+0.1*float64(i))
+		}
+		y[i] = X.At(i, 1) - 2.0*X.At(i, 4) + 0.01*float64(i%3)
+	}
+
+	var sp SearchProgress
+	params := NewSelectModelOptParams()
+	params.MaxMemory = 3 * queueBytes(NewNode(0, make([]bool, 8)))
+	highscore := NewHighscore(10)
+	SelectModel(X, y, highscore, &sp, params)
+
+	evicted := sp.GetEvicted()
+	if evicted.NumNodes == 0 {
+		t.Errorf("Expected nodes to be evicted")
+	}
+
+	if evicted.MinLower > evicted.MaxLower {
+		t.Errorf("Invalid bound range %v", evicted)
+	}
+}
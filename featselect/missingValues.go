@@ -0,0 +1,174 @@
+package featselect
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+)
+
+// ErrMissingValue is returned when a missing value is found and the missing value
+// policy does not allow it
+var ErrMissingValue = errors.New("missing value")
+
+// MissingPolicy determines how missing values are handled when a dataset is parsed
+type MissingPolicy int
+
+const (
+	// MissingError returns an error when a missing value is encountered
+	MissingError MissingPolicy = iota
+
+	// MissingDrop removes all rows that have at least one missing value
+	MissingDrop
+
+	// MissingMean replaces missing values with the mean of the column
+	MissingMean
+
+	// MissingMedian replaces missing values with the median of the column
+	MissingMedian
+
+	// MissingIndicator replaces missing values with the mean of the column and
+	// adds an extra column that is 1 where the value was missing and 0 elsewhere
+	MissingIndicator
+
+	// MissingKeep keeps missing values as NaN and never drops any rows. This is
+	// useful when profiling a dataset, but the fitting routines can not handle NaN
+	MissingKeep
+)
+
+var missingPolicyNames = map[string]MissingPolicy{
+	"error":     MissingError,
+	"drop":      MissingDrop,
+	"mean":      MissingMean,
+	"median":    MissingMedian,
+	"indicator": MissingIndicator,
+}
+
+// ParseMissingPolicy returns the policy corresponding to the passed name.
+// Valid names are error, drop, mean, median and indicator. MissingKeep is not
+// parsed, since NaN would be passed on to the fitting routines
+func ParseMissingPolicy(name string) (MissingPolicy, error) {
+	if policy, ok := missingPolicyNames[strings.ToLower(name)]; ok {
+		return policy, nil
+	}
+	return MissingError, fmt.Errorf("unknown missing value policy %s", name)
+}
+
+// IsMissing returns true if the passed string represents a missing value
+func IsMissing(v string) bool {
+	switch strings.ToLower(strings.TrimSpace(v)) {
+	case "", "na", "n/a", "nan", "null":
+		return true
+	}
+	return false
+}
+
+// MissingIndicatorName returns the name of the indicator column of a feature
+func MissingIndicatorName(name string) string {
+	return name + "_missing"
+}
+
+// applyMissingPolicy handles all NaN entries in data according to the policy. Rows where
+// one of the required columns (e.g. the target) is missing are dropped, unless the policy is
+// MissingKeep. The returned names include any indicator columns that were added.
+func applyMissingPolicy(table *csvTable, data [][]float64, required []int, policy MissingPolicy) ([][]float64, []string, error) {
+	names := make([]string, len(table.names))
+	copy(names, table.names)
+
+	if policy == MissingKeep {
+		return data, names, nil
+	}
+
+	filtered := data[:0]
+	for _, row := range data {
+		if hasNaNAt(row, required) {
+			continue
+		}
+
+		if policy == MissingDrop && hasNaN(row) {
+			continue
+		}
+		filtered = append(filtered, row)
+	}
+	data = filtered
+
+	if policy == MissingDrop || policy == MissingError || len(data) == 0 {
+		return data, names, nil
+	}
+
+	numCols := len(names)
+	for col := 0; col < numCols; col++ {
+		values := []float64{}
+		for _, row := range data {
+			if !math.IsNaN(row[col]) {
+				values = append(values, row[col])
+			}
+		}
+
+		if len(values) == len(data) {
+			continue
+		}
+
+		if len(values) == 0 {
+			return nil, nil, fmt.Errorf("column %s: all values are missing: %w", names[col], ErrMissingValue)
+		}
+
+		fill := Mean(values)
+		if policy == MissingMedian {
+			fill = Median(values)
+		}
+
+		for i, row := range data {
+			indicator := 0.0
+			if math.IsNaN(row[col]) {
+				row[col] = fill
+				indicator = 1.0
+			}
+
+			if policy == MissingIndicator {
+				data[i] = append(row, indicator)
+			}
+		}
+
+		if policy == MissingIndicator {
+			names = append(names, MissingIndicatorName(names[col]))
+		}
+	}
+	return data, names, nil
+}
+
+func hasNaNAt(v []float64, indices []int) bool {
+	for _, i := range indices {
+		if math.IsNaN(v[i]) {
+			return true
+		}
+	}
+	return false
+}
+
+func hasNaN(v []float64) bool {
+	for _, x := range v {
+		if math.IsNaN(x) {
+			return true
+		}
+	}
+	return false
+}
+
+// Median returns the median of the values in v. The passed slice is not altered
+func Median(v []float64) float64 {
+	if len(v) == 0 {
+		return math.NaN()
+	}
+
+	srt := make([]float64, len(v))
+	copy(srt, v)
+	sort.Float64s(srt)
+
+	mid := len(srt) / 2
+	if len(srt)%2 == 1 {
+		return srt[mid]
+	}
+	return 0.5 * (srt[mid-1] + srt[mid])
+}
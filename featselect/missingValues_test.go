@@ -0,0 +1,60 @@
+package featselect
+
+import (
+	"math"
+	"testing"
+)
+
+func TestParseMissingPolicy(t *testing.T) {
+	for i, test := range []struct {
+		name   string
+		expect MissingPolicy
+		isErr  bool
+	}{
+		{name: "error", expect: MissingError},
+		{name: "drop", expect: MissingDrop},
+		{name: "Mean", expect: MissingMean},
+		{name: "median", expect: MissingMedian},
+		{name: "indicator", expect: MissingIndicator},
+		{name: "keep", expect: MissingError, isErr: true},
+		{name: "unknown", expect: MissingError, isErr: true},
+	} {
+		policy, err := ParseMissingPolicy(test.name)
+		if (err != nil) != test.isErr {
+			t.Errorf("Test #%d: unexpected error state %v", i, err)
+		}
+
+		if policy != test.expect {
+			t.Errorf("Test #%d: expected %v got %v", i, test.expect, policy)
+		}
+	}
+}
+
+func TestIsMissing(t *testing.T) {
+	for _, v := range []string{"", " ", "NA", "nan", "NaN", "N/A", "null"} {
+		if !IsMissing(v) {
+			t.Errorf("Expected %q to be missing", v)
+		}
+	}
+
+	for _, v := range []string{"0.0", "1e-5", "abc"} {
+		if IsMissing(v) {
+			t.Errorf("Expected %q not to be missing", v)
+		}
+	}
+}
+
+func TestMedian(t *testing.T) {
+	for i, test := range []struct {
+		values []float64
+		expect float64
+	}{
+		{values: []float64{3.0, 1.0, 2.0}, expect: 2.0},
+		{values: []float64{4.0, 1.0, 2.0, 3.0}, expect: 2.5},
+		{values: []float64{1.0}, expect: 1.0},
+	} {
+		if got := Median(test.values); math.Abs(got-test.expect) > 1e-10 {
+			t.Errorf("Test #%d: expected %f got %f", i, test.expect, got)
+		}
+	}
+}
@@ -1,38 +1,97 @@
 package featselect
 
-// Model is a wrapper around a bit array
+import (
+	"encoding/binary"
+	"fmt"
+	"math/bits"
+)
+
+// Model is a bit array where bit i is set if feature i is selected. A model uses one bit
+// per feature, and is used instead of []bool where many models are stored
 type Model struct {
-	bits []uint8
+	bits []uint64
 	size int
 }
 
 // NewModel create a new model of a given size
 func NewModel(size int) *Model {
 	var m Model
-	m.bits = make([]uint8, size/8+1)
+	m.bits = make([]uint64, (size+63)/64)
 	m.size = size
 	return &m
 }
 
+// NewModelFromBools creates a model where the features that are true in model are selected
+func NewModelFromBools(model []bool) *Model {
+	m := NewModel(len(model))
+	for i, v := range model {
+		if v {
+			m.Set(i)
+		}
+	}
+	return m
+}
+
 // Get return true if feature at position index is 1
 func (m *Model) Get(index int) bool {
-	arrayIndex := index / 8
-	reminder := index - 8*arrayIndex
-	return m.bits[arrayIndex]&uint8(0x01<<uint(reminder)) == uint8(0x01<<uint(reminder))
+	return m.bits[index/64]&(uint64(1)<<uint(index%64)) != 0
 }
 
 // Set sets the bit at position index
 func (m *Model) Set(index int) {
-	arrayIndex := index / 8
-	reminder := index - 8*arrayIndex
-	m.bits[arrayIndex] |= 0x01 << uint(reminder)
+	m.bits[index/64] |= uint64(1) << uint(index%64)
 }
 
 // Flip flips the bit at position
 func (m *Model) Flip(index int) {
-	arrayIndex := index / 8
-	reminder := index - 8*arrayIndex
-	m.bits[arrayIndex] ^= 0x01 << uint(reminder)
+	m.bits[index/64] ^= uint64(1) << uint(index%64)
+}
+
+// Size returns the total number of features
+func (m *Model) Size() int {
+	return m.size
+}
+
+// NumFeatures returns the number of selected features
+func (m *Model) NumFeatures() int {
+	num := 0
+	for _, w := range m.bits {
+		num += bits.OnesCount64(w)
+	}
+	return num
+}
+
+// Selected returns the selected features in increasing order
+func (m *Model) Selected() []int {
+	res := make([]int, 0, m.NumFeatures())
+	for i, w := range m.bits {
+		for w != 0 {
+			res = append(res, 64*i+bits.TrailingZeros64(w))
+			w &= w - 1
+		}
+	}
+	return res
+}
+
+// Clone returns a copy of the model
+func (m *Model) Clone() Model {
+	res := Model{bits: make([]uint64, len(m.bits)), size: m.size}
+	copy(res.bits, m.bits)
+	return res
+}
+
+// Equal returns true if the two models have the same size and selected features
+func (m *Model) Equal(other *Model) bool {
+	if m.size != other.size {
+		return false
+	}
+
+	for i, w := range m.bits {
+		if w != other.bits[i] {
+			return false
+		}
+	}
+	return true
 }
 
 // ToBools converts the model into an array of booleans
@@ -43,3 +102,43 @@ func (m *Model) ToBools() []bool {
 	}
 	return res
 }
+
+// memory returns the estimated memory used by the bits in bytes
+func (m *Model) memory() int {
+	return 8 * len(m.bits)
+}
+
+// key returns a string that identifies the model, such that models can be used as map keys
+func (m *Model) key() string {
+	data, _ := m.MarshalBinary()
+	return string(data)
+}
+
+// MarshalBinary encodes the model as the number of features followed by the bits
+func (m *Model) MarshalBinary() ([]byte, error) {
+	data := make([]byte, 8*(len(m.bits)+1))
+	binary.LittleEndian.PutUint64(data, uint64(m.size))
+	for i, w := range m.bits {
+		binary.LittleEndian.PutUint64(data[8*(i+1):], w)
+	}
+	return data, nil
+}
+
+// UnmarshalBinary decodes a model encoded by MarshalBinary
+func (m *Model) UnmarshalBinary(data []byte) error {
+	if len(data) < 8 {
+		return fmt.Errorf("model: %d bytes is too short for a model", len(data))
+	}
+
+	size := int(binary.LittleEndian.Uint64(data))
+	res := NewModel(size)
+	if len(data) != 8*(len(res.bits)+1) {
+		return fmt.Errorf("model: %d bytes does not match a model of %d features", len(data), size)
+	}
+
+	for i := range res.bits {
+		res.bits[i] = binary.LittleEndian.Uint64(data[8*(i+1):])
+	}
+	*m = *res
+	return nil
+}
@@ -72,3 +72,58 @@ func TestFlipModel(t *testing.T) {
 		}
 	}
 }
+
+func TestModelSelected(t *testing.T) {
+	for i, test := range []struct {
+		model []bool
+		want  []int
+	}{
+		{model: []bool{}, want: []int{}},
+		{model: []bool{false, true, false, true}, want: []int{1, 3}},
+		{model: append(make([]bool, 70), true, false, true), want: []int{70, 72}},
+	} {
+		m := NewModelFromBools(test.model)
+		if got := m.Selected(); !EqualInt(got, test.want) {
+			t.Errorf("Test #%d: Expected %v got %v", i, test.want, got)
+		}
+
+		if m.NumFeatures() != len(test.want) || m.Size() != len(test.model) {
+			t.Errorf("Test #%d: Expected %d of %d features got %d of %d", i, len(test.want), len(test.model), m.NumFeatures(), m.Size())
+		}
+
+		clone := m.Clone()
+		if !clone.Equal(m) {
+			t.Errorf("Test #%d: Clone differs from the model", i)
+		}
+
+		if m.Size() > 0 {
+			clone.Flip(0)
+			if clone.Equal(m) || m.Get(0) {
+				t.Errorf("Test #%d: Flipping the clone should not change the model", i)
+			}
+		}
+	}
+}
+
+func TestModelBinary(t *testing.T) {
+	for i, model := range [][]bool{{}, {true, false, true}, append(make([]bool, 100), true)} {
+		m := NewModelFromBools(model)
+		data, err := m.MarshalBinary()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var res Model
+		if err := res.UnmarshalBinary(data); err != nil {
+			t.Errorf("Test #%d: %v", i, err)
+		}
+
+		if !res.Equal(m) {
+			t.Errorf("Test #%d: Expected %v got %v", i, m.ToBools(), res.ToBools())
+		}
+
+		if err := res.UnmarshalBinary(data[:len(data)-1]); err == nil {
+			t.Errorf("Test #%d: Expected an error for truncated data", i)
+		}
+	}
+}
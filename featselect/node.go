@@ -2,34 +2,40 @@ package featselect
 
 import (
 	"encoding/json"
+	"fmt"
 	"math"
 
 	"gonum.org/v1/gonum/floats"
 )
 
-// Node is a type that holds an array of bools (model) indicating which
+// Node is a type that holds a bit array (model) indicating which
 // features are active. The leven field tells which level in the tree this
 // node is on. The lower and upper fields represents bounds of the score
 // for all models that are childrens of this node. The score field holsd
-// the score of this model.
+// the score of this model. The coefficients are only kept for the nodes in the
+// highscore list. The model of a node is not modified after the node is created,
+// such that it can be shared with the highscore list.
 type Node struct {
-	Model      []bool
+	Model      Model
 	Coeff      []float64
 	Level      int
 	Lower      float64
 	Upper      float64
 	Score      float64
 	WasFlipped bool
+
+	// factors are reused when the children are fitted. They are not part of the JSON
+	// representation, and are recomputed when a search is resumed
+	factors nodeFactors
 }
 
 // GetChildNode creates a child not of node. If flip is true, the "bit" at
 // parent.Level is flipped
 func (n *Node) GetChildNode(flip bool) *Node {
 	var child Node
-	child.Model = make([]bool, len(n.Model))
-	copy(child.Model, n.Model)
+	child.Model = n.Model.Clone()
 	if flip {
-		child.Model[n.Level] = !child.Model[n.Level]
+		child.Model.Flip(n.Level)
 	}
 	child.WasFlipped = flip
 	child.Level = n.Level + 1
@@ -38,7 +44,7 @@ func (n *Node) GetChildNode(flip bool) *Node {
 
 // NodesEqual compare two nodes
 func NodesEqual(node1 *Node, node2 *Node) bool {
-	if len(node1.Model) != len(node2.Model) {
+	if !node1.Model.Equal(&node2.Model) {
 		return false
 	}
 
@@ -46,12 +52,6 @@ func NodesEqual(node1 *Node, node2 *Node) bool {
 		return false
 	}
 
-	for i := 0; i < len(node1.Model); i++ {
-		if node1.Model[i] != node2.Model[i] {
-			return false
-		}
-	}
-
 	tol := 1e-10
 	if math.Abs(node1.Lower-node2.Lower) > tol || math.Abs(node1.Upper-node2.Upper) > tol || math.Abs(node1.Score-node2.Score) > tol {
 		return false
@@ -67,12 +67,27 @@ func NodesEqual(node1 *Node, node2 *Node) bool {
 func NewNode(level int, model []bool) *Node {
 	var node Node
 	node.Level = level
-	node.Model = make([]bool, len(model))
-	copy(node.Model, model)
-	node.Coeff = make([]float64, NumFeatures(model))
+	node.Model = *NewModelFromBools(model)
 	return &node
 }
 
+// highscoreEntry returns a node for the highscore list with the model, the scores and the
+// coefficients of n. The coefficients are moved to the returned node, such that they are
+// not kept for the nodes in the queue
+func (n *Node) highscoreEntry() *Node {
+	entry := &Node{
+		Model:      n.Model,
+		Coeff:      n.Coeff,
+		Level:      n.Level,
+		Lower:      n.Lower,
+		Upper:      n.Upper,
+		Score:      n.Score,
+		WasFlipped: n.WasFlipped,
+	}
+	n.Coeff = nil
+	return entry
+}
+
 // Fields that will be written to JSON file when the Node
 // struct is jsonified
 type nodeJsonified struct {
@@ -83,27 +98,36 @@ type nodeJsonified struct {
 	Score          float64   `json:"score"`
 	Level          int       `json:"level"`
 	Coeff          []float64 `json:"coeff"`
+	Names          []string  `json:"names,omitempty"`
 }
 
 // MarshalJSON converts a node to JSON representation
 func (n *Node) MarshalJSON() ([]byte, error) {
-	selected := make([]int, NumFeatures(n.Model))
-	numInserted := 0
-	for i, v := range n.Model {
-		if v {
-			selected[numInserted] = i
-			numInserted++
+	return json.Marshal(n.jsonified(nil))
+}
+
+// jsonified returns the fields that are written to JSON. If featNames is given,
+// the names of the selected features are included
+func (n *Node) jsonified(featNames []string) *nodeJsonified {
+	selected := n.Model.Selected()
+
+	var names []string
+	if featNames != nil {
+		names = make([]string, len(selected))
+		for i, v := range selected {
+			names[i] = featNames[v]
 		}
 	}
-	return json.Marshal(&nodeJsonified{
+	return &nodeJsonified{
 		Selected:       selected,
-		TotNumFeatures: len(n.Model),
+		TotNumFeatures: n.Model.Size(),
 		Lower:          n.Lower,
 		Upper:          n.Upper,
 		Score:          n.Score,
 		Level:          n.Level,
 		Coeff:          n.Coeff,
-	})
+		Names:          names,
+	}
 }
 
 // UnmarshalJSON decodes a JSON representation of a Node
@@ -117,17 +141,20 @@ func (n *Node) UnmarshalJSON(data []byte) error {
 	n.Score = aux.Score
 	n.Level = aux.Level
 	n.Coeff = aux.Coeff
-	n.Model = make([]bool, aux.TotNumFeatures)
+	n.Model = *NewModel(aux.TotNumFeatures)
 
 	for _, v := range aux.Selected {
-		n.Model[v] = true
+		if v < 0 || v >= aux.TotNumFeatures {
+			return fmt.Errorf("node: selected feature %d out of range (%d features)", v, aux.TotNumFeatures)
+		}
+		n.Model.Set(v)
 	}
 	return nil
 }
 
 // EstimateMemory estimate the memory consumption of the node in bytes
 func (n *Node) EstimateMemory() int {
-	estimate := len(n.Model)
+	estimate := n.Model.memory()
 	estimate += 8 * len(n.Coeff)
 
 	// Level attribute (int)
@@ -138,22 +165,13 @@ func (n *Node) EstimateMemory() int {
 
 	// WasFlipped
 	estimate++
-	return estimate
+	return estimate + n.factors.memory()
 }
 
 // ToSparseCoeff converts a node into a SparseCoeff structure
 func (n *Node) ToSparseCoeff() SparseCoeff {
 	var sp SparseCoeff
-	nFeat := NumFeatures(n.Model)
 	sp.Coeff = n.Coeff
-	sp.Selection = make([]int, nFeat)
-
-	sIdx := 0
-	for i, v := range n.Model {
-		if v {
-			sp.Selection[sIdx] = i
-			sIdx++
-		}
-	}
+	sp.Selection = n.Model.Selected()
 	return sp
 }
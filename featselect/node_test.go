@@ -8,7 +8,7 @@ import (
 
 func TestGetChildNode(t *testing.T) {
 	var parent Node
-	parent.Model = []bool{true, false, false, false}
+	parent.Model = *NewModelFromBools([]bool{true, false, false, false})
 	parent.Level = 1
 	child := parent.GetChildNode(false)
 
@@ -16,9 +16,14 @@ func TestGetChildNode(t *testing.T) {
 		t.Errorf("ChildNode: Wrong level. Expected 2, Got %v", child.Level)
 	}
 
-	if child.Model[1] {
+	if child.Model.Get(1) {
 		t.Errorf("ChildNode: Model is not updated correctly")
 	}
+
+	flipped := parent.GetChildNode(true)
+	if !flipped.Model.Get(1) || parent.Model.Get(1) {
+		t.Errorf("ChildNode: Only the model of the child should be flipped")
+	}
 }
 
 func TestNodeEqual(t *testing.T) {
@@ -57,7 +62,7 @@ func TestNodeEqual(t *testing.T) {
 func TestNodeJSON(t *testing.T) {
 	buf := bytes.NewBufferString("")
 	origNode := Node{
-		Model: []bool{false, true, false},
+		Model: *NewModelFromBools([]bool{false, true, false}),
 		Coeff: []float64{5.1},
 		Level: 2,
 		Lower: -4.1,
@@ -83,18 +88,25 @@ func TestNodeJSON(t *testing.T) {
 func TestNodeMemoryEstimate(t *testing.T) {
 	for i, test := range []struct {
 		model  []bool
+		coeff  []float64
 		expect int
 	}{
 		{
 			model:  []bool{true, true, true, true},
-			expect: 65,
+			expect: 37,
 		},
 		{
 			model:  []bool{true, true, false, true},
-			expect: 57,
+			coeff:  []float64{1.0, 2.0, 3.0},
+			expect: 61,
+		},
+		{
+			model:  make([]bool, 130),
+			expect: 53,
 		},
 	} {
 		n := NewNode(0, test.model)
+		n.Coeff = test.coeff
 		est := n.EstimateMemory()
 
 		if est != test.expect {
@@ -2,12 +2,14 @@ package featselect
 
 import (
 	"fmt"
+	"math"
 
 	"gonum.org/v1/gonum/mat"
 )
 
 // NormalizedData is a structure that is used to normalise
-// columns to zero mean and unit variance
+// columns to zero mean and unit variance. When it is created from sufficient
+// statistics, X is nil and only XᵀX and Xᵀy of the normalised data are stored
 type NormalizedData struct {
 	X       *mat.Dense
 	y       []float64
@@ -16,40 +18,88 @@ type NormalizedData struct {
 	stdY    float64
 	muY     float64
 	HasBias bool
+	gram    *mat.SymDense
+	xTy     []float64
+	numRows int
+}
+
+// Dims returns the number of data points and the number of features
+func (n *NormalizedData) Dims() (int, int) {
+	if n.X == nil {
+		return n.numRows, len(n.xTy)
+	}
+	return n.X.Dims()
 }
 
 // NewNormalizedData initializes a new structure with normalised data
 // Note that both X and y will be altered by this method.
 func NewNormalizedData(X *mat.Dense, y []float64) *NormalizedData {
-	var normD NormalizedData
-	normD.stdY = Std(y)
-	normD.muY = Mean(y)
-	normD.X = X
-	normD.y = y
+	return NewWeightedNormalizedData(X, y, nil)
+}
 
-	for i := 0; i < len(y); i++ {
-		y[i] = (y[i] - normD.muY) / normD.stdY
+// TryNewNormalizedData is the same as NewNormalizedData, but returns an error instead
+// of panicking, see TryNewWeightedNormalizedData
+func TryNewNormalizedData(X *mat.Dense, y []float64) (*NormalizedData, error) {
+	return TryNewWeightedNormalizedData(X, y, nil)
+}
+
+// NewWeightedNormalizedData initializes a new structure where the columns are normalised
+// using the weighted mean and standard deviation. After normalisation, each row is multiplied
+// by the square root of its weight (scaled such that the mean weight is one). Thus, methods
+// that minimize the sum of squared residuals of the normalized data minimize the weighted sum
+// of squared residuals. If w is nil, the result is the same as NewNormalizedData. Note that
+// both X and y will be altered by this method.
+func NewWeightedNormalizedData(X *mat.Dense, y []float64, w []float64) *NormalizedData {
+	normD, err := TryNewWeightedNormalizedData(X, y, w)
+	if err != nil {
+		panic(err)
 	}
+	return normD
+}
 
+// TryNewWeightedNormalizedData is the same as NewWeightedNormalizedData, but returns an
+// error instead of panicking. An error wrapping ErrDimensionMismatch is returned if the
+// lengths of y and w do not match the number of rows in X, and a *ColumnError wrapping
+// ErrConstantColumn if any column other than the first is constant. X and y are only
+// altered if no error is returned.
+func TryNewWeightedNormalizedData(X *mat.Dense, y []float64, w []float64) (*NormalizedData, error) {
 	nr, nc := X.Dims()
-	tmp := make([]float64, nr)
+	if len(y) != nr || (w != nil && len(w) != nr) {
+		return nil, fmt.Errorf("normdata: %d rows in design matrix, %d targets and %d weights: %w", nr, len(y), len(w), ErrDimensionMismatch)
+	}
+
+	var normD NormalizedData
+	if w == nil {
+		w = make([]float64, len(y))
+		for i := range w {
+			w[i] = 1.0
+		}
+	}
 
 	normD.mu = make([]float64, nc)
 	normD.std = make([]float64, nc)
 
+	cols := make([][]float64, nc)
 	for c := 0; c < nc; c++ {
-		for r := 0; r < nr; r++ {
-			tmp[r] = X.At(r, c)
-		}
-
-		normD.std[c] = Std(tmp)
-		normD.mu[c] = Mean(tmp)
+		cols[c] = mat.Col(nil, c, X)
+		normD.std[c] = WeightedStd(cols[c], w)
+		normD.mu[c] = WeightedMean(cols[c], w)
 
 		if normD.std[c] < 1e-10 && c != 0 {
-			msg := fmt.Sprintf("normdata: Only the first column can be constant! Std: %e of column %d", normD.std[c], c)
-			panic(msg)
+			return nil, fmt.Errorf("normdata: only the first column can be constant: %w", &ColumnError{Column: c, Err: ErrConstantColumn})
 		}
+	}
+
+	normD.stdY = WeightedStd(y, w)
+	normD.muY = WeightedMean(y, w)
+	normD.X = X
+	normD.y = y
 
+	for i := 0; i < len(y); i++ {
+		y[i] = (y[i] - normD.muY) / normD.stdY
+	}
+
+	for c := 0; c < nc; c++ {
 		stdtmp := normD.std[c]
 		if stdtmp < 1e-10 {
 			normD.HasBias = true
@@ -57,10 +107,73 @@ func NewNormalizedData(X *mat.Dense, y []float64) *NormalizedData {
 		}
 
 		for r := 0; r < nr; r++ {
-			X.Set(r, c, (tmp[r]-normD.mu[c])/stdtmp)
+			X.Set(r, c, (cols[c][r]-normD.mu[c])/stdtmp)
+		}
+	}
+
+	meanW := Mean(w)
+	for r := 0; r < nr; r++ {
+		scale := math.Sqrt(w[r] / meanW)
+		y[r] *= scale
+		row := X.RawRowView(r)
+		for c := range row {
+			row[c] *= scale
+		}
+	}
+	return &normD, nil
+}
+
+// NewNormalizedDataFromStats initializes the normalised data from sufficient statistics.
+// The result is the same as NewWeightedNormalizedData, except that the normalised design
+// matrix is not available. Only methods that need XᵀX and Xᵀy (e.g. LassoCrdDesc with the
+// Empirical covariance matrix) can be used.
+func NewNormalizedDataFromStats(s *SufficientStats) *NormalizedData {
+	normD, err := TryNewNormalizedDataFromStats(s)
+	if err != nil {
+		panic(err)
+	}
+	return normD
+}
+
+// TryNewNormalizedDataFromStats is the same as NewNormalizedDataFromStats, but returns a
+// *ColumnError wrapping ErrConstantColumn instead of panicking if any column other than
+// the first is constant
+func TryNewNormalizedDataFromStats(s *SufficientStats) (*NormalizedData, error) {
+	var normD NormalizedData
+	normD.mu = s.Means()
+	normD.std = s.Stds()
+	normD.muY = s.MeanY()
+	normD.stdY = s.StdY()
+	normD.numRows = s.NumRows
+
+	nc := len(normD.mu)
+	scale := make([]float64, nc)
+	for c := 0; c < nc; c++ {
+		if normD.std[c] < 1e-10 && c != 0 {
+			return nil, fmt.Errorf("normdata: only the first column can be constant: %w", &ColumnError{Column: c, Err: ErrConstantColumn})
+		}
+
+		scale[c] = normD.std[c]
+		if scale[c] < 1e-10 {
+			normD.HasBias = true
+			scale[c] = 1.0
+		}
+	}
+
+	// The rows are scaled by the square root of w/mean(w), which is the same as
+	// multiplying the weighted sums by NumRows/SumW. The centred sums are used, since
+	// subtracting the means from the raw sums suffers from cancellation
+	wScale := float64(s.NumRows) / s.SumW
+	normD.gram = mat.NewSymDense(nc, nil)
+	normD.xTy = make([]float64, nc)
+	for i := 0; i < nc; i++ {
+		normD.xTy[i] = wScale * s.CovXy[i] / (scale[i] * normD.stdY)
+		for j := i; j < nc; j++ {
+			v := wScale * s.CovXX.At(i, j) / (scale[i] * scale[j])
+			normD.gram.SetSym(i, j, v)
 		}
 	}
-	return &normD
+	return &normD, nil
 }
 
 // LinearNormalizationTransformation computes the difference in coefficient in the expansion
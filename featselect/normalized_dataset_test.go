@@ -1,6 +1,7 @@
 package featselect
 
 import (
+	"math"
 	"testing"
 
 	"gonum.org/v1/gonum/floats"
@@ -24,3 +25,32 @@ func TestNormalizedDataset(t *testing.T) {
 		t.Errorf("unexpected values expected\n%v\ngot\n%v\n", data.y, normY)
 	}
 }
+
+func TestWeightedNormalizedDataset(t *testing.T) {
+	// Unit weights should give the same result as the unweighted version
+	X := mat.NewDense(3, 2, []float64{-4.0, -2.0, 0.0, 2.0, 4.0, 0.0})
+	y := []float64{-1.0, 0.0, 1.0}
+	data := NewWeightedNormalizedData(mat.DenseCopyOf(X), []float64{-1.0, 0.0, 1.0}, []float64{1.0, 1.0, 1.0})
+	unweighted := NewNormalizedData(X, y)
+
+	if !mat.EqualApprox(data.X, unweighted.X, 1e-10) || !floats.EqualApprox(data.y, unweighted.y, 1e-10) {
+		t.Errorf("Unit weights should give the same normalization as no weights")
+	}
+
+	// Weighted column means should be zero
+	X = mat.NewDense(4, 2, []float64{1.0, 2.0, 2.0, 5.0, 3.0, 1.0, 6.0, 2.0})
+	w := []float64{1.0, 3.0, 2.0, 0.5}
+	data = NewWeightedNormalizedData(X, []float64{1.0, 2.0, 4.0, 3.0}, w)
+
+	meanW := Mean(w)
+	for c := 0; c < 2; c++ {
+		sum := 0.0
+		for r := 0; r < 4; r++ {
+			sum += math.Sqrt(w[r]*meanW) * data.X.At(r, c)
+		}
+
+		if math.Abs(sum) > 1e-10 {
+			t.Errorf("Weighted mean of column %d is %f", c, sum)
+		}
+	}
+}
@@ -0,0 +1,691 @@
+package featselect
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"math"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"gonum.org/v1/gonum/mat"
+)
+
+// ErrNpyFormat is returned when a file is not a valid (or supported) NumPy array
+var ErrNpyFormat = errors.New("invalid npy format")
+
+const npyMagic = "\x93NUMPY"
+
+// Names of the arrays in a npz archive holding a dataset
+const (
+	npzX          = "X"
+	npzY          = "y"
+	npzNames      = "names"
+	npzTargetCol  = "target_col"
+	npzWeights    = "weights"
+	npzWeightName = "weight_name"
+	npzCatGroup   = "categorical"
+	npzCatNames   = "categorical_names"
+	npzCatLevels  = "categorical_levels"
+)
+
+var (
+	npyDescrRe   = regexp.MustCompile(`'descr'\s*:\s*'([^']*)'`)
+	npyFortranRe = regexp.MustCompile(`'fortran_order'\s*:\s*(True|False)`)
+	npyShapeRe   = regexp.MustCompile(`'shape'\s*:\s*\(([^)]*)\)`)
+)
+
+// npyHeader holds the information in the header of a npy file
+type npyHeader struct {
+	descr        string
+	fortranOrder bool
+	shape        []int
+}
+
+// size returns the number of elements in the array
+func (h *npyHeader) size() int {
+	n := 1
+	for _, s := range h.shape {
+		n *= s
+	}
+	return n
+}
+
+// WriteNpy writes data as a little endian float64 array in the NumPy npy format.
+// The data are stored in row-major (C) order and shape gives the dimensions of the array.
+func WriteNpy(handle io.Writer, data []float64, shape []int) error {
+	h := npyHeader{descr: "<f8", shape: shape}
+	if h.size() != len(data) {
+		return fmt.Errorf("writenpy: shape %v does not match %d values", shape, len(data))
+	}
+	return writeNpyArray(handle, &h, data)
+}
+
+// writeNpyArray writes the header followed by the data in little endian byte order
+func writeNpyArray(handle io.Writer, h *npyHeader, data interface{}) error {
+	if err := writeNpyHeader(handle, h); err != nil {
+		return err
+	}
+	return binary.Write(handle, binary.LittleEndian, data)
+}
+
+// WriteNpyStrings writes a one dimensional array of unicode strings in the NumPy npy format
+func WriteNpyStrings(handle io.Writer, values []string) error {
+	width := 1
+	for _, v := range values {
+		if n := utf8.RuneCountInString(v); n > width {
+			width = n
+		}
+	}
+
+	h := npyHeader{descr: fmt.Sprintf("<U%d", width), shape: []int{len(values)}}
+	if err := writeNpyHeader(handle, &h); err != nil {
+		return err
+	}
+
+	buf := make([]uint32, width)
+	for _, v := range values {
+		for i := range buf {
+			buf[i] = 0
+		}
+
+		i := 0
+		for _, r := range v {
+			buf[i] = uint32(r)
+			i++
+		}
+
+		if err := binary.Write(handle, binary.LittleEndian, buf); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ParseNpy reads a numerical array in the NumPy npy format. All numerical types are
+// converted to float64. The data are returned in row-major (C) order together with the
+// shape of the array.
+func ParseNpy(handle io.Reader) ([]float64, []int, error) {
+	h, err := readNpyHeader(handle)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	order, kind, size, err := parseNpyDescr(h.descr)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	raw := make([]byte, h.size()*size)
+	if _, err := io.ReadFull(handle, raw); err != nil {
+		return nil, nil, fmt.Errorf("parsenpy: %v: %w", err, ErrNpyFormat)
+	}
+
+	data := make([]float64, h.size())
+	for i := range data {
+		v, err := npyNumber(raw[i*size:(i+1)*size], order, kind)
+		if err != nil {
+			return nil, nil, err
+		}
+		data[i] = v
+	}
+
+	if h.fortranOrder {
+		data = fortran2C(data, h.shape)
+	}
+	return data, h.shape, nil
+}
+
+// ParseNpyStrings reads a one dimensional array of unicode strings in the NumPy npy format
+func ParseNpyStrings(handle io.Reader) ([]string, error) {
+	h, err := readNpyHeader(handle)
+	if err != nil {
+		return nil, err
+	}
+
+	order, kind, size, err := parseNpyDescr(h.descr)
+	if err != nil {
+		return nil, err
+	}
+
+	if kind != 'U' || len(h.shape) > 1 {
+		return nil, fmt.Errorf("parsenpystrings: expected a 1D unicode array, got %s with shape %v: %w", h.descr, h.shape, ErrNpyFormat)
+	}
+
+	raw := make([]byte, h.size()*size)
+	if _, err := io.ReadFull(handle, raw); err != nil {
+		return nil, fmt.Errorf("parsenpystrings: %v: %w", err, ErrNpyFormat)
+	}
+
+	values := make([]string, h.size())
+	for i := range values {
+		var sb strings.Builder
+		for j := i * size; j < (i+1)*size; j += 4 {
+			r := order.Uint32(raw[j : j+4])
+			if r == 0 {
+				break
+			}
+			sb.WriteRune(rune(r))
+		}
+		values[i] = sb.String()
+	}
+	return values, nil
+}
+
+// SaveNpy writes all columns of the dataset (including the target) to a two dimensional
+// npy file. The column names are not stored.
+func (dset *Dataset) SaveNpy(fname string) error {
+	file, err := os.Create(fname)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	nr, nc := dset.X.Dims()
+	data := make([]float64, 0, nr*(nc+1))
+	for i := 0; i < nr; i++ {
+		shift := 0
+		for j := 0; j < nc+1; j++ {
+			if j == dset.TargetCol {
+				data = append(data, dset.Y[i])
+				shift = 1
+			} else {
+				data = append(data, dset.X.At(i, j-shift))
+			}
+		}
+	}
+	return WriteNpy(file, data, []int{nr, nc + 1})
+}
+
+// ReadNpy reads a dataset from a two dimensional npy file where each column is a
+// variable. The columns are named x0, x1, etc. If targetCol is negative, it is
+// counted from the last column.
+func ReadNpy(fname string, targetCol int) (*Dataset, error) {
+	file, err := os.Open(fname)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	data, shape, err := ParseNpy(file)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(shape) != 2 {
+		return nil, fmt.Errorf("readnpy: expected a 2D array, got shape %v: %w", shape, ErrNpyFormat)
+	}
+
+	names := make([]string, shape[1])
+	for i := range names {
+		names[i] = fmt.Sprintf("x%d", i)
+	}
+	return newDatasetFromTable(data, shape[0], names, targetCol)
+}
+
+// SaveNpz writes the dataset to a npz archive. See SaveNpzHandle for the content.
+func (dset *Dataset) SaveNpz(fname string) error {
+	file, err := os.Create(fname)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	return dset.SaveNpzHandle(file)
+}
+
+// SaveNpzHandle writes the dataset as a npz archive (the format of numpy.savez).
+// The archive holds the arrays X, y, names (all column names, including the target)
+// and target_col. If the dataset has weights, the arrays weights and weight_name
+// are added. If it has categorical groups, the arrays categorical (the group of each
+// feature, -1 if the feature is not categorical), categorical_names (the name of each
+// group) and categorical_levels (the level of each feature) are added.
+func (dset *Dataset) SaveNpzHandle(handle io.Writer) error {
+	archive := zip.NewWriter(handle)
+	nr, nc := dset.X.Dims()
+
+	X := make([]float64, 0, nr*nc)
+	for i := 0; i < nr; i++ {
+		X = append(X, dset.X.RawRowView(i)...)
+	}
+
+	writers := map[string]func(io.Writer) error{
+		npzX:     func(w io.Writer) error { return WriteNpy(w, X, []int{nr, nc}) },
+		npzY:     func(w io.Writer) error { return WriteNpy(w, dset.Y, []int{len(dset.Y)}) },
+		npzNames: func(w io.Writer) error { return WriteNpyStrings(w, dset.Names) },
+		npzTargetCol: func(w io.Writer) error {
+			return writeNpyArray(w, &npyHeader{descr: "<i8"}, []int64{int64(dset.TargetCol)})
+		},
+	}
+	order := []string{npzX, npzY, npzNames, npzTargetCol}
+
+	if dset.Weights != nil {
+		writers[npzWeights] = func(w io.Writer) error { return WriteNpy(w, dset.Weights, []int{len(dset.Weights)}) }
+		writers[npzWeightName] = func(w io.Writer) error { return WriteNpyStrings(w, []string{dset.weightColumnName()}) }
+		order = append(order, npzWeights, npzWeightName)
+	}
+
+	if len(dset.Categorical) > 0 {
+		groups := make([]int64, nc)
+		levels := make([]string, nc)
+		names := make([]string, len(dset.Categorical))
+		for i := range groups {
+			groups[i] = -1
+		}
+
+		for i, g := range dset.Categorical {
+			names[i] = g.Name
+			for j, f := range g.Features {
+				groups[f] = int64(i)
+				levels[f] = g.Levels[j]
+			}
+		}
+		writers[npzCatGroup] = func(w io.Writer) error {
+			return writeNpyArray(w, &npyHeader{descr: "<i8", shape: []int{nc}}, groups)
+		}
+		writers[npzCatNames] = func(w io.Writer) error { return WriteNpyStrings(w, names) }
+		writers[npzCatLevels] = func(w io.Writer) error { return WriteNpyStrings(w, levels) }
+		order = append(order, npzCatGroup, npzCatNames, npzCatLevels)
+	}
+
+	for _, name := range order {
+		w, err := archive.CreateHeader(&zip.FileHeader{Name: name + ".npy", Method: zip.Store})
+		if err != nil {
+			return err
+		}
+
+		if err := writers[name](w); err != nil {
+			return err
+		}
+	}
+	return archive.Close()
+}
+
+// ReadNpz reads a dataset from a npz archive. See ParseNpz for the expected content.
+func ReadNpz(fname string) (*Dataset, error) {
+	archive, err := zip.OpenReader(fname)
+	if err != nil {
+		return nil, err
+	}
+	defer archive.Close()
+	return parseNpzArchive(&archive.Reader)
+}
+
+// ParseNpz reads a dataset from a npz archive (e.g. created with numpy.savez). The
+// archive must hold the arrays X (2D) and y (1D). Optionally, it may contain names
+// (column names including the target), target_col (index of the target in names,
+// the last column if not given), weights, weight_name and the categorical groups
+// (categorical, categorical_names and categorical_levels, see SaveNpzHandle).
+func ParseNpz(handle io.ReaderAt, size int64) (*Dataset, error) {
+	archive, err := zip.NewReader(handle, size)
+	if err != nil {
+		return nil, err
+	}
+	return parseNpzArchive(archive)
+}
+
+func parseNpzArchive(archive *zip.Reader) (*Dataset, error) {
+	files := make(map[string][]byte)
+	for _, f := range archive.File {
+		rc, err := f.Open()
+		if err != nil {
+			return nil, err
+		}
+
+		content, err := ioutil.ReadAll(rc)
+		rc.Close()
+		if err != nil {
+			return nil, err
+		}
+		files[strings.TrimSuffix(f.Name, ".npy")] = content
+	}
+
+	for _, name := range []string{npzX, npzY} {
+		if _, ok := files[name]; !ok {
+			return nil, fmt.Errorf("parsenpz: array %s is missing: %w", name, ErrNpyFormat)
+		}
+	}
+
+	X, xShape, err := ParseNpy(bytes.NewReader(files[npzX]))
+	if err != nil {
+		return nil, fmt.Errorf("parsenpz: %s: %w", npzX, err)
+	}
+
+	y, yShape, err := ParseNpy(bytes.NewReader(files[npzY]))
+	if err != nil {
+		return nil, fmt.Errorf("parsenpz: %s: %w", npzY, err)
+	}
+
+	if len(xShape) != 2 || len(yShape) != 1 || xShape[0] != yShape[0] {
+		return nil, fmt.Errorf("parsenpz: inconsistent shapes X%v and y%v: %w", xShape, yShape, ErrNpyFormat)
+	}
+
+	var dset Dataset
+	dset.X = mat.NewDense(xShape[0], xShape[1], X)
+	dset.Y = y
+	dset.TargetCol = xShape[1]
+
+	if content, ok := files[npzTargetCol]; ok {
+		target, _, err := ParseNpy(bytes.NewReader(content))
+		if err != nil || len(target) != 1 {
+			return nil, fmt.Errorf("parsenpz: %s must be a single integer: %w", npzTargetCol, ErrNpyFormat)
+		}
+		dset.TargetCol = int(target[0])
+		if dset.TargetCol < 0 {
+			dset.TargetCol += xShape[1] + 1
+		}
+
+		if dset.TargetCol < 0 || dset.TargetCol > xShape[1] {
+			return nil, fmt.Errorf("parsenpz: target column %d out of range: %w", int(target[0]), ErrNpyFormat)
+		}
+	}
+
+	if content, ok := files[npzNames]; ok {
+		dset.Names, err = ParseNpyStrings(bytes.NewReader(content))
+		if err != nil {
+			return nil, fmt.Errorf("parsenpz: %s: %w", npzNames, err)
+		}
+
+		if len(dset.Names) != xShape[1]+1 {
+			return nil, fmt.Errorf("parsenpz: expected %d names got %d: %w", xShape[1]+1, len(dset.Names), ErrNpyFormat)
+		}
+	} else {
+		dset.Names = make([]string, xShape[1]+1)
+		for i := range dset.Names {
+			dset.Names[i] = fmt.Sprintf("x%d", nameIdx2Feat(i, dset.TargetCol))
+		}
+		dset.Names[dset.TargetCol] = "y"
+	}
+
+	if content, ok := files[npzWeights]; ok {
+		dset.Weights, _, err = ParseNpy(bytes.NewReader(content))
+		if err != nil {
+			return nil, fmt.Errorf("parsenpz: %s: %w", npzWeights, err)
+		}
+
+		if len(dset.Weights) != len(dset.Y) {
+			return nil, fmt.Errorf("parsenpz: expected %d weights got %d: %w", len(dset.Y), len(dset.Weights), ErrNpyFormat)
+		}
+
+		for _, w := range dset.Weights {
+			if w < 0.0 || math.IsNaN(w) || math.IsInf(w, 0) {
+				return nil, fmt.Errorf("parsenpz: weight %v: %w", w, ErrInvalidWeight)
+			}
+		}
+	}
+
+	if content, ok := files[npzWeightName]; ok {
+		name, err := ParseNpyStrings(bytes.NewReader(content))
+		if err != nil || len(name) != 1 {
+			return nil, fmt.Errorf("parsenpz: %s must be a single string: %w", npzWeightName, ErrNpyFormat)
+		}
+		dset.WeightName = name[0]
+	}
+
+	if content, ok := files[npzCatGroup]; ok {
+		dset.Categorical, err = parseNpzCategorical(content, files[npzCatNames], files[npzCatLevels], xShape[1])
+		if err != nil {
+			return nil, err
+		}
+	}
+	return &dset, nil
+}
+
+// parseNpzCategorical returns the categorical groups stored in a npz archive
+func parseNpzCategorical(groupContent []byte, nameContent []byte, levelContent []byte, numFeatures int) ([]CategoricalGroup, error) {
+	groups, _, err := ParseNpy(bytes.NewReader(groupContent))
+	if err != nil {
+		return nil, fmt.Errorf("parsenpz: %s: %w", npzCatGroup, err)
+	}
+
+	names, err := ParseNpyStrings(bytes.NewReader(nameContent))
+	if err != nil {
+		return nil, fmt.Errorf("parsenpz: %s: %w", npzCatNames, err)
+	}
+
+	levels, err := ParseNpyStrings(bytes.NewReader(levelContent))
+	if err != nil {
+		return nil, fmt.Errorf("parsenpz: %s: %w", npzCatLevels, err)
+	}
+
+	if len(groups) != numFeatures || len(levels) != numFeatures {
+		return nil, fmt.Errorf("parsenpz: expected %d categorical groups and levels got %d and %d: %w", numFeatures, len(groups), len(levels), ErrNpyFormat)
+	}
+
+	res := make([]CategoricalGroup, len(names))
+	for i, name := range names {
+		res[i].Name = name
+	}
+
+	for f, g := range groups {
+		if g < 0 {
+			continue
+		}
+
+		if int(g) >= len(res) {
+			return nil, fmt.Errorf("parsenpz: categorical group %d out of range: %w", int(g), ErrNpyFormat)
+		}
+		res[int(g)].Features = append(res[int(g)].Features, f)
+		res[int(g)].Levels = append(res[int(g)].Levels, levels[f])
+	}
+	return res, nil
+}
+
+// newDatasetFromTable creates a dataset from row-major data where each column is a variable
+func newDatasetFromTable(data []float64, nr int, names []string, targetCol int) (*Dataset, error) {
+	nc := len(names)
+	if targetCol < 0 {
+		targetCol += nc
+	}
+
+	if targetCol < 0 || targetCol >= nc {
+		return nil, fmt.Errorf("target column %d out of range for %d columns", targetCol, nc)
+	}
+
+	var dset Dataset
+	dset.X = mat.NewDense(nr, nc-1, nil)
+	dset.Y = make([]float64, nr)
+	dset.Names = names
+	dset.TargetCol = targetCol
+	for i := 0; i < nr; i++ {
+		for j := 0; j < nc; j++ {
+			v := data[i*nc+j]
+			if j == targetCol {
+				dset.Y[i] = v
+			} else {
+				dset.X.Set(i, nameIdx2Feat(j, targetCol), v)
+			}
+		}
+	}
+	return &dset, nil
+}
+
+func writeNpyHeader(handle io.Writer, h *npyHeader) error {
+	shape := make([]string, len(h.shape))
+	for i, s := range h.shape {
+		shape[i] = strconv.Itoa(s)
+	}
+
+	shapeStr := strings.Join(shape, ", ")
+	if len(shape) == 1 {
+		shapeStr += ","
+	}
+
+	dict := fmt.Sprintf("{'descr': '%s', 'fortran_order': False, 'shape': (%s), }", h.descr, shapeStr)
+
+	// The total header length (magic, version, length field and dict) is padded to a
+	// multiple of 64 bytes and terminated by a newline
+	preamble := len(npyMagic) + 2 + 2
+	padding := 64 - (preamble+len(dict)+1)%64
+	if padding == 64 {
+		padding = 0
+	}
+	dict += strings.Repeat(" ", padding) + "\n"
+
+	if len(dict) > math.MaxUint16 {
+		return fmt.Errorf("writenpy: header too long")
+	}
+
+	var buf bytes.Buffer
+	buf.WriteString(npyMagic)
+	buf.Write([]byte{1, 0})
+	binary.Write(&buf, binary.LittleEndian, uint16(len(dict)))
+	buf.WriteString(dict)
+	_, err := handle.Write(buf.Bytes())
+	return err
+}
+
+func readNpyHeader(handle io.Reader) (*npyHeader, error) {
+	preamble := make([]byte, len(npyMagic)+2)
+	if _, err := io.ReadFull(handle, preamble); err != nil {
+		return nil, fmt.Errorf("%v: %w", err, ErrNpyFormat)
+	}
+
+	if string(preamble[:len(npyMagic)]) != npyMagic {
+		return nil, fmt.Errorf("missing magic string: %w", ErrNpyFormat)
+	}
+
+	var headerLen int
+	switch major := preamble[len(npyMagic)]; major {
+	case 1:
+		var n uint16
+		if err := binary.Read(handle, binary.LittleEndian, &n); err != nil {
+			return nil, fmt.Errorf("%v: %w", err, ErrNpyFormat)
+		}
+		headerLen = int(n)
+	case 2, 3:
+		var n uint32
+		if err := binary.Read(handle, binary.LittleEndian, &n); err != nil {
+			return nil, fmt.Errorf("%v: %w", err, ErrNpyFormat)
+		}
+		headerLen = int(n)
+	default:
+		return nil, fmt.Errorf("unsupported version %d: %w", major, ErrNpyFormat)
+	}
+
+	raw := make([]byte, headerLen)
+	if _, err := io.ReadFull(handle, raw); err != nil {
+		return nil, fmt.Errorf("%v: %w", err, ErrNpyFormat)
+	}
+	dict := string(raw)
+
+	descr := npyDescrRe.FindStringSubmatch(dict)
+	fortran := npyFortranRe.FindStringSubmatch(dict)
+	shape := npyShapeRe.FindStringSubmatch(dict)
+	if descr == nil || fortran == nil || shape == nil {
+		return nil, fmt.Errorf("invalid header %s: %w", strings.TrimSpace(dict), ErrNpyFormat)
+	}
+
+	h := npyHeader{descr: descr[1], fortranOrder: fortran[1] == "True"}
+	for _, s := range strings.Split(shape[1], ",") {
+		s = strings.TrimSpace(s)
+		if s == "" {
+			continue
+		}
+
+		n, err := strconv.Atoi(strings.TrimSuffix(s, "L"))
+		if err != nil || n < 0 {
+			return nil, fmt.Errorf("invalid shape (%s): %w", shape[1], ErrNpyFormat)
+		}
+		h.shape = append(h.shape, n)
+	}
+	return &h, nil
+}
+
+// parseNpyDescr returns the byte order, the kind (f, i, u, b or U) and the item size in bytes
+func parseNpyDescr(descr string) (binary.ByteOrder, byte, int, error) {
+	if len(descr) < 3 {
+		return nil, 0, 0, fmt.Errorf("unsupported dtype %s: %w", descr, ErrNpyFormat)
+	}
+
+	var order binary.ByteOrder
+	switch descr[0] {
+	case '<', '|', '=':
+		order = binary.LittleEndian
+	case '>':
+		order = binary.BigEndian
+	default:
+		return nil, 0, 0, fmt.Errorf("unsupported dtype %s: %w", descr, ErrNpyFormat)
+	}
+
+	kind := descr[1]
+	size, err := strconv.Atoi(descr[2:])
+	if err != nil {
+		return nil, 0, 0, fmt.Errorf("unsupported dtype %s: %w", descr, ErrNpyFormat)
+	}
+
+	valid := false
+	switch kind {
+	case 'f':
+		valid = size == 4 || size == 8
+	case 'i', 'u':
+		valid = size == 1 || size == 2 || size == 4 || size == 8
+	case 'b':
+		valid = size == 1
+	case 'U':
+		valid = size > 0
+		size *= 4
+	}
+
+	if !valid {
+		return nil, 0, 0, fmt.Errorf("unsupported dtype %s: %w", descr, ErrNpyFormat)
+	}
+	return order, kind, size, nil
+}
+
+// npyNumber converts the raw bytes of one element into a float64
+func npyNumber(raw []byte, order binary.ByteOrder, kind byte) (float64, error) {
+	switch kind {
+	case 'f':
+		if len(raw) == 4 {
+			return float64(math.Float32frombits(order.Uint32(raw))), nil
+		}
+		return math.Float64frombits(order.Uint64(raw)), nil
+	case 'b', 'u':
+		return float64(npyUint(raw, order)), nil
+	case 'i':
+		u := npyUint(raw, order)
+		shift := uint(64 - 8*len(raw))
+		return float64(int64(u<<shift) >> shift), nil
+	}
+	return 0.0, fmt.Errorf("dtype %c is not numerical: %w", kind, ErrNpyFormat)
+}
+
+func npyUint(raw []byte, order binary.ByteOrder) uint64 {
+	switch len(raw) {
+	case 1:
+		return uint64(raw[0])
+	case 2:
+		return uint64(order.Uint16(raw))
+	case 4:
+		return uint64(order.Uint32(raw))
+	}
+	return order.Uint64(raw)
+}
+
+// fortran2C converts data stored in column-major order to row-major order
+func fortran2C(data []float64, shape []int) []float64 {
+	res := make([]float64, len(data))
+	index := make([]int, len(shape))
+	for i := range data {
+		// i is the row-major position, index the corresponding multi index
+		rem := i
+		for d := len(shape) - 1; d >= 0; d-- {
+			index[d] = rem % shape[d]
+			rem /= shape[d]
+		}
+
+		pos := 0
+		for d := len(shape) - 1; d >= 0; d-- {
+			pos = pos*shape[d] + index[d]
+		}
+		res[i] = data[pos]
+	}
+	return res
+}
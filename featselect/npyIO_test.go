@@ -0,0 +1,170 @@
+package featselect
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"reflect"
+	"testing"
+
+	"gonum.org/v1/gonum/floats"
+	"gonum.org/v1/gonum/mat"
+)
+
+// npyBytes creates a version 1 npy file with the passed header dictionary
+func npyBytes(dict string, data interface{}) []byte {
+	var buf bytes.Buffer
+	buf.WriteString(npyMagic)
+	buf.Write([]byte{1, 0})
+	binary.Write(&buf, binary.LittleEndian, uint16(len(dict)+1))
+	buf.WriteString(dict + "\n")
+	binary.Write(&buf, binary.LittleEndian, data)
+	return buf.Bytes()
+}
+
+func TestWriteParseNpy(t *testing.T) {
+	data := []float64{1e-9, 2.0, -3.5, 1.0 / 3.0, 5.0, 6.0}
+	var buf bytes.Buffer
+	if err := WriteNpy(&buf, data, []int{2, 3}); err != nil {
+		t.Errorf("Unexpected error %v", err)
+		return
+	}
+
+	if (buf.Len()-6*8)%64 != 0 {
+		t.Errorf("Header length is not a multiple of 64")
+	}
+
+	res, shape, err := ParseNpy(&buf)
+	if err != nil || !floats.Equal(res, data) || !EqualInt(shape, []int{2, 3}) {
+		t.Errorf("Expected %v with shape (2, 3) got %v with shape %v (err: %v)", data, res, shape, err)
+	}
+
+	if err := WriteNpy(&buf, data, []int{4}); err == nil {
+		t.Errorf("Expected error when the shape does not match the data")
+	}
+}
+
+func TestParseNpyDtypes(t *testing.T) {
+	for i, test := range []struct {
+		raw    []byte
+		expect []float64
+		shape  []int
+	}{
+		{
+			raw:    npyBytes("{'descr': '<i4', 'fortran_order': False, 'shape': (3,), }", []int32{-1, 2, 3}),
+			expect: []float64{-1.0, 2.0, 3.0},
+			shape:  []int{3},
+		},
+		{
+			raw:    npyBytes("{'descr': '<f4', 'fortran_order': False, 'shape': (2,), }", []float32{0.5, -2.0}),
+			expect: []float64{0.5, -2.0},
+			shape:  []int{2},
+		},
+		{
+			raw:    npyBytes("{'descr': '|u1', 'fortran_order': False, 'shape': (2,), }", []uint8{255, 1}),
+			expect: []float64{255.0, 1.0},
+			shape:  []int{2},
+		},
+		{
+			raw:    npyBytes("{'descr': '<i8', 'fortran_order': False, 'shape': (), }", []int64{-7}),
+			expect: []float64{-7.0},
+			shape:  nil,
+		},
+		{
+			// Column-major 2x3 matrix [[1, 2, 3], [4, 5, 6]]
+			raw:    npyBytes("{'descr': '<f8', 'fortran_order': True, 'shape': (2, 3), }", []float64{1, 4, 2, 5, 3, 6}),
+			expect: []float64{1, 2, 3, 4, 5, 6},
+			shape:  []int{2, 3},
+		},
+	} {
+		res, shape, err := ParseNpy(bytes.NewReader(test.raw))
+		if err != nil {
+			t.Errorf("Test #%d: Unexpected error %v", i, err)
+			continue
+		}
+
+		if !floats.Equal(res, test.expect) || !EqualInt(shape, test.shape) {
+			t.Errorf("Test #%d: Expected %v with shape %v got %v with shape %v", i, test.expect, test.shape, res, shape)
+		}
+	}
+}
+
+func TestParseNpyErrors(t *testing.T) {
+	for i, raw := range [][]byte{
+		[]byte("not a npy file"),
+		npyBytes("{'descr': '<c16', 'fortran_order': False, 'shape': (1,), }", []float64{1.0, 2.0}),
+		npyBytes("{'descr': '<f8', 'fortran_order': False, 'shape': (3,), }", []float64{1.0}),
+		npyBytes("{'descr': '<f8', 'shape': (1,), }", []float64{1.0}),
+	} {
+		if _, _, err := ParseNpy(bytes.NewReader(raw)); !errors.Is(err, ErrNpyFormat) {
+			t.Errorf("Test #%d: Expected ErrNpyFormat got %v", i, err)
+		}
+	}
+}
+
+func TestWriteParseNpyStrings(t *testing.T) {
+	names := []string{"x", "temperature", "Δt", ""}
+	var buf bytes.Buffer
+	if err := WriteNpyStrings(&buf, names); err != nil {
+		t.Errorf("Unexpected error %v", err)
+		return
+	}
+
+	res, err := ParseNpyStrings(&buf)
+	if err != nil || !strArrayEqual(res, names) {
+		t.Errorf("Expected %v got %v (err: %v)", names, res, err)
+	}
+}
+
+func TestNpzRoundTrip(t *testing.T) {
+	var dset Dataset
+	dset.X = mat.NewDense(3, 2, []float64{1e-12, 2.0, 3.0, 4.0, 5.0, 1.0 / 7.0})
+	dset.Y = []float64{-1.0, 0.1, 1e20}
+	dset.Names = []string{"a", "y", "b"}
+	dset.TargetCol = 1
+	dset.Weights = []float64{1.0, 2.0, 0.5}
+	dset.WeightName = "w"
+	dset.Categorical = []CategoricalGroup{{Name: "c", Levels: []string{"x", "yz"}, Features: []int{0, 1}}}
+
+	var buf bytes.Buffer
+	if err := dset.SaveNpzHandle(&buf); err != nil {
+		t.Errorf("Unexpected error %v", err)
+		return
+	}
+
+	res, err := ParseNpz(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Errorf("Unexpected error %v", err)
+		return
+	}
+
+	if !mat.Equal(res.X, dset.X) || !floats.Equal(res.Y, dset.Y) || !floats.Equal(res.Weights, dset.Weights) {
+		t.Errorf("Round trip is not exact.\nExpected\n%v\nGot\n%v\n", dset, res)
+	}
+
+	if res.TargetCol != 1 || !strArrayEqual(res.Names, dset.Names) || res.WeightName != "w" {
+		t.Errorf("Expected names %v (target 1) got %v (target %d)", dset.Names, res.Names, res.TargetCol)
+	}
+
+	if !reflect.DeepEqual(res.Categorical, dset.Categorical) {
+		t.Errorf("Expected categorical groups %v got %v", dset.Categorical, res.Categorical)
+	}
+}
+
+func TestNewDatasetFromTable(t *testing.T) {
+	data := []float64{1, 2, 3, 4, 5, 6}
+	dset, err := newDatasetFromTable(data, 2, []string{"x0", "x1", "x2"}, -2)
+	if err != nil {
+		t.Errorf("Unexpected error %v", err)
+		return
+	}
+
+	expectX := mat.NewDense(2, 2, []float64{1, 3, 4, 6})
+	if !mat.Equal(dset.X, expectX) || !floats.Equal(dset.Y, []float64{2, 5}) || dset.TargetCol != 1 {
+		t.Errorf("Unexpected dataset %v", dset)
+	}
+
+	if _, err := newDatasetFromTable(data, 2, []string{"x0", "x1", "x2"}, 3); err == nil {
+		t.Errorf("Expected error when the target column is out of range")
+	}
+}
@@ -2,8 +2,11 @@ package featselect
 
 import (
 	"container/list"
+	"context"
+	"errors"
 	"fmt"
 	"math"
+	"time"
 
 	"gonum.org/v1/gonum/mat"
 )
@@ -46,11 +49,28 @@ func NumFeatures(model []bool) int {
 }
 
 // SelectModelOptParams is a struct holding optional parameters for the SelectModel
-// function.
+// function. If Weights is not nil, the weighted sum of squared residuals is minimized
+// when the models are fitted. Criterion is minimized by the search (AICc if nil). Only
+// models satisfying Constraints (if not nil) are considered.
+//
+// If CheckpointFile is given, the state of the search is written to it every
+// CheckpointInterval (if positive) and when the search is interrupted. The search is
+// interrupted when Interrupt is closed (or receives a value) or when Budget is used
+// up. A search is continued from a checkpoint by setting Resume.
 type SelectModelOptParams struct {
-	Cutoff       float64
-	RootModel    []bool
-	MaxQueueSize int
+	Cutoff             float64
+	RootModel          []bool
+	MaxQueueSize       int
+	MaxMemory          int64
+	Strategy           SearchStrategy
+	Constraints        *Constraints
+	Weights            []float64
+	Criterion          Criterion
+	CheckpointFile     string
+	CheckpointInterval time.Duration
+	Interrupt          <-chan struct{}
+	Resume             *Checkpoint
+	Budget             Budget
 }
 
 // NewSelectModelOptParams initialises the struct with optional parameters with the
@@ -60,56 +80,183 @@ func NewSelectModelOptParams() *SelectModelOptParams {
 	optParams.Cutoff = 0.0
 	optParams.RootModel = nil
 	optParams.MaxQueueSize = 10000000000
+	optParams.Weights = nil
+	optParams.Criterion = AiccCriterion
 	return &optParams
 }
 
-// SelectModel finds the model which minimizes AICC. X is the NxM design matrix, y is a vector of length
+// SelectModel finds the model which minimizes the criterion in params (AICC by default). X is the NxM design matrix, y is a vector of length
 // N, highscore keeps track of the best models and cutoff is a value that is added to the lower bounds
 // when judging if a node shoudl be added. The check for if a node will be added or not is this
 //
 // lower_bound + cutoff < current_best_score
+//
+// SelectModel panics if the input is invalid, see SelectModelContext
 func SelectModel(X mat.Matrix, y []float64, highscore *Highscore, sp *SearchProgress, params *SelectModelOptParams) {
-	queue := list.New()
+	err := SelectModelContext(context.Background(), X, y, highscore, sp, params)
+	panicOnInvalid(err)
+}
 
-	_, ncols := X.Dims()
+// SelectModelContext finds the best model in the same way as SelectModel, but the search
+// stops when ctx is cancelled or params.Budget is used up. The highscore list then holds
+// the best models found so far, and ctx.Err() or ErrBudgetExhausted is returned. Invalid
+// input is reported by an error wrapping ErrTooFewFeatures, ErrDimensionMismatch or
+// ErrInvalidWeight. If a model can not be fitted, the search stops and an error wrapping
+// ErrNumerical is returned.
+func SelectModelContext(ctx context.Context, X mat.Matrix, y []float64, highscore *Highscore, sp *SearchProgress, params *SelectModelOptParams) error {
+	if nr, _ := X.Dims(); nr != len(y) {
+		return fmt.Errorf("selectmodel: %d rows in design matrix and %d targets: %w", nr, len(y), ErrDimensionMismatch)
+	}
+
+	if params != nil && params.Weights != nil {
+		if len(params.Weights) != len(y) {
+			return fmt.Errorf("selectmodel: %d targets and %d weights: %w", len(y), len(params.Weights), ErrDimensionMismatch)
+		}
+
+		for _, w := range params.Weights {
+			if w < 0.0 || math.IsNaN(w) || math.IsInf(w, 0) {
+				return fmt.Errorf("selectmodel: weight %v: %w", w, ErrInvalidWeight)
+			}
+		}
+		X, y = WeightRows(X, y, params.Weights)
+	}
+	return selectModel(ctx, &DenseSystem{X: X, Y: y}, highscore, sp, params)
+}
+
+// SelectModelFromStats finds the model which minimizes AICC in the same way as SelectModel,
+// but the models are fitted from sufficient statistics such that the design matrix is not
+// needed. Weights must be applied when the statistics are accumulated, thus params.Weights
+// has to be nil.
+func SelectModelFromStats(stats *SufficientStats, highscore *Highscore, sp *SearchProgress, params *SelectModelOptParams) {
+	err := SelectModelFromStatsContext(context.Background(), stats, highscore, sp, params)
+	panicOnInvalid(err)
+}
+
+// SelectModelFromStatsContext is the same as SelectModelFromStats, but the search can be
+// stopped in the same way as SelectModelContext and invalid input is returned as an error
+func SelectModelFromStatsContext(ctx context.Context, stats *SufficientStats, highscore *Highscore, sp *SearchProgress, params *SelectModelOptParams) error {
+	if params != nil && params.Weights != nil {
+		return fmt.Errorf("selectmodelfromstats: weights must be applied when the statistics are accumulated")
+	}
+	return selectModel(ctx, stats, highscore, sp, params)
+}
+
+// panicOnInvalid panics if err is not caused by the search being stopped early
+func panicOnInvalid(err error) {
+	if err == nil || errors.Is(err, ErrInterrupted) || errors.Is(err, ErrBudgetExhausted) {
+		return
+	}
+	panic(err)
+}
+
+func selectModel(ctx context.Context, sys LinearSystem, highscore *Highscore, sp *SearchProgress, params *SelectModelOptParams) error {
+	_, err := branchAndBound(ctx, sys, highscore, sp, params)
+	return err
+}
+
+// branchAndBound runs the search and returns its final state. If the search was stopped
+// early, the queue of the state holds the nodes that were not explored
+func branchAndBound(ctx context.Context, sys LinearSystem, highscore *Highscore, sp *SearchProgress, params *SelectModelOptParams) (*Checkpoint, error) {
+	_, ncols := sys.Dims()
 
 	if params == nil {
 		params = NewSelectModelOptParams()
 	}
 
 	if ncols < 3 {
-		panic("SelectModel: The number of features has to be larger or equal to 3.")
+		return nil, fmt.Errorf("selectmodel: %d features, at least 3 are needed: %w", ncols, ErrTooFewFeatures)
 	}
 
 	if params.RootModel == nil {
 		params.RootModel = make([]bool, ncols)
 	} else {
 		if len(params.RootModel) != ncols {
-			panic("SelectModel: Inconsistent length of rootModel.")
+			return nil, fmt.Errorf("selectmodel: root model has length %d, expected %d: %w", len(params.RootModel), ncols, ErrDimensionMismatch)
 		}
 	}
 
+	sys = newGramSystem(sys)
 	rootNode := NewNode(0, params.RootModel)
+	criterion, err := prepareCriterion(params.Criterion, sys)
+	if err != nil {
+		return nil, err
+	}
+
+	constraints := params.Constraints
+	if err := constraints.Validate(ncols); err != nil {
+		return nil, err
+	}
+
+	if constraints != nil {
+		criterion = &constrainedCriterion{Criterion: criterion, c: constraints}
+	}
 
 	log2Pruned := 0.0
 	numChecked := 0
+	var evicted Eviction
+	queue := newNodeQueue(params.Strategy)
+	if params.Resume != nil {
+		if params.Resume.NumFeatures != ncols {
+			return nil, fmt.Errorf("selectmodel: the checkpoint was created for %d features, the data has %d: %w", params.Resume.NumFeatures, ncols, ErrDimensionMismatch)
+		}
+
+		for _, n := range params.Resume.Queue {
+			queue.Push(n)
+		}
+
+		for _, n := range params.Resume.Highscore {
+			highscore.Insert(n)
+		}
+		numChecked = params.Resume.NumChecked
+		log2Pruned = params.Resume.Log2Pruned
+		evicted = params.Resume.Evicted
+		sp.Set(highscore.BestScore(), numChecked, log2Pruned)
+		sp.SetEvicted(evicted)
+
+		if queue.Len() == 0 {
+			return params.Resume, nil
+		}
+		rootNode = queue.Pop()
+	}
 
 	node := make(chan *Node)
 	score := make(chan *Node)
 	wantChildNode := make(chan *Node)
 	childReady := make(chan bool)
 	pruneCh := make(chan int)
+	errCh := make(chan error)
 	currentBestScore := -1e100
+	if highscore.Len() > 0 {
+		currentBestScore = highscore.BestScore()
+	}
 
 	numScoreWorkers := 8
 	for i := 0; i < numScoreWorkers; i++ {
-		go ScoreWorker(node, score, X, y)
+		go scoreWorker(node, score, errCh, sys, criterion, constraints)
 	}
 
 	numChildWorkers := 8
 	for i := 0; i < numChildWorkers; i++ {
-		go CreateChildNodes(wantChildNode, pruneCh, node, childReady, X, y, params.Cutoff, highscore)
+		go createChildNodes(wantChildNode, pruneCh, node, childReady, errCh, sys, criterion, constraints, params.Cutoff, highscore)
+	}
+
+	var tick <-chan time.Time
+	if params.CheckpointFile != "" && params.CheckpointInterval > 0 {
+		ticker := time.NewTicker(params.CheckpointInterval)
+		defer ticker.Stop()
+		tick = ticker.C
 	}
+	interrupt := params.Interrupt
+	tracker := newBudgetTracker(ctx, params.Budget)
+	defer tracker.stop()
+	done := tracker.Done()
+	var stopErr error
+
+	// A child worker waits for a new parent when readyWaiting is true. The state of the
+	// search is only consistent (and can be checkpointed) when no nodes are in progress.
+	readyWaiting := false
+	checkpointDue := false
+	interrupted := false
 
 	wantChildNode <- rootNode
 	numInProgress := 2
@@ -121,24 +268,23 @@ exploreLoop:
 			numInProgress--
 			sp.Set(highscore.BestScore(), numChecked, log2Pruned)
 
-			if isNewNode(ns) {
-				highscore.Insert(ns)
+			if isNewNode(ns) && constraints.Satisfied(ns.Model.ToBools()) {
+				highscore.Insert(ns.highscoreEntry())
 				numChecked++
 
 				if highscore.BestScore() > currentBestScore {
 					currentBestScore = highscore.BestScore()
-					CleanQueue(queue, -currentBestScore)
+					queue.Prune(-currentBestScore)
 				}
 			}
 
 			if ns.Level < ncols {
-				queue.PushBack(ns)
+				queue.Push(ns)
 			}
 
-			if queue.Len() > params.MaxQueueSize {
-				RemoveLeastPromising(queue)
-				fmt.Printf("Reached maximum buffer size. Removed least promising without exploring them\n"+
-					"Number of nodes in the queue %d\n", queue.Len())
+			if e := limitQueue(queue, params); e.NumNodes > 0 {
+				evicted.merge(e)
+				sp.SetEvicted(evicted)
 			}
 
 			if numInProgress <= 0 && queue.Len() == 0 {
@@ -146,28 +292,104 @@ exploreLoop:
 			}
 
 		case prLevel := <-pruneCh:
-			log2Pruned = NewLog2Pruned(log2Pruned, ncols-prLevel)
+			log2Pruned = NewLog2Pruned(log2Pruned, float64(ncols-prLevel))
 			numInProgress--
 			if numInProgress <= 0 && queue.Len() == 0 {
 				break exploreLoop
 			}
 
 		case <-childReady:
-			element := queue.Front()
-			var node *Node
-			node = nil
-			if element != nil {
-				node = element.Value.(*Node)
-				queue.Remove(queue.Front())
-				numInProgress += 2
+			readyWaiting = true
+
+		case err := <-errCh:
+			if stopErr == nil {
+				stopErr = err
 			}
-			wantChildNode <- node
+
+		case <-tick:
+			checkpointDue = true
+
+		case <-interrupt:
+			stopErr = ErrInterrupted
+			interrupt = nil
+
+		case <-done:
+			done = nil
+		}
+
+		if stopErr == nil {
+			stopErr = tracker.err(numChecked, 0)
+		}
+
+		if stopErr != nil && !interrupted {
+			interrupted = true
+			checkpointDue = params.CheckpointFile != ""
 		}
+
+		if !readyWaiting {
+			continue
+		}
+
+		if checkpointDue || interrupted {
+			if numInProgress > 0 {
+				continue
+			}
+
+			if checkpointDue {
+				checkpointDue = false
+				cp := newCheckpoint(ncols, queue, highscore, numChecked, log2Pruned)
+				cp.Evicted = evicted
+				if err := cp.Save(params.CheckpointFile); err != nil {
+					fmt.Printf("Could not write checkpoint: %s\n", err)
+				}
+			}
+
+			if interrupted {
+				break exploreLoop
+			}
+		}
+
+		readyWaiting = false
+		node := queue.Pop()
+		if node != nil {
+			numInProgress += 2
+		}
+		wantChildNode <- node
 	}
 	close(node)
 	close(wantChildNode)
 	close(pruneCh)
 	close(score)
+
+	cp := newCheckpoint(ncols, queue, highscore, numChecked, log2Pruned)
+	cp.Evicted = evicted
+	return cp, stopErr
+}
+
+// limitQueue evicts the least promising nodes if the queue exceeds the maximum size or
+// memory given in params
+func limitQueue(queue nodeQueue, params *SelectModelOptParams) Eviction {
+	if queue.Len() <= params.MaxQueueSize && (params.MaxMemory <= 0 || queue.Bytes() <= params.MaxMemory) {
+		return Eviction{}
+	}
+
+	maxNodes := int(evictFraction * float64(params.MaxQueueSize))
+	maxBytes := int64(evictFraction * float64(params.MaxMemory))
+	e := queue.Evict(maxNodes, maxBytes)
+	fmt.Printf("Reached maximum queue size. Evicted %d nodes with lower bounds in [%e, %e] without exploring them\n"+
+		"Number of nodes in the queue %d (%d bytes)\n", e.NumNodes, e.MinLower, e.MaxLower, queue.Len(), queue.Bytes())
+	return e
+}
+
+// newCheckpoint collects the state of the search
+func newCheckpoint(numFeatures int, queue nodeQueue, h *Highscore, numChecked int, log2Pruned float64) *Checkpoint {
+	cp := Checkpoint{NumFeatures: numFeatures, NumChecked: numChecked, Log2Pruned: log2Pruned}
+	cp.Queue = queue.Nodes()
+
+	for e := h.Items.Front(); e != nil; e = e.Next() {
+		cp.Highscore = append(cp.Highscore, e.Value.(*Node))
+	}
+	return &cp
 }
 
 // BruteForceSelect runs through all possible models
@@ -181,11 +403,11 @@ func BruteForceSelect(X *mat.Dense, y []float64) *Highscore {
 
 	for queue.Front() != nil {
 		currentNode := queue.Front().Value.(*Node)
-		if NumFeatures(currentNode.Model) > 0 && isNewNode(currentNode) {
-			design := GetDesignMatrix(currentNode.Model, X)
+		if numFeat := currentNode.Model.NumFeatures(); numFeat > 0 && isNewNode(currentNode) {
+			design := GetDesignMatrix(currentNode.Model.ToBools(), X)
 			currentNode.Coeff = Fit(design, y)
 			rss := Rss(design, currentNode.Coeff, y)
-			currentNode.Score = -Aicc(NumFeatures(currentNode.Model), len(y), rss)
+			currentNode.Score = -Aicc(numFeat, len(y), rss)
 			highscore.Insert(currentNode)
 		}
 		queue.Remove(queue.Front())
@@ -216,31 +438,63 @@ func isNewNode(node *Node) bool {
 
 // ScoreWorker is a function that calculates the score of a node
 func ScoreWorker(nodeCh <-chan *Node, scoreCh chan<- *Node, X mat.Matrix, y []float64) {
-	nrows, _ := X.Dims()
-	for n := range nodeCh {
-		numFeat := NumFeatures(n.Model)
+	scoreWorker(nodeCh, scoreCh, nil, &DenseSystem{X: X, Y: y}, AiccCriterion, nil)
+}
 
-		if numFeat > 0 && isNewNode(n) {
-			design := GetDesignMatrix(n.Model, X)
-			n.Coeff = Fit(design, y)
-			rss := Rss(design, n.Coeff, y)
-			n.Score = -Aicc(numFeat, nrows, rss)
-		} else {
-			n.Score = -math.MaxFloat64
+// scoreWorker scores the nodes received on nodeCh. If a fit fails, the error is sent on
+// errCh before the node is passed on with the lowest possible score
+func scoreWorker(nodeCh <-chan *Node, scoreCh chan<- *Node, errCh chan<- error, sys LinearSystem, criterion Criterion, c *Constraints) {
+	nrows, _ := sys.Dims()
+	for n := range nodeCh {
+		numFeat := n.Model.NumFeatures()
+		model := n.Model.ToBools()
+
+		n.Score = -math.MaxFloat64
+		if numFeat > 0 && isNewNode(n) && c.Satisfied(model) {
+			func() {
+				defer recoverNumerical(errCh)
+				var rss float64
+				n.Coeff, rss = sys.FitModel(model)
+				n.Score = -criterion.Score(numFeat, nrows, rss)
+			}()
 		}
 		scoreCh <- n
 	}
 }
 
+// recoverNumerical converts a panic in a fit to an error wrapping ErrNumerical that is sent
+// on errCh. If errCh is nil, the panic is not recovered
+func recoverNumerical(errCh chan<- error) {
+	if errCh == nil {
+		return
+	}
+
+	if r := recover(); r != nil {
+		errCh <- fmt.Errorf("selectmodel: fit failed: %v: %w", r, ErrNumerical)
+	}
+}
+
 // CreateChild creates a child not of a parent. Returns nil if number of rows is zero or the lower bound
 // is lower than the current best score
 func CreateChild(node *Node, flip bool, X mat.Matrix, y []float64, cutoff float64, h *Highscore) *Node {
+	return createChild(node, flip, &DenseSystem{X: X, Y: y}, AiccCriterion, nil, cutoff, h)
+}
+
+func createChild(node *Node, flip bool, sys LinearSystem, criterion Criterion, c *Constraints, cutoff float64, h *Highscore) *Node {
 	child := node.GetChildNode(flip)
-	n := NumFeatures(child.Model)
-	nrows, _ := X.Dims()
+	model := child.Model.ToBools()
+	if !c.feasible(model, child.Level) {
+		return nil
+	}
+
+	n := child.Model.NumFeatures()
+	nrows, _ := sys.Dims()
 	if n < nrows {
 		if n > 0 {
-			child.Lower, child.Upper = BoundsAICC(child.Model, child.Level, X, y)
+			// The models used for the bounds are fitted by updating the factorisation of the
+			// parent. The factorisations are not passed on, to keep the queue small
+			cache := newFactorCache(sys, node.factors.all())
+			child.Lower, child.Upper = criterion.Bounds(model, child.Level, cache)
 		} else {
 			child.Lower = -1e100
 			child.Upper = 1e100
@@ -258,10 +512,27 @@ func CreateChild(node *Node, flip bool, X mat.Matrix, y []float64, cutoff float6
 // CreateChildNodes creates left child of a parent node
 func CreateChildNodes(parentCh <-chan *Node, pruneCh chan<- int, nodeCh chan<- *Node, ready chan<- bool,
 	X mat.Matrix, y []float64, cutoff float64, h *Highscore) {
+	createChildNodes(parentCh, pruneCh, nodeCh, ready, nil, &DenseSystem{X: X, Y: y}, AiccCriterion, nil, cutoff, h)
+}
+
+// createChildNodes creates the children of the parents received on parentCh. If a fit
+// fails, the error is sent on errCh and the child is pruned
+func createChildNodes(parentCh <-chan *Node, pruneCh chan<- int, nodeCh chan<- *Node, ready chan<- bool,
+	errCh chan<- error, sys LinearSystem, criterion Criterion, c *Constraints, cutoff float64, h *Highscore) {
 	for parent := range parentCh {
 		if parent != nil {
+			func() {
+				defer recoverNumerical(errCh)
+				parent.factors = parentFactors(sys, parent)
+			}()
+
 			for _, flip := range []bool{false, true} {
-				n := CreateChild(parent, flip, X, y, cutoff, h)
+				var n *Node
+				func() {
+					defer recoverNumerical(errCh)
+					n = createChild(parent, flip, sys, criterion, c, cutoff, h)
+				}()
+
 				if n == nil {
 					pruneCh <- parent.Level
 				} else {
@@ -273,17 +544,39 @@ func CreateChildNodes(parentCh <-chan *Node, pruneCh chan<- int, nodeCh chan<- *
 	}
 }
 
+// parentFactors returns the factorisation of the model of a node that is explored. It is
+// computed when the node is taken from the queue, such that the queued nodes hold no
+// factorisations
+func parentFactors(sys LinearSystem, parent *Node) nodeFactors {
+	nrows, _ := sys.Dims()
+	if n := parent.Model.NumFeatures(); n == 0 || n >= nrows {
+		return nodeFactors{}
+	}
+
+	cache := newFactorCache(sys, nil)
+	cache.FitModel(parent.Model.ToBools())
+	return nodeFactors{model: cache.last()}
+}
+
 // CleanQueue removes all items where the lower bound is lower than the current
 // score
 func CleanQueue(q *list.List, threshold float64) {
+	cleanQueue(q, threshold)
+}
+
+// cleanQueue removes the same items as CleanQueue and returns the memory they used
+func cleanQueue(q *list.List, threshold float64) int64 {
+	var removed int64
 	var next *list.Element
 	for item := q.Front(); item != nil; item = next {
 		next = item.Next()
 
-		if item.Value.(*Node).Lower > threshold {
+		if n := item.Value.(*Node); n.Lower > threshold {
+			removed += queueBytes(n)
 			q.Remove(item)
 		}
 	}
+	return removed
 }
 
 // RemoveLeastPromising removes the least nodes that has is lower than the
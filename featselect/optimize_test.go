@@ -2,6 +2,8 @@ package featselect
 
 import (
 	"container/list"
+	"context"
+	"errors"
 	"math"
 	"sort"
 	"testing"
@@ -138,6 +140,126 @@ func TestSelectModel(t *testing.T) {
 	}
 }
 
+func TestSelectModelWeighted(t *testing.T) {
+	X := mat.NewDense(7, 4, []float64{1.0, 0.0, 0.0, 0.0,
+		1.0, 1.0, 1.0, 1.0,
+		1.0, 2.0, 4.0, 8.0,
+		1.0, 3.0, 9.0, 15.0,
+		1.0, 4.0, 9.0, 30.0,
+		1.0, 2.0, 3.0, 6.0,
+		1.0, -2.0, 5.0, 4.0})
+
+	y := []float64{1.0, 2.0, 5.0, 7.0, 10.0, 8.0, 15.0}
+	w := []float64{1.0, 0.5, 2.0, 1.0, 3.0, 0.2, 1.0}
+	var sp SearchProgress
+	highscore := NewHighscore(100)
+	params := NewSelectModelOptParams()
+	params.Weights = w
+	SelectModel(X, y, highscore, &sp, params)
+
+	Xw, yw := WeightRows(X, y, w)
+	brute := BruteForceSelect(Xw, yw)
+
+	if math.Abs(highscore.BestScore()-brute.BestScore()) > 1e-10 {
+		t.Errorf("BestScore differ. Brute force: %v, BandB: %v", brute.BestScore(), highscore.BestScore())
+	}
+
+	best := highscore.Items.Front().Value.(*Node)
+	rss := RssWeighted(GetDesignMatrix(best.Model.ToBools(), X), best.Coeff, y, w)
+	if math.Abs(-Aicc(best.Model.NumFeatures(), len(y), rss)-best.Score) > 1e-10 {
+		t.Errorf("Score does not match the weighted AICc")
+	}
+}
+
+func TestCoefficientsOnlyInHighscore(t *testing.T) {
+	X, y := incrementalTestData(40, 20)
+	highscore := NewHighscore(5)
+	var sp SearchProgress
+	params := NewSelectModelOptParams()
+	params.Budget = Budget{MaxNodes: 20}
+	cp, err := branchAndBound(context.Background(), &DenseSystem{X: X, Y: y}, highscore, &sp, params)
+	if !errors.Is(err, ErrBudgetExhausted) {
+		t.Fatalf("Expected ErrBudgetExhausted got %v", err)
+	}
+
+	if len(cp.Queue) == 0 {
+		t.Fatalf("Expected unexplored nodes in the queue")
+	}
+
+	for i, n := range cp.Queue {
+		if n.Coeff != nil {
+			t.Errorf("Queue node #%d: Expected no coefficients got %v", i, n.Coeff)
+		}
+	}
+
+	for item := highscore.Items.Front(); item != nil; item = item.Next() {
+		if n := item.Value.(*Node); len(n.Coeff) != n.Model.NumFeatures() {
+			t.Errorf("Expected %d coefficients got %v", n.Model.NumFeatures(), n.Coeff)
+		}
+	}
+}
+
+func TestQueueMemoryPerNode(t *testing.T) {
+	X, y := incrementalTestData(100, 30)
+	highscore := NewHighscore(5)
+	var sp SearchProgress
+	params := NewSelectModelOptParams()
+	params.Budget = Budget{MaxNodes: 200}
+	cp, err := branchAndBound(context.Background(), &DenseSystem{X: X, Y: y}, highscore, &sp, params)
+	if !errors.Is(err, ErrBudgetExhausted) {
+		t.Fatalf("Expected ErrBudgetExhausted got %v", err)
+	}
+
+	if len(cp.Queue) == 0 {
+		t.Fatalf("Expected unexplored nodes in the queue")
+	}
+
+	// A queued node holds its model and a few scalars, and no factorisations that grow
+	// with the square of the number of features
+	for i, n := range cp.Queue {
+		if n.factors.model != nil || n.EstimateMemory() > 64+8*30 {
+			t.Errorf("Queue node #%d: Expected O(k) memory, got %d bytes", i, n.EstimateMemory())
+		}
+	}
+}
+
+// failingSystem panics when a model with both feature 1 and 2 is fitted
+type failingSystem struct {
+	sys *DenseSystem
+}
+
+func (f *failingSystem) Dims() (int, int) {
+	return f.sys.Dims()
+}
+
+func (f *failingSystem) FitModel(model []bool) ([]float64, float64) {
+	if model[1] && model[2] {
+		panic("singular matrix")
+	}
+	return f.sys.FitModel(model)
+}
+
+func TestSelectModelNumericalError(t *testing.T) {
+	X, y := incrementalTestData(30, 5)
+	var sp SearchProgress
+	sys := &failingSystem{&DenseSystem{X: X, Y: y}}
+	_, err := branchAndBound(context.Background(), sys, NewHighscore(5), &sp, nil)
+	if !errors.Is(err, ErrNumerical) {
+		t.Errorf("Expected ErrNumerical got %v", err)
+	}
+
+	for i, w := range []float64{-1.0, math.NaN(), math.Inf(1)} {
+		params := NewSelectModelOptParams()
+		params.Weights = make([]float64, len(y))
+		floats.AddConst(1.0, params.Weights)
+		params.Weights[3] = w
+		err := SelectModelContext(context.Background(), X, y, NewHighscore(5), &sp, params)
+		if !errors.Is(err, ErrInvalidWeight) {
+			t.Errorf("Test #%d: Expected ErrInvalidWeight got %v", i, err)
+		}
+	}
+}
+
 func TestBruteForceSelect(t *testing.T) {
 	for testnum, test := range []struct {
 		X      *mat.Dense
@@ -245,3 +367,54 @@ func reverse(a []float64) {
 		a[left], a[right] = a[right], a[left]
 	}
 }
+
+func TestSelectModelCriterion(t *testing.T) {
+	X := mat.NewDense(8, 4, []float64{1.0, 0.0, 0.0, 0.0,
+		1.0, 1.0, 1.0, 1.0,
+		1.0, 2.0, 4.0, 8.0,
+		1.0, 3.0, 9.0, 15.0,
+		1.0, 4.0, 9.0, 30.0,
+		1.0, 2.0, 3.0, 6.0,
+		1.0, -2.0, 5.0, 4.0,
+		1.0, 5.0, -1.0, 2.0})
+	y := []float64{1.0, 2.0, 5.0, 7.0, 10.0, 8.0, 15.0, 3.0}
+	sys := &DenseSystem{X: X, Y: y}
+
+	for i, criterion := range []Criterion{AicCriterion, BicCriterion, &MallowsCp{}} {
+		var sp SearchProgress
+		highscore := NewHighscore(100)
+		params := NewSelectModelOptParams()
+		params.Criterion = criterion
+		SelectModel(X, y, highscore, &sp, params)
+
+		// Find the best model by brute force
+		prepared, err := prepareCriterion(criterion, sys)
+		if err != nil {
+			t.Fatal(err)
+		}
+		best := math.MaxFloat64
+		for _, model := range allModels(4) {
+			if NumFeatures(model) == 0 {
+				continue
+			}
+			_, rss := sys.FitModel(model)
+			best = math.Min(best, prepared.Score(NumFeatures(model), 8, rss))
+		}
+
+		if math.Abs(highscore.BestScore()+best) > 1e-8 {
+			t.Errorf("Test #%d: Expected best score %f got %f", i, -best, highscore.BestScore())
+		}
+	}
+}
+
+func allModels(n int) [][]bool {
+	models := [][]bool{}
+	for i := 0; i < 1<<uint(n); i++ {
+		model := make([]bool, n)
+		for j := range model {
+			model[j] = i&(1<<uint(j)) != 0
+		}
+		models = append(models, model)
+	}
+	return models
+}
@@ -30,7 +30,27 @@ func NewOmpResult(numFeatures int) *OmpResult {
 
 // Omp performs Orthogonal Matching Pursuit
 func Omp(X mat.Matrix, y []float64, tol float64) *OmpResult {
+	res, _ := OmpConstrained(X, y, tol, nil)
+	return res
+}
+
+// OmpConstrained performs Orthogonal Matching Pursuit where the included features are
+// added first (in the given order), and excluded features and features in an exclusive
+// group where a feature is already selected are never added. The iterations continue
+// until the tolerance is reached and at least MinFeatures are selected, or until
+// MaxFeatures are selected. Together groups are not supported, since OMP adds one
+// feature at a time. An error wrapping ErrInvalidConstraints is returned if the
+// constraints are inconsistent or Together groups are given.
+func OmpConstrained(X mat.Matrix, y []float64, tol float64, c *Constraints) (*OmpResult, error) {
 	_, ncols := X.Dims()
+	if err := c.Validate(ncols); err != nil {
+		return nil, err
+	}
+
+	if c != nil && len(c.Together) > 0 {
+		return nil, fmt.Errorf("ompconstrained: together groups are not supported: %w", ErrInvalidConstraints)
+	}
+
 	res := NewOmpResult(ncols)
 	residuals := mat.NewVecDense(len(y), nil)
 	for i := 0; i < residuals.Len(); i++ {
@@ -47,14 +67,30 @@ func Omp(X mat.Matrix, y []float64, tol float64) *OmpResult {
 		norms.SetVec(i, math.Sqrt(innerProds.At(i, i)))
 	}
 
+	forced := []int{}
+	if c != nil {
+		forced = c.Include
+	}
+
 	for current < ncols {
-		resNorm := mat.Norm(residuals, 2)
-		proj.MulVec(X.T(), residuals)
-		for i := 0; i < proj.Len(); i++ {
-			proj.SetVec(i, math.Abs(proj.AtVec(i)/(resNorm*norms.AtVec(i))))
+		imax := -1
+		if current < len(forced) {
+			imax = forced[current]
+		} else {
+			resNorm := mat.Norm(residuals, 2)
+			proj.MulVec(X.T(), residuals)
+			for i := 0; i < proj.Len(); i++ {
+				proj.SetVec(i, math.Abs(proj.AtVec(i)/(resNorm*norms.AtVec(i))))
+				if c != nil && (model[i] || !c.canAdd(model, i)) {
+					proj.SetVec(i, -1.0)
+				}
+			}
+
+			imax = Argmax(proj.RawVector().Data)
+			if proj.AtVec(imax) < 0.0 {
+				break
+			}
 		}
-
-		imax := Argmax(proj.RawVector().Data)
 		model[imax] = true
 		res.Order[current] = imax
 
@@ -73,10 +109,14 @@ func Omp(X mat.Matrix, y []float64, tol float64) *OmpResult {
 			rss += math.Pow(y[i]-ypred[i], 2)
 		}
 		rss = math.Sqrt(rss / float64(len(y)))
-		if rss < tol {
+		current++
+		if c != nil && c.MaxFeatures > 0 && current >= c.MaxFeatures {
+			break
+		}
+
+		if rss < tol && (c == nil || (current >= c.MinFeatures && current >= len(forced))) {
 			break
 		}
-		current++
 	}
 	fmt.Printf("%v", res.Order)
 
@@ -90,7 +130,7 @@ func Omp(X mat.Matrix, y []float64, tol float64) *OmpResult {
 			foundBefore[v] = true
 		}
 	}
-	return res
+	return res, nil
 }
 
 // Abs calculate absolute value of the max element
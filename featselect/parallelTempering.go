@@ -0,0 +1,316 @@
+package featselect
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"math/rand"
+	"sync"
+
+	"gonum.org/v1/gonum/mat"
+)
+
+// PTParams holds the parameters of the parallel tempering search. NumReplicas chains run
+// at fixed temperatures, spaced geometrically between MinTemp and MaxTemp, unless the
+// temperatures are given explicitly. Each replica performs Sweeps sweeps (one sweep is one
+// Monte Carlo step per feature), and swaps between neighbouring temperatures are attempted
+// every SwapInterval sweeps. The search is reproducible for a given Seed. HighscoreSize is
+// the number of models in the merged highscore list.
+type PTParams struct {
+	NumReplicas   int
+	MinTemp       float64
+	MaxTemp       float64
+	Temperatures  []float64
+	Sweeps        int
+	SwapInterval  int
+	Criterion     Criterion
+	Constraints   *Constraints
+	HighscoreSize int
+	Seed          int64
+	Budget        Budget
+}
+
+// NewPTParams returns the default parameters of the parallel tempering search
+func NewPTParams() *PTParams {
+	var params PTParams
+	params.NumReplicas = 4
+	params.MinTemp = 0.5
+	params.MaxTemp = 50.0
+	params.Sweeps = 100
+	params.SwapInterval = 1
+	params.Criterion = AiccCriterion
+	params.HighscoreSize = 10
+	return &params
+}
+
+// temperatures returns the temperature of each replica in increasing order
+func (p *PTParams) temperatures() ([]float64, error) {
+	if len(p.Temperatures) > 0 {
+		for i, t := range p.Temperatures {
+			if t <= 0.0 || (i > 0 && t <= p.Temperatures[i-1]) {
+				return nil, fmt.Errorf("ptparams: temperatures must be positive and increasing, got %v", p.Temperatures)
+			}
+		}
+		return p.Temperatures, nil
+	}
+
+	if p.NumReplicas < 1 || p.MinTemp <= 0.0 || p.MaxTemp < p.MinTemp {
+		return nil, fmt.Errorf("ptparams: at least one replica (got %d) and 0 < min temperature <= max temperature (got %f and %f) are needed", p.NumReplicas, p.MinTemp, p.MaxTemp)
+	}
+
+	temps := make([]float64, p.NumReplicas)
+	for i := range temps {
+		temps[i] = p.MinTemp
+		if p.NumReplicas > 1 {
+			temps[i] *= math.Pow(p.MaxTemp/p.MinTemp, float64(i)/float64(p.NumReplicas-1))
+		}
+	}
+	return temps, nil
+}
+
+// ReplicaStats holds the statistics of one replica. The swaps are the exchanges with the
+// replica at the next higher temperature, and BestScore is the lowest criterion visited
+type ReplicaStats struct {
+	Temperature     float64
+	NumSteps        int
+	NumAccepted     int
+	NumSwapAttempts int
+	NumSwaps        int
+	BestScore       float64
+}
+
+// AcceptanceRate returns the fraction of accepted Monte Carlo steps
+func (r *ReplicaStats) AcceptanceRate() float64 {
+	if r.NumSteps == 0 {
+		return 0.0
+	}
+	return float64(r.NumAccepted) / float64(r.NumSteps)
+}
+
+// SwapRate returns the fraction of accepted swaps with the replica at the next higher
+// temperature
+func (r *ReplicaStats) SwapRate() float64 {
+	if r.NumSwapAttempts == 0 {
+		return 0.0
+	}
+	return float64(r.NumSwaps) / float64(r.NumSwapAttempts)
+}
+
+// PTRes holds the result of the parallel tempering search. Selected and Coeff are the
+// features and coefficients of the best model, Scores is the highscore list merged from
+// all replicas and Replicas holds the statistics of each replica
+type PTRes struct {
+	Selected []int
+	Coeff    []float64
+	Scores   *SAScore
+	Replicas []ReplicaStats
+}
+
+// SelectModelPT selects the model that minimizes the criterion by parallel tempering
+// (replica exchange). The replicas are Metropolis chains with the same moves as
+// SelectModelSAConstrained, and they run concurrently between the swaps. An error
+// wrapping ErrInvalidConstraints is returned if the constraints are inconsistent.
+func SelectModelPT(X mat.Matrix, y []float64, params *PTParams) (*PTRes, error) {
+	return SelectModelPTContext(context.Background(), X, y, params)
+}
+
+// SelectModelPTContext selects the model in the same way as SelectModelPT, but stops when
+// ctx is cancelled or the budget is used up. The best models found so far are then
+// returned together with ctx.Err() or ErrBudgetExhausted. The iterations of the budget
+// are the Monte Carlo steps of each replica.
+func SelectModelPTContext(ctx context.Context, X mat.Matrix, y []float64, params *PTParams) (*PTRes, error) {
+	if nr, _ := X.Dims(); nr != len(y) {
+		return nil, fmt.Errorf("selectmodelpt: %d rows in design matrix and %d targets: %w", nr, len(y), ErrDimensionMismatch)
+	}
+	return selectModelPT(ctx, &DenseSystem{X: X, Y: y}, params)
+}
+
+// SelectModelPTFromStats selects the model by parallel tempering, where the models are
+// fitted from sufficient statistics
+func SelectModelPTFromStats(stats *SufficientStats, params *PTParams) (*PTRes, error) {
+	return selectModelPT(context.Background(), stats, params)
+}
+
+func selectModelPT(ctx context.Context, sys LinearSystem, params *PTParams) (*PTRes, error) {
+	temps, err := params.temperatures()
+	if err != nil {
+		return nil, err
+	}
+
+	if params.Sweeps < 1 || params.SwapInterval < 1 || params.HighscoreSize < 1 {
+		return nil, fmt.Errorf("ptparams: sweeps (%d), swap interval (%d) and highscore size (%d) must be at least 1", params.Sweeps, params.SwapInterval, params.HighscoreSize)
+	}
+
+	_, nc := sys.Dims()
+	c := params.Constraints
+	if err := c.Validate(nc); err != nil {
+		return nil, err
+	}
+
+	criterion, err := prepareCriterion(params.Criterion, sys)
+	if err != nil {
+		return nil, err
+	}
+
+	initial, err := c.initialModel(nc)
+	if err != nil {
+		return nil, err
+	}
+
+	tracker := newBudgetTracker(ctx, params.Budget)
+	defer tracker.stop()
+
+	// The seeds of the replicas and the swaps are drawn by the calling go-routine, such
+	// that the search is reproducible
+	rng := rand.New(rand.NewSource(params.Seed))
+	replicas := make([]*replica, len(temps))
+	for i, t := range temps {
+		replicas[i] = newReplica(sys, criterion, c, initial, t, params.HighscoreSize, rng.Int63())
+	}
+
+	var stopErr error
+	for round, sweeps := 0, 0; sweeps < params.Sweeps; round++ {
+		if stopErr = tracker.err(0, replicas[0].stats.NumSteps); stopErr != nil {
+			break
+		}
+
+		numSweeps := params.SwapInterval
+		if sweeps+numSweeps > params.Sweeps {
+			numSweeps = params.Sweeps - sweeps
+		}
+		sweeps += numSweeps
+
+		numSteps := numSweeps * nc
+		if params.Budget.MaxIterations > 0 {
+			numSteps = MinInt([]int{numSteps, params.Budget.MaxIterations - replicas[0].stats.NumSteps})
+		}
+
+		var wg sync.WaitGroup
+		for _, r := range replicas {
+			wg.Add(1)
+			go func(r *replica) {
+				defer wg.Done()
+				r.run(numSteps)
+			}(r)
+		}
+		wg.Wait()
+
+		// Even and odd pairs of neighbouring temperatures are tried in alternating rounds
+		for i := round % 2; i+1 < len(replicas); i += 2 {
+			trySwap(replicas[i], replicas[i+1], rng)
+		}
+	}
+
+	res := &PTRes{Scores: NewSAScore(params.HighscoreSize)}
+	for _, r := range replicas {
+		// The items are copied, since Insert overwrites the worst item
+		for _, item := range r.scores.Items {
+			copied := *item
+			res.Scores.Insert(&copied)
+		}
+		res.Replicas = append(res.Replicas, r.stats)
+	}
+
+	if best := res.Scores.BestItem; best != nil {
+		res.Selected = best.Selection()
+		res.Coeff = best.Coeff
+	}
+	return res, stopErr
+}
+
+// replica is a Metropolis chain at a fixed temperature. A replica is only used by one
+// go-routine at a time
+type replica struct {
+	sys       LinearSystem
+	criterion Criterion
+	c         *Constraints
+	rng       *rand.Rand
+	model     []bool
+	coeff     []float64
+	score     float64
+	scores    *SAScore
+	stats     ReplicaStats
+}
+
+// newReplica returns a replica that starts from the passed model
+func newReplica(sys LinearSystem, criterion Criterion, c *Constraints, model []bool, temp float64, highscoreSize int, seed int64) *replica {
+	r := &replica{
+		sys:       sys,
+		criterion: criterion,
+		c:         c,
+		rng:       rand.New(rand.NewSource(seed)),
+		model:     make([]bool, len(model)),
+		scores:    NewSAScore(highscoreSize),
+	}
+	copy(r.model, model)
+	r.stats.Temperature = temp
+
+	nr, _ := sys.Dims()
+	var rss float64
+	r.coeff, rss = sys.FitModel(r.model)
+	r.score = criterion.Score(NumFeatures(r.model), nr, math.Max(rss, RssTol))
+	r.stats.BestScore = r.score
+	r.insert()
+	return r
+}
+
+// run performs numSteps Monte Carlo steps
+func (r *replica) run(numSteps int) {
+	nr, nc := r.sys.Dims()
+	for step := 0; step < numSteps; step++ {
+		r.stats.NumSteps++
+		moved := []int{r.rng.Intn(nc)}
+		if r.c != nil && r.rng.Intn(2) == 1 {
+			moved = swapMove(r.model, r.rng.Intn)
+		}
+
+		if r.c != nil {
+			moved = r.c.extendMove(r.model, moved)
+		}
+		flipAll(r.model, moved)
+
+		N := NumFeatures(r.model)
+		if N == 0 || N >= nr/2 || !r.c.Satisfied(r.model) {
+			flipAll(r.model, moved)
+			continue
+		}
+
+		coeff, rss := r.sys.FitModel(r.model)
+		score := r.criterion.Score(N, nr, math.Max(rss, RssTol))
+		if score < r.score || math.Exp(-(score-r.score)/r.stats.Temperature) > r.rng.Float64() {
+			r.stats.NumAccepted++
+			r.coeff = coeff
+			r.score = score
+			r.stats.BestScore = math.Min(r.stats.BestScore, score)
+			r.insert()
+		} else {
+			flipAll(r.model, moved)
+		}
+	}
+}
+
+// insert adds the current model to the highscore list of the replica
+func (r *replica) insert() {
+	item := NewSAItem(r.model)
+	item.Score = -r.score // Change sign since the highscore list keeps only the largest
+	item.Coeff = r.coeff
+	r.scores.Insert(item)
+}
+
+// trySwap exchanges the models of two replicas with the Metropolis probability
+// min(1, exp((E1 - E2)(1/T1 - 1/T2))), where lower is the replica with the lowest
+// temperature
+func trySwap(lower *replica, upper *replica, rng *rand.Rand) {
+	lower.stats.NumSwapAttempts++
+	delta := (lower.score - upper.score) * (1.0/lower.stats.Temperature - 1.0/upper.stats.Temperature)
+	if delta < 0.0 && math.Exp(delta) <= rng.Float64() {
+		return
+	}
+
+	lower.stats.NumSwaps++
+	lower.model, upper.model = upper.model, lower.model
+	lower.coeff, upper.coeff = upper.coeff, lower.coeff
+	lower.score, upper.score = upper.score, lower.score
+	lower.stats.BestScore = math.Min(lower.stats.BestScore, lower.score)
+	upper.stats.BestScore = math.Min(upper.stats.BestScore, upper.score)
+}
@@ -0,0 +1,159 @@
+package featselect
+
+import (
+	"context"
+	"errors"
+	"math"
+	"math/rand"
+	"testing"
+
+	"gonum.org/v1/gonum/floats"
+)
+
+func TestPTTemperatures(t *testing.T) {
+	for i, test := range []struct {
+		params *PTParams
+		want   []float64
+		ok     bool
+	}{
+		{params: &PTParams{NumReplicas: 3, MinTemp: 1.0, MaxTemp: 100.0}, want: []float64{1.0, 10.0, 100.0}, ok: true},
+		{params: &PTParams{NumReplicas: 1, MinTemp: 2.0, MaxTemp: 100.0}, want: []float64{2.0}, ok: true},
+		{params: &PTParams{Temperatures: []float64{0.5, 4.0}}, want: []float64{0.5, 4.0}, ok: true},
+		{params: &PTParams{Temperatures: []float64{4.0, 0.5}}, ok: false},
+		{params: &PTParams{NumReplicas: 2, MinTemp: 0.0, MaxTemp: 1.0}, ok: false},
+		{params: &PTParams{NumReplicas: 0, MinTemp: 1.0, MaxTemp: 2.0}, ok: false},
+	} {
+		temps, err := test.params.temperatures()
+		if (err == nil) != test.ok {
+			t.Errorf("Test #%d: Unexpected error %v", i, err)
+		}
+
+		if test.ok && !floats.EqualApprox(temps, test.want, 1e-10) {
+			t.Errorf("Test #%d: Expected %v got %v", i, test.want, temps)
+		}
+	}
+}
+
+func TestTrySwap(t *testing.T) {
+	rng := rand.New(rand.NewSource(0))
+	lower := &replica{model: []bool{true, false}, score: 10.0, stats: ReplicaStats{Temperature: 1.0, BestScore: 10.0}}
+	upper := &replica{model: []bool{false, true}, score: 5.0, stats: ReplicaStats{Temperature: 2.0, BestScore: 5.0}}
+
+	// The replica at the lowest temperature has the highest score, so the swap is accepted
+	trySwap(lower, upper, rng)
+	if lower.score != 5.0 || !lower.model[1] || upper.score != 10.0 || lower.stats.BestScore != 5.0 {
+		t.Errorf("Expected the models to be swapped")
+	}
+
+	if lower.stats.NumSwapAttempts != 1 || lower.stats.NumSwaps != 1 || upper.stats.NumSwapAttempts != 0 {
+		t.Errorf("Unexpected swap statistics %v and %v", lower.stats, upper.stats)
+	}
+}
+
+func TestSelectModelPT(t *testing.T) {
+	X, y := incrementalTestData(60, 8)
+	for i := 0; i < 60; i++ {
+		X.Set(i, 0, 1.0)
+	}
+	want := BruteForceSelect(X, y).Items.Front().Value.(*Node)
+
+	params := NewPTParams()
+	params.Sweeps = 30
+	params.SwapInterval = 2
+	params.Seed = 7
+	res, err := SelectModelPT(X, y, params)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !EqualInt(res.Selected, want.Model.Selected()) || math.Abs(res.Scores.BestItem.Score-want.Score) > 1e-8 {
+		t.Errorf("Expected selection %v (%f) got %v (%f)", want.Model.Selected(), want.Score, res.Selected, res.Scores.BestItem.Score)
+	}
+
+	if len(res.Replicas) != params.NumReplicas {
+		t.Fatalf("Expected %d replicas got %d", params.NumReplicas, len(res.Replicas))
+	}
+
+	for i, r := range res.Replicas {
+		if r.NumSteps != 30*8 {
+			t.Errorf("Replica #%d: Expected %d steps got %d", i, 30*8, r.NumSteps)
+		}
+
+		if r.AcceptanceRate() <= 0.0 || r.AcceptanceRate() > 1.0 || r.SwapRate() < 0.0 || r.SwapRate() > 1.0 {
+			t.Errorf("Replica #%d: Unexpected acceptance rate %f or swap rate %f", i, r.AcceptanceRate(), r.SwapRate())
+		}
+
+		hasNeighbour := i+1 < len(res.Replicas)
+		if (r.NumSwapAttempts > 0) != hasNeighbour {
+			t.Errorf("Replica #%d: Unexpected number of swap attempts %d", i, r.NumSwapAttempts)
+		}
+
+		if i > 0 && r.Temperature <= res.Replicas[i-1].Temperature {
+			t.Errorf("Replica #%d: Temperatures are not increasing", i)
+		}
+	}
+
+	// The same seed gives the same result
+	again, err := SelectModelPT(X, y, params)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for i, r := range res.Replicas {
+		if r != again.Replicas[i] {
+			t.Errorf("Replica #%d: Statistics differ between runs with the same seed. %v != %v", i, r, again.Replicas[i])
+		}
+	}
+
+	for i, item := range res.Scores.Items {
+		if other := again.Scores.Items[i]; !item.Model.Equal(&other.Model) || item.Score != other.Score {
+			t.Errorf("Item #%d differs between runs with the same seed", i)
+		}
+	}
+}
+
+func TestSelectModelPTConstraints(t *testing.T) {
+	X, y := incrementalTestData(40, 6)
+	for i, c := range []*Constraints{
+		{Include: []int{2}, Exclude: []int{1}},
+		{MinFeatures: 3, MaxFeatures: 3},
+		{Exclusive: [][]int{{1, 3}}},
+		{Together: [][]int{{1, 3}}},
+	} {
+		params := NewPTParams()
+		params.Sweeps = 10
+		params.Constraints = c
+		res, err := SelectModelPT(X, y, params)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		for _, item := range res.Scores.Items {
+			if !c.Satisfied(item.Model.ToBools()) {
+				t.Errorf("Test #%d: Selection %v does not satisfy the constraints", i, item.Selection())
+			}
+		}
+	}
+}
+
+func TestSelectModelPTBudget(t *testing.T) {
+	X, y := incrementalTestData(40, 6)
+	params := NewPTParams()
+	params.Budget = Budget{MaxIterations: 20}
+	res, err := SelectModelPTContext(context.Background(), X, y, params)
+	if !errors.Is(err, ErrBudgetExhausted) {
+		t.Errorf("Expected ErrBudgetExhausted got %v", err)
+	}
+
+	for i, r := range res.Replicas {
+		if r.NumSteps != 20 {
+			t.Errorf("Replica #%d: Expected 20 steps got %d", i, r.NumSteps)
+		}
+	}
+
+	stats := NewSufficientStats(6)
+	stats.AddRows(X, y, nil)
+	if _, err := SelectModelPTFromStats(stats, NewPTParams()); err != nil {
+		t.Errorf("Unexpected error %v", err)
+	}
+}
@@ -0,0 +1,168 @@
+package featselect
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+
+	"gonum.org/v1/gonum/mat"
+)
+
+// PowerName returns the name of the column formed by the product of the passed powers
+// of the columns with the given names, e.g. a^2*b. The factors are ordered by column
+func PowerName(names []string, power map[int]int) string {
+	cols := make([]int, 0, len(power))
+	for c := range power {
+		cols = append(cols, c)
+	}
+	sort.Ints(cols)
+
+	factors := make([]string, len(cols))
+	for i, c := range cols {
+		factors[i] = names[c]
+		if power[c] > 1 {
+			factors[i] += "^" + strconv.Itoa(power[c])
+		}
+	}
+	return strings.Join(factors, "*")
+}
+
+// ColName returns the name of a column given the names of the original columns
+func (m *LazyPowerMatrix) ColName(col int, names []string) string {
+	if col < m.numOrigCols() {
+		return names[col]
+	}
+	return m.terms[col-m.numOrigCols()].label(names)
+}
+
+// AddPurePowers adds the powers 2, 3, ..., maxPower of each of the listed columns
+func (m *LazyPowerMatrix) AddPurePowers(cols []int, maxPower int) {
+	for _, p := range monomials(cols, maxPower) {
+		if len(p) == 1 {
+			m.AddPower(p)
+		}
+	}
+}
+
+// AddInteractions adds all products of two or more of the listed columns where the sum
+// of the powers is at most maxPower (e.g. a*b, a^2*b and a*b*c when maxPower is 3)
+func (m *LazyPowerMatrix) AddInteractions(cols []int, maxPower int) {
+	for _, p := range monomials(cols, maxPower) {
+		if len(p) > 1 {
+			m.AddPower(p)
+		}
+	}
+}
+
+// monomials returns all products of powers of the listed columns with a total degree
+// between 2 and maxDegree. The terms are ordered by degree
+func monomials(cols []int, maxDegree int) []map[int]int {
+	res := []map[int]int{}
+	for degree := 2; degree <= maxDegree; degree++ {
+		var rec func(start int, remaining int, current map[int]int)
+		rec = func(start int, remaining int, current map[int]int) {
+			if remaining == 0 {
+				p := make(map[int]int)
+				for k, v := range current {
+					p[k] = v
+				}
+				res = append(res, p)
+				return
+			}
+
+			for i := start; i < len(cols); i++ {
+				current[cols[i]]++
+				rec(i, remaining-1, current)
+				current[cols[i]]--
+				if current[cols[i]] == 0 {
+					delete(current, cols[i])
+				}
+			}
+		}
+		rec(0, degree, make(map[int]int))
+	}
+	return res
+}
+
+// ExpandPolynomial returns a new dataset where powers up to maxPower of each feature
+// are added as new features. If interactions is true, products of different features
+// with a total degree up to maxPower (but at least 2) are also added. Constant features
+// are not expanded, powers of binary (0/1) features are skipped since they are equal to
+// the feature itself and products of columns from the same categorical variable are
+// skipped since they are always zero. The new features are named as a^2*b.
+func (d *Dataset) ExpandPolynomial(maxPower int, interactions bool) *Dataset {
+	expanded, _, err := d.Expand(d.PolynomialTerms(maxPower, interactions))
+	if err != nil {
+		panic(err)
+	}
+	return expanded
+}
+
+// PolynomialTerms returns the terms that are added by ExpandPolynomial
+func (d *Dataset) PolynomialTerms(maxPower int, interactions bool) []BasisTerm {
+	cols, binary := d.expandableColumns()
+	maxDegree := maxPower
+	if interactions && maxDegree < 2 {
+		maxDegree = 2
+	}
+
+	terms := []BasisTerm{}
+	for _, p := range monomials(cols, maxDegree) {
+		isPure := len(p) == 1
+		if (isPure && powerDegree(p) > maxPower) || (!isPure && !interactions) || !d.keepPowerTerm(p, binary) {
+			continue
+		}
+		terms = append(terms, BasisTerm{Power: p})
+	}
+	return terms
+}
+
+// expandableColumns returns the features that are not constant, and whether each of
+// them only takes the values 0 and 1
+func (d *Dataset) expandableColumns() ([]int, map[int]bool) {
+	_, nc := d.X.Dims()
+	cols := []int{}
+	binary := make(map[int]bool)
+	for c := 0; c < nc; c++ {
+		col := mat.Col(nil, c, d.X)
+		isConst := true
+		isBinary := true
+		for _, v := range col {
+			isConst = isConst && v == col[0]
+			isBinary = isBinary && (v == 0.0 || v == 1.0)
+		}
+
+		if !isConst {
+			cols = append(cols, c)
+			binary[c] = isBinary
+		}
+	}
+	return cols, binary
+}
+
+// powerDegree returns the total degree of the term
+func powerDegree(power map[int]int) int {
+	degree := 0
+	for _, p := range power {
+		degree += p
+	}
+	return degree
+}
+
+// keepPowerTerm returns false if the term is equal to a lower order term or always zero
+func (d *Dataset) keepPowerTerm(power map[int]int, binary map[int]bool) bool {
+	groups := make(map[int]bool)
+	for c, p := range power {
+		if binary[c] && p > 1 {
+			return false
+		}
+
+		if g := d.CategoricalGroupOf(c); g >= 0 {
+			if groups[g] {
+				return false
+			}
+			groups[g] = true
+		}
+	}
+	return true
+}
@@ -0,0 +1,112 @@
+package featselect
+
+import (
+	"testing"
+
+	"gonum.org/v1/gonum/mat"
+)
+
+func TestPowerName(t *testing.T) {
+	names := []string{"a", "b", "c"}
+	for i, test := range []struct {
+		power map[int]int
+		want  string
+	}{
+		{power: map[int]int{0: 2}, want: "a^2"},
+		{power: map[int]int{1: 1, 0: 2}, want: "a^2*b"},
+		{power: map[int]int{2: 1, 0: 1, 1: 1}, want: "a*b*c"},
+		{power: map[int]int{1: 3}, want: "b^3"},
+	} {
+		if got := PowerName(names, test.power); got != test.want {
+			t.Errorf("Test #%d: Expected %s got %s", i, test.want, got)
+		}
+	}
+}
+
+func TestMonomials(t *testing.T) {
+	names := []string{"a", "b", "c"}
+	for i, test := range []struct {
+		cols      []int
+		maxDegree int
+		want      []string
+	}{
+		{cols: []int{0, 1}, maxDegree: 1, want: []string{}},
+		{cols: []int{0, 1}, maxDegree: 2, want: []string{"a^2", "a*b", "b^2"}},
+		{cols: []int{0, 2}, maxDegree: 3, want: []string{"a^2", "a*c", "c^2", "a^3", "a^2*c", "a*c^2", "c^3"}},
+	} {
+		got := []string{}
+		for _, p := range monomials(test.cols, test.maxDegree) {
+			got = append(got, PowerName(names, p))
+		}
+
+		if !strArrayEqual(got, test.want) {
+			t.Errorf("Test #%d: Expected %v got %v", i, test.want, got)
+		}
+	}
+}
+
+func TestExpandPolynomial(t *testing.T) {
+	// Columns: a, y, b (binary), c (constant)
+	dset := Dataset{
+		X:         mat.NewDense(3, 3, []float64{1.0, 0.0, 5.0, 2.0, 1.0, 5.0, 3.0, 1.0, 5.0}),
+		Y:         []float64{1.0, 2.0, 3.0},
+		Names:     []string{"a", "y", "b", "c"},
+		TargetCol: 1,
+	}
+
+	for i, test := range []struct {
+		maxPower     int
+		interactions bool
+		want         []string
+	}{
+		{maxPower: 1, interactions: false, want: []string{"a", "b", "c"}},
+		{maxPower: 2, interactions: false, want: []string{"a", "b", "c", "a^2"}},
+		{maxPower: 1, interactions: true, want: []string{"a", "b", "c", "a*b"}},
+		{maxPower: 3, interactions: true, want: []string{"a", "b", "c", "a^2", "a*b", "a^3", "a^2*b"}},
+	} {
+		expanded := dset.ExpandPolynomial(test.maxPower, test.interactions)
+		_, nc := expanded.X.Dims()
+		got := make([]string, nc)
+		for j := range got {
+			got[j] = expanded.GetFeatName(j)
+		}
+
+		if !strArrayEqual(got, test.want) {
+			t.Errorf("Test #%d: Expected %v got %v", i, test.want, got)
+		}
+
+		if expanded.Names[expanded.TargetCol] != "y" {
+			t.Errorf("Test #%d: Target name changed to %s", i, expanded.Names[expanded.TargetCol])
+		}
+	}
+
+	expanded := dset.ExpandPolynomial(3, true)
+	want := []float64{9.0, 3.0, 27.0, 9.0}
+	for j, v := range want {
+		if got := expanded.X.At(2, j+3); got != v {
+			t.Errorf("Expected %f in column %d got %f", v, j+3, got)
+		}
+	}
+}
+
+func TestExpandPolynomialCategorical(t *testing.T) {
+	dset := Dataset{
+		X:           mat.NewDense(3, 3, []float64{1.0, 1.0, 0.0, 2.0, 0.0, 1.0, 3.0, 0.0, 0.0}),
+		Y:           []float64{1.0, 2.0, 3.0},
+		Names:       []string{"a", "color_red", "color_blue", "y"},
+		TargetCol:   3,
+		Categorical: []CategoricalGroup{{Name: "color", Levels: []string{"red", "blue"}, Features: []int{1, 2}}},
+	}
+
+	expanded := dset.ExpandPolynomial(2, true)
+	_, nc := expanded.X.Dims()
+	got := make([]string, nc)
+	for j := range got {
+		got[j] = expanded.GetFeatName(j)
+	}
+
+	want := []string{"a", "color_red", "color_blue", "a^2", "a*color_red", "a*color_blue"}
+	if !strArrayEqual(got, want) {
+		t.Errorf("Expected %v got %v", want, got)
+	}
+}
@@ -0,0 +1,232 @@
+package featselect
+
+import (
+	"container/heap"
+	"container/list"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// SearchStrategy determines the order in which branch and bound explores the open nodes
+type SearchStrategy int
+
+const (
+	// BreadthFirst explores the nodes in the order they were created
+	BreadthFirst SearchStrategy = iota
+
+	// DepthFirst explores the most recently created node first
+	DepthFirst
+
+	// BestFirst explores the node with the lowest lower bound first. The nodes are kept
+	// in a heap, such that insertion and removal are O(log n)
+	BestFirst
+)
+
+// String returns the name of the strategy
+func (s SearchStrategy) String() string {
+	switch s {
+	case BreadthFirst:
+		return "breadth"
+	case DepthFirst:
+		return "depth"
+	case BestFirst:
+		return "best"
+	}
+	return fmt.Sprintf("SearchStrategy(%d)", int(s))
+}
+
+// ParseSearchStrategy returns the strategy with the given name |breadth|depth|best|
+func ParseSearchStrategy(name string) (SearchStrategy, error) {
+	for _, s := range []SearchStrategy{BreadthFirst, DepthFirst, BestFirst} {
+		if strings.ToLower(name) == s.String() {
+			return s, nil
+		}
+	}
+	return BreadthFirst, fmt.Errorf("parsesearchstrategy: unknown strategy %s", name)
+}
+
+// nodeQueue holds the open nodes of a branch and bound search and keeps track of the
+// memory they use
+type nodeQueue interface {
+	// Push adds a node to the queue
+	Push(n *Node)
+
+	// Pop removes and returns the next node to explore, or nil if the queue is empty
+	Pop() *Node
+
+	// Len returns the number of nodes in the queue
+	Len() int
+
+	// Bytes returns the estimated memory used by the nodes in the queue
+	Bytes() int64
+
+	// Prune removes all nodes where the lower bound is larger than threshold
+	Prune(threshold float64)
+
+	// Evict removes the nodes with the highest lower bounds until at most maxNodes nodes
+	// using at most maxBytes bytes are left (no memory limit if maxBytes is zero)
+	Evict(maxNodes int, maxBytes int64) Eviction
+
+	// Nodes returns the nodes in the queue. Pushing them to an empty queue in the
+	// returned order restores the queue
+	Nodes() []*Node
+}
+
+// newNodeQueue returns an empty queue for the passed strategy
+func newNodeQueue(s SearchStrategy) nodeQueue {
+	if s == BestFirst {
+		return &heapQueue{}
+	}
+	return &listQueue{nodes: list.New(), lifo: s == DepthFirst}
+}
+
+// listQueue is a FIFO (breadth first) or LIFO (depth first) queue
+type listQueue struct {
+	nodes *list.List
+	lifo  bool
+	bytes int64
+}
+
+func (q *listQueue) Push(n *Node) {
+	q.nodes.PushBack(n)
+	q.bytes += queueBytes(n)
+}
+
+func (q *listQueue) Pop() *Node {
+	item := q.nodes.Front()
+	if q.lifo {
+		item = q.nodes.Back()
+	}
+
+	if item == nil {
+		return nil
+	}
+	n := q.nodes.Remove(item).(*Node)
+	q.bytes -= queueBytes(n)
+	return n
+}
+
+func (q *listQueue) Len() int {
+	return q.nodes.Len()
+}
+
+func (q *listQueue) Bytes() int64 {
+	return q.bytes
+}
+
+func (q *listQueue) Prune(threshold float64) {
+	q.bytes -= cleanQueue(q.nodes, threshold)
+}
+
+func (q *listQueue) Evict(maxNodes int, maxBytes int64) Eviction {
+	evicted, removed := evictLeastPromising(q.nodes, maxNodes, maxBytes)
+	q.bytes -= removed
+	return evicted
+}
+
+func (q *listQueue) Nodes() []*Node {
+	nodes := make([]*Node, 0, q.nodes.Len())
+	for item := q.nodes.Front(); item != nil; item = item.Next() {
+		nodes = append(nodes, item.Value.(*Node))
+	}
+	return nodes
+}
+
+// nodeHeap is a min-heap of nodes keyed on the lower bound
+type nodeHeap []*Node
+
+func (h nodeHeap) Len() int            { return len(h) }
+func (h nodeHeap) Less(i, j int) bool  { return h[i].Lower < h[j].Lower }
+func (h nodeHeap) Swap(i, j int)       { h[i], h[j] = h[j], h[i] }
+func (h *nodeHeap) Push(x interface{}) { *h = append(*h, x.(*Node)) }
+func (h *nodeHeap) Pop() interface{} {
+	old := *h
+	n := old[len(old)-1]
+	old[len(old)-1] = nil
+	*h = old[:len(old)-1]
+	return n
+}
+
+// heapQueue is a best first queue
+type heapQueue struct {
+	nodes nodeHeap
+	bytes int64
+}
+
+func (q *heapQueue) Push(n *Node) {
+	heap.Push(&q.nodes, n)
+	q.bytes += queueBytes(n)
+}
+
+func (q *heapQueue) Pop() *Node {
+	if len(q.nodes) == 0 {
+		return nil
+	}
+	n := heap.Pop(&q.nodes).(*Node)
+	q.bytes -= queueBytes(n)
+	return n
+}
+
+func (q *heapQueue) Len() int {
+	return len(q.nodes)
+}
+
+func (q *heapQueue) Bytes() int64 {
+	return q.bytes
+}
+
+// Prune removes all nodes above the threshold in one pass, and restores the heap afterwards
+func (q *heapQueue) Prune(threshold float64) {
+	kept := q.nodes[:0]
+	for _, n := range q.nodes {
+		if n.Lower > threshold {
+			q.bytes -= queueBytes(n)
+		} else {
+			kept = append(kept, n)
+		}
+	}
+
+	for i := len(kept); i < len(q.nodes); i++ {
+		q.nodes[i] = nil
+	}
+	q.nodes = kept
+	heap.Init(&q.nodes)
+}
+
+// Evict sorts the nodes by their lower bound and truncates the slice. A sorted slice is
+// a valid heap, thus the heap does not have to be rebuilt
+func (q *heapQueue) Evict(maxNodes int, maxBytes int64) Eviction {
+	sort.Sort(q.nodes)
+
+	var evicted Eviction
+	numKept := numToKeep(q.nodes, maxNodes, maxBytes)
+	for i := numKept; i < len(q.nodes); i++ {
+		evicted.add(q.nodes[i])
+		q.bytes -= queueBytes(q.nodes[i])
+		q.nodes[i] = nil
+	}
+	q.nodes = q.nodes[:numKept]
+	return evicted
+}
+
+func (q *heapQueue) Nodes() []*Node {
+	nodes := make([]*Node, len(q.nodes))
+	copy(nodes, q.nodes)
+	sort.Stable(nodeHeap(nodes))
+	return nodes
+}
+
+// numToKeep returns the number of nodes at the start of sorted (ordered by lower bound)
+// that fit within maxNodes nodes and maxBytes bytes
+func numToKeep(sorted []*Node, maxNodes int, maxBytes int64) int {
+	var kept int64
+	for i, n := range sorted {
+		size := queueBytes(n)
+		if i >= maxNodes || (maxBytes > 0 && kept+size > maxBytes) {
+			return i
+		}
+		kept += size
+	}
+	return len(sorted)
+}
@@ -0,0 +1,123 @@
+package featselect
+
+import (
+	"testing"
+
+	"gonum.org/v1/gonum/floats"
+	"gonum.org/v1/gonum/mat"
+)
+
+// queueLower pushes nodes with the given lower bounds and returns the bounds in the order
+// the nodes are popped
+func queueLower(q nodeQueue, lower []float64) []float64 {
+	for _, v := range lower {
+		n := NewNode(0, []bool{true, false, true})
+		n.Lower = v
+		q.Push(n)
+	}
+
+	got := []float64{}
+	for n := q.Pop(); n != nil; n = q.Pop() {
+		got = append(got, n.Lower)
+	}
+	return got
+}
+
+func TestNodeQueueOrder(t *testing.T) {
+	lower := []float64{-1.0, -3.0, 2.0, -4.0, 0.5}
+	for i, test := range []struct {
+		strategy SearchStrategy
+		want     []float64
+	}{
+		{strategy: BreadthFirst, want: []float64{-1.0, -3.0, 2.0, -4.0, 0.5}},
+		{strategy: DepthFirst, want: []float64{0.5, -4.0, 2.0, -3.0, -1.0}},
+		{strategy: BestFirst, want: []float64{-4.0, -3.0, -1.0, 0.5, 2.0}},
+	} {
+		q := newNodeQueue(test.strategy)
+		if got := queueLower(q, lower); !floats.Equal(got, test.want) {
+			t.Errorf("Test #%d: Expected %v got %v", i, test.want, got)
+		}
+
+		if q.Len() != 0 || q.Bytes() != 0 {
+			t.Errorf("Test #%d: Expected an empty queue got %d nodes (%d bytes)", i, q.Len(), q.Bytes())
+		}
+	}
+}
+
+func TestNodeQueuePruneEvict(t *testing.T) {
+	lower := []float64{-1.0, -3.0, 2.0, -4.0, 0.5, -2.0}
+	for i, strategy := range []SearchStrategy{BreadthFirst, DepthFirst, BestFirst} {
+		q := newNodeQueue(strategy)
+		size := int64(0)
+		for _, v := range lower {
+			n := NewNode(0, []bool{true, false, true})
+			n.Lower = v
+			q.Push(n)
+			size = queueBytes(n)
+		}
+
+		q.Prune(0.0)
+		if q.Len() != 4 || q.Bytes() != 4*size {
+			t.Errorf("Test #%d: Expected 4 nodes after pruning got %d (%d bytes)", i, q.Len(), q.Bytes())
+		}
+
+		evicted := q.Evict(2, 0)
+		want := Eviction{NumNodes: 2, MinLower: -2.0, MaxLower: -1.0}
+		if evicted != want {
+			t.Errorf("Test #%d: Expected %v got %v", i, want, evicted)
+		}
+
+		restored := newNodeQueue(strategy)
+		for _, n := range q.Nodes() {
+			restored.Push(n)
+		}
+
+		got := queueLower(restored, nil)
+		remaining := queueLower(q, nil)
+		if !floats.Equal(got, remaining) || len(got) != 2 {
+			t.Errorf("Test #%d: Restored queue gives %v, expected %v", i, got, remaining)
+		}
+	}
+}
+
+func TestParseSearchStrategy(t *testing.T) {
+	for i, test := range []struct {
+		name    string
+		want    SearchStrategy
+		wantErr bool
+	}{
+		{name: "breadth", want: BreadthFirst},
+		{name: "Depth", want: DepthFirst},
+		{name: "best", want: BestFirst},
+		{name: "random", want: BreadthFirst, wantErr: true},
+	} {
+		got, err := ParseSearchStrategy(test.name)
+		if got != test.want || (err != nil) != test.wantErr {
+			t.Errorf("Test #%d: Expected (%v, %v) got (%v, %v)", i, test.want, test.wantErr, got, err)
+		}
+	}
+}
+
+func TestSelectModelStrategies(t *testing.T) {
+	X := mat.NewDense(12, 5, nil)
+	y := make([]float64, 12)
+	for i := 0; i < 12; i++ {
+		for j := 0; j < 5; j++ {
+			X.Set(i, j, float64((i*(j+2)+3*j)%5)+0.2*float64(i))
+		}
+		y[i] = 2.0*X.At(i, 0) - X.At(i, 3) + 0.05*float64(i%4)
+	}
+	want := BruteForceSelect(X, y).BestScore()
+
+	for i, strategy := range []SearchStrategy{BreadthFirst, DepthFirst, BestFirst} {
+		var sp SearchProgress
+		params := NewSelectModelOptParams()
+		params.Strategy = strategy
+		highscore := NewHighscore(10)
+		SelectModel(X, y, highscore, &sp, params)
+
+		if got := highscore.BestScore(); !floats.EqualWithinAbsOrRel(got, want, 1e-8, 1e-8) {
+			t.Errorf("Test #%d: Expected best score %f got %f", i, want, got)
+		}
+	}
+}
@@ -1,32 +1,43 @@
 package featselect
 
+import "encoding/json"
+
 // SAItem is one item in the SA queue
 type SAItem struct {
-	Selection []int
-	Coeff     []float64
-	Score     float64
+	Model Model
+	Coeff []float64
+	Score float64
+	Names []string
 }
 
 // NewSAItem creates a new instane of SAIte
 func NewSAItem(model []bool) *SAItem {
 	var saItem SAItem
-	if model == nil {
-		return &saItem
-	}
-
-	n := NumFeatures(model)
-	saItem.Selection = make([]int, n)
-	saItem.Coeff = make([]float64, n)
-	counter := 0
-	for i, v := range model {
-		if v {
-			saItem.Selection[counter] = i
-			counter++
-		}
-	}
+	saItem.Model = *NewModelFromBools(model)
 	return &saItem
 }
 
+// Selection returns the selected features
+func (s *SAItem) Selection() []int {
+	return s.Model.Selected()
+}
+
+// MarshalJSON writes the selected features together with the coefficients, the score
+// and the names
+func (s *SAItem) MarshalJSON() ([]byte, error) {
+	return json.Marshal(&struct {
+		Selection []int
+		Coeff     []float64
+		Score     float64
+		Names     []string `json:",omitempty"`
+	}{
+		Selection: s.Selection(),
+		Coeff:     s.Coeff,
+		Score:     s.Score,
+		Names:     s.Names,
+	})
+}
+
 // SAScore is a type that is used to efficiently maintain a highscore list of
 // simmulated annealing features
 type SAScore struct {
@@ -64,8 +75,7 @@ func (s *SAScore) Insert(item *SAItem) {
 		}
 	} else {
 		if item.Score > s.WorstItem.Score {
-			s.WorstItem.Selection = item.Selection
-			s.WorstItem.Score = item.Score
+			*s.WorstItem = *item
 
 			if item.Score > s.BestItem.Score {
 				s.BestItem = item
@@ -86,9 +96,24 @@ func (s *SAScore) Insert(item *SAItem) {
 // Exists return true if the item already exists in the queue
 func (s *SAScore) Exists(item *SAItem) bool {
 	for _, v := range s.Items {
-		if EqualInt(v.Selection, item.Selection) {
+		if v.Model.Equal(&item.Model) {
 			return true
 		}
 	}
 	return false
 }
+
+// SetNames sets the names of the selected features of all items, given the names
+// of all the features
+func (s *SAScore) SetNames(featNames []string) {
+	for _, item := range append(s.Items, s.BestItem, s.WorstItem) {
+		if item == nil {
+			continue
+		}
+		selection := item.Selection()
+		item.Names = make([]string, len(selection))
+		for i, v := range selection {
+			item.Names[i] = featNames[v]
+		}
+	}
+}
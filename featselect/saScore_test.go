@@ -1,6 +1,7 @@
 package featselect
 
 import (
+	"encoding/json"
 	"math"
 	"testing"
 )
@@ -21,12 +22,12 @@ func TestSAItemInit(t *testing.T) {
 	} {
 		item := NewSAItem(test.model)
 
-		if test.model == nil && item.Selection != nil {
-			t.Errorf("Expected nil, got %v", item.Selection)
+		if test.model == nil && len(item.Selection()) != 0 {
+			t.Errorf("Expected no selection, got %v", item.Selection())
 		} else {
 			for j := range test.selection {
-				if test.selection[j] != item.Selection[j] {
-					t.Errorf("Expected\n%v\ngot\n%v\n", test.selection, item.Selection)
+				if test.selection[j] != item.Selection()[j] {
+					t.Errorf("Expected\n%v\ngot\n%v\n", test.selection, item.Selection())
 				}
 			}
 		}
@@ -129,3 +130,32 @@ func TestSAScore(t *testing.T) {
 		}
 	}
 }
+
+func TestSAScoreSetNames(t *testing.T) {
+	score := NewSAScore(2)
+	score.Insert(NewSAItem([]bool{true, false, true}))
+	score.Insert(NewSAItem([]bool{false, true, false}))
+	score.SetNames([]string{"a", "b", "a*b"})
+
+	want := [][]string{{"a", "a*b"}, {"b"}}
+	for i, item := range score.Items {
+		if !strArrayEqual(item.Names, want[i]) {
+			t.Errorf("Test #%d: Expected %v got %v", i, want[i], item.Names)
+		}
+	}
+}
+
+func TestSAItemJSON(t *testing.T) {
+	item := NewSAItem([]bool{false, true, true})
+	item.Coeff = []float64{1.0, 2.0}
+	item.Score = 3.0
+	data, err := json.Marshal(item)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := `{"Selection":[1,2],"Coeff":[1,2],"Score":3}`
+	if string(data) != want {
+		t.Errorf("Expected %s got %s", want, data)
+	}
+}
@@ -6,6 +6,8 @@ type SearchProgress struct {
 	BestScore     float64
 	NumExplored   int
 	Log2NumPruned float64
+	Evicted       Eviction
+	NumWorkers    int
 	rwlock        sync.RWMutex
 }
 
@@ -24,3 +26,31 @@ func (sp *SearchProgress) Get() (float64, int, float64) {
 	defer sp.rwlock.RUnlock()
 	return sp.BestScore, sp.NumExplored, sp.Log2NumPruned
 }
+
+// SetEvicted sets the summary of the nodes evicted from the queue
+func (sp *SearchProgress) SetEvicted(e Eviction) {
+	sp.rwlock.Lock()
+	defer sp.rwlock.Unlock()
+	sp.Evicted = e
+}
+
+// GetEvicted returns the summary of the nodes evicted from the queue
+func (sp *SearchProgress) GetEvicted() Eviction {
+	sp.rwlock.RLock()
+	defer sp.rwlock.RUnlock()
+	return sp.Evicted
+}
+
+// SetNumWorkers sets the number of workers taking part in a distributed search
+func (sp *SearchProgress) SetNumWorkers(n int) {
+	sp.rwlock.Lock()
+	defer sp.rwlock.Unlock()
+	sp.NumWorkers = n
+}
+
+// GetNumWorkers returns the number of workers taking part in a distributed search
+func (sp *SearchProgress) GetNumWorkers() int {
+	sp.rwlock.RLock()
+	defer sp.rwlock.RUnlock()
+	return sp.NumWorkers
+}
@@ -1,6 +1,7 @@
 package featselect
 
 import (
+	"context"
 	"fmt"
 	"math"
 	"math/rand"
@@ -15,37 +16,110 @@ type SARes struct {
 	Scores   *SAScore
 }
 
+// SelectModelSAWeighted uses simmulated annealing to select the model that minimizes
+// the cost function when the residuals are weighted by w
+func SelectModelSAWeighted(X mat.Matrix, y []float64, w []float64, nSweeps int, cost crit) *SARes {
+	Xw, yw := WeightRows(X, y, w)
+	return SelectModelSA(Xw, yw, nSweeps, cost)
+}
+
 // SelectModelSA uses simmulated annealing to select the model
 func SelectModelSA(X mat.Matrix, y []float64, nSweeps int, cost crit) *SARes {
+	res, _ := selectModelSA(context.Background(), &DenseSystem{X: X, Y: y}, nSweeps, cost, Budget{}, nil)
+	return res
+}
+
+// SelectModelSAConstrained selects the model in the same way as SelectModelSA, but only
+// models that satisfy the constraints are visited. Moves that add or remove a single
+// feature are then mixed with moves that swap a selected and an unselected feature,
+// such that models of a fixed size can be explored. With heredity constraints, a feature
+// is added together with its parents, and removed together with the features that
+// require it. An error wrapping
+// ErrInvalidConstraints is returned if the constraints can not be satisfied.
+func SelectModelSAConstrained(X mat.Matrix, y []float64, nSweeps int, cost crit, c *Constraints) (*SARes, error) {
+	if nr, _ := X.Dims(); nr != len(y) {
+		return nil, fmt.Errorf("selectmodelsa: %d rows in design matrix and %d targets: %w", nr, len(y), ErrDimensionMismatch)
+	}
+	return selectModelSA(context.Background(), &DenseSystem{X: X, Y: y}, nSweeps, cost, Budget{}, c)
+}
+
+// SelectModelSAContext selects the model in the same way as SelectModelSA, but stops
+// when ctx is cancelled or the budget is used up. The best models found so far are then
+// returned together with ctx.Err() or ErrBudgetExhausted.
+func SelectModelSAContext(ctx context.Context, X mat.Matrix, y []float64, nSweeps int, cost crit, budget Budget) (*SARes, error) {
+	if nr, _ := X.Dims(); nr != len(y) {
+		return nil, fmt.Errorf("selectmodelsa: %d rows in design matrix and %d targets: %w", nr, len(y), ErrDimensionMismatch)
+	}
+	return selectModelSA(ctx, &DenseSystem{X: X, Y: y}, nSweeps, cost, budget, nil)
+}
+
+// SelectModelSAFromStats uses simmulated annealing to select the model, where the models
+// are fitted from sufficient statistics
+func SelectModelSAFromStats(stats *SufficientStats, nSweeps int, cost crit) *SARes {
+	res, _ := selectModelSA(context.Background(), stats, nSweeps, cost, Budget{}, nil)
+	return res
+}
+
+// SelectModelSAConstrainedFromStats selects the model in the same way as
+// SelectModelSAConstrained, where the models are fitted from sufficient statistics
+func SelectModelSAConstrainedFromStats(stats *SufficientStats, nSweeps int, cost crit, c *Constraints) (*SARes, error) {
+	return selectModelSA(context.Background(), stats, nSweeps, cost, Budget{}, c)
+}
+
+func selectModelSA(ctx context.Context, sys LinearSystem, nSweeps int, cost crit, budget Budget, c *Constraints) (*SARes, error) {
+	nr, nc := sys.Dims()
+	if err := c.Validate(nc); err != nil {
+		return nil, err
+	}
+
+	current, err := c.initialModel(nc)
+	if err != nil {
+		return nil, err
+	}
+
+	tracker := newBudgetTracker(ctx, budget)
+	defer tracker.stop()
+
 	var res SARes
 	res.Scores = NewSAScore(10)
 
-	nr, nc := X.Dims()
-	current := make([]bool, nc)
 	currentScore := math.MaxFloat64
-	current[0] = true
 	coeff := make([]float64, nc)
 	temp := 500.0
 
 	numAccept := 0
 	numSteps := 0
 	hasReached50 := false
+	numIter := 0
+	var stopErr error
 
 	for {
-		index := rand.Intn(nc)
-		current[index] = !current[index]
+		if stopErr = tracker.err(0, numIter); stopErr != nil {
+			break
+		}
+		numIter++
+
+		moved := []int{rand.Intn(nc)}
+		if c != nil && rand.Intn(2) == 1 {
+			moved = swapMove(current, rand.Intn)
+		}
+
+		if c != nil {
+			moved = c.extendMove(current, moved)
+		}
+		flipAll(current, moved)
+
 		N := NumFeatures(current)
 		if N == 0 {
-			current[index] = !current[index]
-			N = 1
-		} else if N >= nr/2 {
-			current[index] = !current[index]
+			flipAll(current, moved)
+			N = NumFeatures(current)
+		} else if N >= nr/2 || !c.Satisfied(current) {
+			flipAll(current, moved)
 			continue
 		}
 
-		design := GetDesignMatrix(current, X)
-		coeffTemp := Fit(design, y)
-		score := cost(N, len(y), math.Max(Rss(design, coeffTemp, y), RssTol))
+		coeffTemp, rss := sys.FitModel(current)
+		score := cost(N, nr, math.Max(rss, RssTol))
 
 		accept := score < currentScore || math.Exp(-(score-currentScore)/temp) > rand.Float64()
 
@@ -55,10 +129,10 @@ func SelectModelSA(X mat.Matrix, y []float64, nSweeps int, cost crit) *SARes {
 			copy(coeff, coeffTemp)
 			item := NewSAItem(current)
 			item.Score = -score // Change sign since the highscore list keeps only the larges
-			copy(item.Coeff, coeffTemp)
+			item.Coeff = coeffTemp
 			res.Scores.Insert(item)
 		} else {
-			current[index] = !current[index]
+			flipAll(current, moved)
 		}
 		numSteps++
 
@@ -101,5 +175,30 @@ func SelectModelSA(X mat.Matrix, y []float64, nSweeps int, cost crit) *SARes {
 			counter++
 		}
 	}
-	return &res
+	return &res, stopErr
+}
+
+// swapMove returns a selected and an unselected feature, which are swapped by flipping
+// both. If all or no features are selected, a single random feature is returned. The
+// features are drawn by intn, which returns a random number in [0, n)
+func swapMove(model []bool, intn func(n int) int) []int {
+	selected := SelectedFeatures(model)
+	if len(selected) == 0 || len(selected) == len(model) {
+		return []int{intn(len(model))}
+	}
+
+	unselected := make([]int, 0, len(model)-len(selected))
+	for i, v := range model {
+		if !v {
+			unselected = append(unselected, i)
+		}
+	}
+	return []int{selected[intn(len(selected))], unselected[intn(len(unselected))]}
+}
+
+// flipAll flips the passed features of the model
+func flipAll(model []bool, features []int) {
+	for _, f := range features {
+		model[f] = !model[f]
+	}
 }
@@ -32,7 +32,7 @@ func (s *SliceVec) Dims() (int, int) {
 
 // T returns the transpose of the vector (i.e. a column vector)
 func (s *SliceVec) T() mat.Matrix {
-	return mat.Transpose{s}
+	return mat.Transpose{Matrix: s}
 }
 
 // NewSliceVec returns a new slice vector
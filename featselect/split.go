@@ -0,0 +1,142 @@
+package featselect
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"sort"
+
+	"gonum.org/v1/gonum/mat"
+)
+
+// Fold holds the row indices used for training and testing in one cross validation fold
+type Fold struct {
+	Train []int
+	Test  []int
+}
+
+// SubsetRows returns a new dataset consisting only of the passed rows
+func (d *Dataset) SubsetRows(rows []int) *Dataset {
+	_, nc := d.X.Dims()
+	var subset Dataset
+	subset.X = mat.NewDense(len(rows), nc, nil)
+	subset.Y = make([]float64, len(rows))
+	for i, r := range rows {
+		subset.X.SetRow(i, d.X.RawRowView(r))
+		subset.Y[i] = d.Y[r]
+	}
+
+	if d.Weights != nil {
+		subset.Weights = make([]float64, len(rows))
+		for i, r := range rows {
+			subset.Weights[i] = d.Weights[r]
+		}
+		subset.WeightName = d.WeightName
+	}
+
+	subset.Names = make([]string, len(d.Names))
+	copy(subset.Names, d.Names)
+	subset.TargetCol = d.TargetCol
+
+	for _, g := range d.Categorical {
+		group := CategoricalGroup{Name: g.Name}
+		group.Levels = append(group.Levels, g.Levels...)
+		group.Features = append(group.Features, g.Features...)
+		subset.Categorical = append(subset.Categorical, group)
+	}
+	return &subset
+}
+
+// TrainTestSplit randomly divides the rows into a training set and a test set. The
+// fraction of rows in the test set is given by testFraction. The same seed always
+// gives the same split. The returned indices are sorted
+func (d *Dataset) TrainTestSplit(testFraction float64, seed int64) ([]int, []int, error) {
+	if testFraction <= 0.0 || testFraction >= 1.0 {
+		return nil, nil, fmt.Errorf("traintestsplit: test fraction must be in (0, 1), got %f", testFraction)
+	}
+
+	perm := rand.New(rand.NewSource(seed)).Perm(len(d.Y))
+	numTest := int(math.Round(testFraction * float64(len(d.Y))))
+	if numTest == 0 || numTest == len(d.Y) {
+		return nil, nil, fmt.Errorf("traintestsplit: test fraction %f leaves one of the sets empty", testFraction)
+	}
+
+	test := perm[:numTest]
+	train := perm[numTest:]
+	sort.Ints(test)
+	sort.Ints(train)
+	return train, test, nil
+}
+
+// Split returns a training and a test dataset as defined by TrainTestSplit
+func (d *Dataset) Split(testFraction float64, seed int64) (*Dataset, *Dataset, error) {
+	train, test, err := d.TrainTestSplit(testFraction, seed)
+	if err != nil {
+		return nil, nil, err
+	}
+	return d.SubsetRows(train), d.SubsetRows(test), nil
+}
+
+// KFold randomly divides the rows into k folds of (almost) equal size. Each row
+// is in the test set of exactly one fold. The same seed always gives the same folds
+func (d *Dataset) KFold(k int, seed int64) ([]Fold, error) {
+	return kFold(len(d.Y), k, rand.New(rand.NewSource(seed)))
+}
+
+// RepeatedKFold repeats KFold with a different random partitioning each time. The
+// returned slice has k*repeats folds, where the first k folds belong to the first repetition.
+func (d *Dataset) RepeatedKFold(k int, repeats int, seed int64) ([]Fold, error) {
+	rng := rand.New(rand.NewSource(seed))
+	folds := []Fold{}
+	for i := 0; i < repeats; i++ {
+		f, err := kFold(len(d.Y), k, rng)
+		if err != nil {
+			return nil, err
+		}
+		folds = append(folds, f...)
+	}
+	return folds, nil
+}
+
+func kFold(n int, k int, rng *rand.Rand) ([]Fold, error) {
+	if k < 2 || k > n {
+		return nil, fmt.Errorf("kfold: number of folds must be between 2 and %d, got %d", n, k)
+	}
+
+	perm := rng.Perm(n)
+	folds := make([]Fold, k)
+	start := 0
+	for i := 0; i < k; i++ {
+		size := n / k
+		if i < n%k {
+			size++
+		}
+
+		folds[i].Test = make([]int, size)
+		copy(folds[i].Test, perm[start:start+size])
+		folds[i].Train = make([]int, 0, n-size)
+		folds[i].Train = append(folds[i].Train, perm[:start]...)
+		folds[i].Train = append(folds[i].Train, perm[start+size:]...)
+		sort.Ints(folds[i].Test)
+		sort.Ints(folds[i].Train)
+		start += size
+	}
+	return folds, nil
+}
+
+// Rmse returns the (weighted) root mean square error of the model given by the
+// selected features and the corresponding coefficients
+func (d *Dataset) Rmse(selection []int, coeff []float64) float64 {
+	_, nc := d.X.Dims()
+	design := GetDesignMatrix(Selected2Model(selection, nc), d.X)
+	rss := RssWeighted(design, coeff, d.Y, d.Weights)
+
+	sumW := float64(len(d.Y))
+	if d.Weights != nil {
+		sumW = 0.0
+		for _, w := range d.Weights {
+			sumW += w
+		}
+	}
+	return math.Sqrt(rss / sumW)
+}
@@ -0,0 +1,116 @@
+package featselect
+
+import (
+	"math"
+	"sort"
+	"testing"
+
+	"gonum.org/v1/gonum/floats"
+	"gonum.org/v1/gonum/mat"
+)
+
+func splitTestDataset(n int) *Dataset {
+	X := mat.NewDense(n, 2, nil)
+	y := make([]float64, n)
+	for i := 0; i < n; i++ {
+		X.Set(i, 0, 1.0)
+		X.Set(i, 1, float64(i))
+		y[i] = 2.0 * float64(i)
+	}
+	return &Dataset{X: X, Y: y, Names: []string{"bias", "x", "y"}, TargetCol: 2}
+}
+
+func TestTrainTestSplit(t *testing.T) {
+	dset := splitTestDataset(20)
+	train, test, err := dset.TrainTestSplit(0.25, 42)
+	if err != nil {
+		t.Errorf("Unexpected error %v", err)
+		return
+	}
+
+	if len(train) != 15 || len(test) != 5 {
+		t.Errorf("Expected 15 train and 5 test rows got %d and %d", len(train), len(test))
+	}
+
+	all := append(append([]int{}, train...), test...)
+	sort.Ints(all)
+	for i, v := range all {
+		if v != i {
+			t.Errorf("Train and test set does not partition the rows %v", all)
+			break
+		}
+	}
+
+	train2, test2, _ := dset.TrainTestSplit(0.25, 42)
+	if !EqualInt(train, train2) || !EqualInt(test, test2) {
+		t.Errorf("Same seed should give the same split")
+	}
+
+	if _, _, err := dset.TrainTestSplit(1.0, 42); err == nil {
+		t.Errorf("Expected error when the test fraction is one")
+	}
+
+	trainDset, testDset, _ := dset.Split(0.25, 42)
+	if !floats.EqualApprox(testDset.Y, []float64{2.0 * float64(test[0]), 2.0 * float64(test[1]), 2.0 * float64(test[2]),
+		2.0 * float64(test[3]), 2.0 * float64(test[4])}, 1e-10) {
+		t.Errorf("Unexpected target values in test set %v", testDset.Y)
+	}
+
+	if nr, _ := trainDset.X.Dims(); nr != 15 {
+		t.Errorf("Expected 15 rows in the training set got %d", nr)
+	}
+}
+
+func TestKFold(t *testing.T) {
+	dset := splitTestDataset(11)
+	folds, err := dset.KFold(3, 1)
+	if err != nil {
+		t.Errorf("Unexpected error %v", err)
+		return
+	}
+
+	count := make([]int, 11)
+	for _, f := range folds {
+		if len(f.Train)+len(f.Test) != 11 {
+			t.Errorf("Fold does not contain all rows")
+		}
+
+		for _, v := range f.Test {
+			count[v]++
+			if ExistInt(f.Train, v) {
+				t.Errorf("Row %d is in both the training and test set", v)
+			}
+		}
+	}
+
+	for i, c := range count {
+		if c != 1 {
+			t.Errorf("Row %d is in the test set of %d folds", i, c)
+		}
+	}
+
+	repeated, err := dset.RepeatedKFold(3, 4, 1)
+	if err != nil || len(repeated) != 12 {
+		t.Errorf("Expected 12 folds got %d (err: %v)", len(repeated), err)
+	}
+
+	if _, err := dset.KFold(12, 1); err == nil {
+		t.Errorf("Expected error when the number of folds exceeds the number of rows")
+	}
+}
+
+func TestDatasetRmse(t *testing.T) {
+	dset := splitTestDataset(5)
+	if rmse := dset.Rmse([]int{1}, []float64{2.0}); math.Abs(rmse) > 1e-10 {
+		t.Errorf("Expected zero RMSE got %f", rmse)
+	}
+
+	if rmse := dset.Rmse([]int{1}, []float64{1.0}); math.Abs(rmse-math.Sqrt(6.0)) > 1e-10 {
+		t.Errorf("Expected RMSE %f got %f", math.Sqrt(6.0), rmse)
+	}
+
+	dset.Weights = []float64{0.0, 0.0, 0.0, 0.0, 1.0}
+	if rmse := dset.Rmse([]int{1}, []float64{1.0}); math.Abs(rmse-4.0) > 1e-10 {
+		t.Errorf("Expected weighted RMSE 4 got %f", rmse)
+	}
+}
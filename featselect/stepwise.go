@@ -0,0 +1,285 @@
+package featselect
+
+import (
+	"fmt"
+	"math"
+	"strings"
+
+	"gonum.org/v1/gonum/mat"
+)
+
+// StepwiseStrategy determines which moves a stepwise selection considers
+type StepwiseStrategy int
+
+const (
+	// Forward starts from the smallest model and adds the feature that improves the
+	// score the most, until no feature improves it
+	Forward StepwiseStrategy = iota
+
+	// Backward starts from the largest model and removes the feature that improves the
+	// score the most, until no removal improves it
+	Backward
+
+	// Bidirectional starts from the smallest model and in each step adds or removes the
+	// feature that improves the score the most
+	Bidirectional
+
+	// FoBa is adaptive forward-backward selection. After each forward step, features are
+	// removed as long as the score increases by less than FoBaRatio times the improvement
+	// of the latest forward step that has not been undone
+	FoBa
+)
+
+// String returns the name of the strategy
+func (s StepwiseStrategy) String() string {
+	switch s {
+	case Forward:
+		return "forward"
+	case Backward:
+		return "backward"
+	case Bidirectional:
+		return "bidirectional"
+	case FoBa:
+		return "foba"
+	}
+	return fmt.Sprintf("StepwiseStrategy(%d)", int(s))
+}
+
+// ParseStepwiseStrategy returns the strategy with the given name |forward|backward|bidirectional|foba|
+func ParseStepwiseStrategy(name string) (StepwiseStrategy, error) {
+	for _, s := range []StepwiseStrategy{Forward, Backward, Bidirectional, FoBa} {
+		if strings.ToLower(name) == s.String() {
+			return s, nil
+		}
+	}
+	return Forward, fmt.Errorf("parsestepwisestrategy: unknown strategy %s", name)
+}
+
+// StepwiseParams holds the parameters of a stepwise selection. The criterion is minimized
+// (AICc by default) and only models satisfying the constraints are accepted. FoBaRatio
+// is only used by FoBa and must be in the interval (0, 1).
+type StepwiseParams struct {
+	Strategy    StepwiseStrategy
+	Criterion   Criterion
+	Constraints *Constraints
+	FoBaRatio   float64
+}
+
+// NewStepwiseParams returns the default parameters of a stepwise selection
+func NewStepwiseParams() *StepwiseParams {
+	var params StepwiseParams
+	params.Strategy = Forward
+	params.Criterion = AiccCriterion
+	params.FoBaRatio = 0.5
+	return &params
+}
+
+// StepwiseResult holds the models accepted by a stepwise selection. Path[0] is the initial
+// model and each of the following nodes is the model after one step, such that the last
+// node is the selected model. The level of a node is the step where it was first visited.
+type StepwiseResult struct {
+	Path []*Node
+}
+
+// Best returns the selected model
+func (r *StepwiseResult) Best() *Node {
+	return r.Path[len(r.Path)-1]
+}
+
+// Stepwise selects a model by adding and removing one feature at a time. All the models
+// that are visited are inserted in the highscore list, with the score and the coefficients
+// of the model. The initial model of forward, bidirectional and FoBa selection holds
+// feature 0 (usually the intercept) and the included features. Backward selection starts
+// from the largest model satisfying the constraints. An error wrapping
+// ErrInvalidConstraints is returned if the constraints are inconsistent.
+func Stepwise(X mat.Matrix, y []float64, highscore *Highscore, params *StepwiseParams) (*StepwiseResult, error) {
+	return stepwise(&DenseSystem{X: X, Y: y}, highscore, params)
+}
+
+// StepwiseFromStats performs a stepwise selection, where the models are fitted from
+// sufficient statistics
+func StepwiseFromStats(stats *SufficientStats, highscore *Highscore, params *StepwiseParams) (*StepwiseResult, error) {
+	return stepwise(stats, highscore, params)
+}
+
+func stepwise(sys LinearSystem, highscore *Highscore, params *StepwiseParams) (*StepwiseResult, error) {
+	_, nc := sys.Dims()
+	c := params.Constraints
+	if err := c.Validate(nc); err != nil {
+		return nil, err
+	}
+
+	if params.Strategy == FoBa && (params.FoBaRatio <= 0.0 || params.FoBaRatio >= 1.0) {
+		return nil, fmt.Errorf("stepwise: foba ratio must be in (0, 1), got %f", params.FoBaRatio)
+	}
+
+	criterion, err := prepareCriterion(params.Criterion, sys)
+	if err != nil {
+		return nil, err
+	}
+
+	if c == nil {
+		c = &Constraints{}
+	}
+
+	// Features are added to reach MinFeatures by the forward steps
+	start := *c
+	if params.Strategy != Backward {
+		start.MinFeatures = 0
+	}
+
+	model, err := start.initialModel(nc)
+	if err != nil {
+		return nil, err
+	}
+
+	if params.Strategy == Backward {
+		for f := range model {
+			if !model[f] && c.canAdd(model, f) {
+				setAll(model, c.together(f), true)
+			}
+		}
+	}
+
+	s := &stepwiseSearch{
+		sys:       sys,
+		criterion: criterion,
+		c:         c,
+		highscore: highscore,
+		visited:   make(map[string]*Node),
+	}
+
+	current := s.visit(model, 0)
+	res := &StepwiseResult{Path: []*Node{current}}
+	gains := []float64{}
+	for step := 1; ; step++ {
+		var next *Node
+		switch params.Strategy {
+		case Forward:
+			next = s.bestAddition(current, step)
+		case Backward:
+			next = s.bestRemoval(current, step)
+		case Bidirectional:
+			next = s.bestAddition(current, step)
+			if removal := s.bestRemoval(current, step); removal != nil && (next == nil || removal.Score > next.Score) {
+				next = removal
+			}
+		case FoBa:
+			next = s.bestAddition(current, step)
+		default:
+			return nil, fmt.Errorf("stepwise: unknown strategy %v", params.Strategy)
+		}
+
+		if next == nil || !s.accept(current, next) {
+			break
+		}
+		gains = append(gains, next.Score-current.Score)
+		current = next
+		res.Path = append(res.Path, current)
+
+		if params.Strategy != FoBa {
+			continue
+		}
+
+		// A removal is compared with the gain of the latest forward step that has not been
+		// undone, such that each cycle of forward and backward steps improves the score
+		for len(gains) > 0 {
+			removal := s.bestRemoval(current, step+1)
+			if removal == nil || current.Score-removal.Score >= params.FoBaRatio*gains[len(gains)-1] {
+				break
+			}
+			step++
+			gains = gains[:len(gains)-1]
+			current = removal
+			res.Path = append(res.Path, current)
+		}
+	}
+	return res, nil
+}
+
+// stepwiseSearch holds the state of a stepwise selection. visited holds all models that
+// have been fitted, such that each model is fitted and inserted in the highscore list once
+type stepwiseSearch struct {
+	sys       LinearSystem
+	criterion Criterion
+	c         *Constraints
+	highscore *Highscore
+	visited   map[string]*Node
+}
+
+// visit returns the node of the model, and fits the model if it has not been visited before
+func (s *stepwiseSearch) visit(model []bool, level int) *Node {
+	node := NewNode(level, model)
+	if n, ok := s.visited[node.Model.key()]; ok {
+		return n
+	}
+
+	nr, _ := s.sys.Dims()
+	var rss float64
+	node.Coeff, rss = s.sys.FitModel(model)
+	node.Score = -s.criterion.Score(NumFeatures(model), nr, math.Max(rss, RssTol))
+	s.visited[node.Model.key()] = node
+	if s.highscore != nil {
+		s.highscore.Insert(node)
+	}
+	return node
+}
+
+// accept returns true if the model can move from current to next. A move is accepted if
+// it improves the score, or if it is an addition and the current model has too few
+// features to satisfy the constraints
+func (s *stepwiseSearch) accept(current *Node, next *Node) bool {
+	if next.Score > current.Score {
+		return true
+	}
+	return next.Model.NumFeatures() > current.Model.NumFeatures() && !s.c.Satisfied(current.Model.ToBools())
+}
+
+// bestAddition returns the best model where one feature, and the features that are selected
+// together with it, is added to the model of current. It returns nil if no feature can be added
+func (s *stepwiseSearch) bestAddition(current *Node, level int) *Node {
+	model := current.Model.ToBools()
+	var best *Node
+	for f := range model {
+		if model[f] || !s.c.canAdd(model, f) {
+			continue
+		}
+
+		group := s.c.together(f)
+		setAll(model, group, true)
+		if n := s.visit(model, level); best == nil || n.Score > best.Score {
+			best = n
+		}
+		setAll(model, group, false)
+	}
+	return best
+}
+
+// bestRemoval returns the best model satisfying the constraints where one feature, and the
+// features that are selected together with it, is removed from the model of current. It returns nil if no feature can be removed
+func (s *stepwiseSearch) bestRemoval(current *Node, level int) *Node {
+	model := current.Model.ToBools()
+	var best *Node
+	for f := range model {
+		if !model[f] {
+			continue
+		}
+
+		group := s.c.together(f)
+		setAll(model, group, false)
+		if NumFeatures(model) > 0 && s.c.Satisfied(model) {
+			if n := s.visit(model, level); best == nil || n.Score > best.Score {
+				best = n
+			}
+		}
+		setAll(model, group, true)
+	}
+	return best
+}
+
+// setAll sets the passed features in model to value
+func setAll(model []bool, features []int, value bool) {
+	for _, f := range features {
+		model[f] = value
+	}
+}
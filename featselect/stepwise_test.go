@@ -0,0 +1,132 @@
+package featselect
+
+import (
+	"errors"
+	"math"
+	"testing"
+)
+
+func TestParseStepwiseStrategy(t *testing.T) {
+	for i, test := range []struct {
+		name     string
+		strategy StepwiseStrategy
+		ok       bool
+	}{
+		{name: "forward", strategy: Forward, ok: true},
+		{name: "Backward", strategy: Backward, ok: true},
+		{name: "bidirectional", strategy: Bidirectional, ok: true},
+		{name: "FOBA", strategy: FoBa, ok: true},
+		{name: "sideways", ok: false},
+	} {
+		s, err := ParseStepwiseStrategy(test.name)
+		if (err == nil) != test.ok {
+			t.Errorf("Test #%d: Unexpected error %v", i, err)
+		}
+
+		if test.ok && s != test.strategy {
+			t.Errorf("Test #%d: Expected %v got %v", i, test.strategy, s)
+		}
+	}
+}
+
+func TestStepwise(t *testing.T) {
+	X, y := incrementalTestData(60, 8)
+	for i := 0; i < 60; i++ {
+		X.Set(i, 0, 1.0)
+	}
+	brute := BruteForceSelect(X, y)
+	want := brute.Items.Front().Value.(*Node)
+
+	for i, strategy := range []StepwiseStrategy{Forward, Backward, Bidirectional, FoBa} {
+		params := NewStepwiseParams()
+		params.Strategy = strategy
+		highscore := NewHighscore(10)
+		res, err := Stepwise(X, y, highscore, params)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		best := res.Best()
+		if !best.Model.Equal(&want.Model) {
+			t.Errorf("Test #%d: Expected selection %v got %v", i, want.Model.Selected(), best.Model.Selected())
+		}
+
+		if math.Abs(best.Score-want.Score) > 1e-8 || math.Abs(highscore.BestScore()-best.Score) > 1e-8 {
+			t.Errorf("Test #%d: Expected score %f got %f (highscore %f)", i, want.Score, best.Score, highscore.BestScore())
+		}
+
+		if len(best.Coeff) != best.Model.NumFeatures() {
+			t.Errorf("Test #%d: Expected %d coefficients got %d", i, best.Model.NumFeatures(), len(best.Coeff))
+		}
+
+		for j := 1; j < len(res.Path) && strategy != FoBa; j++ {
+			if res.Path[j].Score <= res.Path[j-1].Score {
+				t.Errorf("Test #%d: Step %d does not improve the score", i, j)
+			}
+		}
+	}
+}
+
+func TestStepwiseConstraints(t *testing.T) {
+	X, y := incrementalTestData(40, 6)
+	for i, c := range []*Constraints{
+		{Include: []int{2}, Exclude: []int{1}},
+		{MinFeatures: 5},
+		{MaxFeatures: 1},
+		{Exclusive: [][]int{{1, 3}}},
+		{Together: [][]int{{1, 3}}},
+	} {
+		for _, strategy := range []StepwiseStrategy{Forward, Backward, Bidirectional, FoBa} {
+			params := NewStepwiseParams()
+			params.Strategy = strategy
+			params.Constraints = c
+			res, err := Stepwise(X, y, nil, params)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if model := res.Best().Model.ToBools(); !c.Satisfied(model) {
+				t.Errorf("Test #%d: %v selected %v which does not satisfy the constraints", i, strategy, model)
+			}
+		}
+	}
+
+	params := NewStepwiseParams()
+	params.Constraints = &Constraints{Include: []int{7}}
+	if _, err := Stepwise(X, y, nil, params); !errors.Is(err, ErrInvalidConstraints) {
+		t.Errorf("Expected ErrInvalidConstraints got %v", err)
+	}
+
+	params = NewStepwiseParams()
+	params.Strategy = FoBa
+	params.FoBaRatio = 1.0
+	if _, err := Stepwise(X, y, nil, params); err == nil {
+		t.Errorf("Expected an error for a FoBa ratio of 1")
+	}
+}
+
+func TestStepwiseFromStats(t *testing.T) {
+	X, y, w := statsTestData()
+	stats := NewSufficientStats(4)
+	stats.AddRows(X, y, w)
+	Xw, yw := WeightRows(X, y, w)
+
+	for i, strategy := range []StepwiseStrategy{Forward, Backward} {
+		params := NewStepwiseParams()
+		params.Strategy = strategy
+		params.Criterion = BicCriterion
+		fromStats, err := StepwiseFromStats(stats, nil, params)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		dense, err := Stepwise(Xw, yw, nil, params)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if !fromStats.Best().Model.Equal(&dense.Best().Model) || math.Abs(fromStats.Best().Score-dense.Best().Score) > 1e-8 {
+			t.Errorf("Test #%d: Expected %v (%f) got %v (%f)", i, dense.Best().Model.Selected(), dense.Best().Score, fromStats.Best().Model.Selected(), fromStats.Best().Score)
+		}
+	}
+}
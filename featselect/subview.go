@@ -24,7 +24,7 @@ func (v *IndexedColView) Dims() (int, int) {
 
 // T returns an implicit transpose of the matrix field
 func (v *IndexedColView) T() mat.Matrix {
-	return mat.Transpose{v}
+	return mat.Transpose{Matrix: v}
 }
 
 // NewIndexedColView returns a view of the matrix containing only the rows specified
@@ -65,7 +65,7 @@ func (v *IndexedColVecView) Dims() (int, int) {
 
 // T returns an implicit transpose of the matrix field
 func (v *IndexedColVecView) T() mat.Matrix {
-	return mat.Transpose{v}
+	return mat.Transpose{Matrix: v}
 }
 
 // Len returns the length of the vector
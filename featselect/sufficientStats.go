@@ -0,0 +1,430 @@
+package featselect
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"strconv"
+
+	"gonum.org/v1/gonum/mat"
+)
+
+// StatsChunkSize is the number of rows that are accumulated before they are added
+// to the sufficient statistics when a file is streamed
+const StatsChunkSize = 4096
+
+// SufficientStats holds the (weighted) sums needed to fit linear models by least
+// squares, so that the raw design matrix does not need to be kept in memory. If the
+// statistics are unweighted, all weights are one and SumW is equal to NumRows.
+// CovXX, CovXy and CovYY hold the (weighted) sums of products of the deviations from
+// the means. They are merged chunk by chunk, such that the residual sum of squares, the
+// standard deviations and the normalised data do not suffer from cancellation when the
+// means are large compared to the spread.
+type SufficientStats struct {
+	XTX        *mat.SymDense
+	XTy        []float64
+	YTy        float64
+	SumX       []float64
+	SumY       float64
+	SumW       float64
+	CovXX      *mat.SymDense
+	CovXy      []float64
+	CovYY      float64
+	NumRows    int
+	Names      []string
+	TargetCol  int
+	WeightName string
+}
+
+// NewSufficientStats returns empty statistics for numFeatures features
+func NewSufficientStats(numFeatures int) *SufficientStats {
+	var s SufficientStats
+	s.XTX = mat.NewSymDense(numFeatures, nil)
+	s.XTy = make([]float64, numFeatures)
+	s.SumX = make([]float64, numFeatures)
+	s.CovXX = mat.NewSymDense(numFeatures, nil)
+	s.CovXy = make([]float64, numFeatures)
+	return &s
+}
+
+// AddRows adds a chunk of data points to the statistics. X holds one data point per row
+// and y the corresponding targets. If w is not nil, it holds the weight of each data point
+func (s *SufficientStats) AddRows(X mat.Matrix, y []float64, w []float64) {
+	nr, nc := X.Dims()
+	if nc != len(s.XTy) {
+		panic("AddRows: Inconsistent number of features")
+	}
+
+	Xw, yw := WeightRows(X, y, w)
+	s.XTX.SymRankK(s.XTX, 1.0, Xw.T())
+
+	sumX := make([]float64, nc)
+	sumY := 0.0
+	sumW := 0.0
+	for i := 0; i < nr; i++ {
+		weight := 1.0
+		if w != nil {
+			weight = w[i]
+		}
+
+		row := Xw.RawRowView(i)
+		sqrtW := math.Sqrt(weight)
+		for j, v := range row {
+			s.XTy[j] += v * yw[i]
+			sumX[j] += v * sqrtW
+		}
+		s.YTy += yw[i] * yw[i]
+		sumY += weight * y[i]
+		sumW += weight
+	}
+	s.addCentred(Xw, yw, w, sumX, sumY, sumW)
+
+	for j, v := range sumX {
+		s.SumX[j] += v
+	}
+	s.SumY += sumY
+	s.SumW += sumW
+	s.NumRows += nr
+}
+
+// addCentred merges the sums of products of deviations of a chunk into CovXX, CovXy and
+// CovYY. Xw and yw are the weighted rows of the chunk, and sumX, sumY and sumW are its
+// weighted sums. Xw and yw are centred in place.
+func (s *SufficientStats) addCentred(Xw *mat.Dense, yw []float64, w []float64, sumX []float64, sumY float64, sumW float64) {
+	if sumW <= 0.0 {
+		return
+	}
+
+	nr, nc := Xw.Dims()
+	for i := 0; i < nr; i++ {
+		sqrtW := 1.0
+		if w != nil {
+			sqrtW = math.Sqrt(w[i])
+		}
+
+		row := Xw.RawRowView(i)
+		for j := range row {
+			row[j] -= sqrtW * sumX[j] / sumW
+		}
+		yw[i] -= sqrtW * sumY / sumW
+	}
+
+	// The chunk is merged with the previous data by shifting both to the common mean
+	factor := 0.0
+	dx := make([]float64, nc)
+	dy := 0.0
+	if s.SumW > 0.0 {
+		factor = s.SumW * sumW / (s.SumW + sumW)
+		for j := range dx {
+			dx[j] = sumX[j]/sumW - s.SumX[j]/s.SumW
+		}
+		dy = sumY/sumW - s.SumY/s.SumW
+	}
+
+	var shift mat.SymDense
+	shift.SymOuterK(factor, mat.NewVecDense(nc, dx))
+	s.CovXX.SymRankK(s.CovXX, 1.0, Xw.T())
+	s.CovXX.AddSym(s.CovXX, &shift)
+
+	yVec := mat.NewVecDense(nr, yw)
+	for j := range s.CovXy {
+		s.CovXy[j] += mat.Dot(Xw.ColView(j), yVec) + factor*dx[j]*dy
+	}
+	s.CovYY += mat.Dot(yVec, yVec) + factor*dy*dy
+}
+
+// Dims returns the number of data points and the number of features
+func (s *SufficientStats) Dims() (int, int) {
+	return s.NumRows, len(s.XTy)
+}
+
+// Means returns the (weighted) mean of each feature
+func (s *SufficientStats) Means() []float64 {
+	mu := make([]float64, len(s.SumX))
+	for i, v := range s.SumX {
+		mu[i] = v / s.SumW
+	}
+	return mu
+}
+
+// Stds returns the (weighted) standard deviation of each feature. The result is the
+// same as WeightedStd applied to each column
+func (s *SufficientStats) Stds() []float64 {
+	std := make([]float64, len(s.SumX))
+	for i := range std {
+		std[i] = s.std(s.CovXX.At(i, i))
+	}
+	return std
+}
+
+// MeanY returns the (weighted) mean of the target
+func (s *SufficientStats) MeanY() float64 {
+	return s.SumY / s.SumW
+}
+
+// StdY returns the (weighted) standard deviation of the target
+func (s *SufficientStats) StdY() float64 {
+	return s.std(s.CovYY)
+}
+
+// std calculates the standard deviation from the weighted sum of squared deviations
+func (s *SufficientStats) std(sumSqDev float64) float64 {
+	if s.NumRows <= 1 {
+		return 0.0
+	}
+	n := float64(s.NumRows)
+	variance := sumSqDev / s.SumW * n / (n - 1.0)
+	return math.Sqrt(math.Max(variance, 0.0))
+}
+
+// Fit returns the least squares coefficients of the features that are true in model
+func (s *SufficientStats) Fit(model []bool) []float64 {
+	selected := SelectedFeatures(model)
+	n := len(selected)
+	gram := mat.NewSymDense(n, nil)
+	rhs := mat.NewVecDense(n, nil)
+	for i, fi := range selected {
+		rhs.SetVec(i, s.XTy[fi])
+		for j := i; j < n; j++ {
+			gram.SetSym(i, j, s.XTX.At(fi, selected[j]))
+		}
+	}
+
+	// The eigenvalues of XᵀX are the squared singular values of X. Thus, the
+	// threshold is the square of the one used in Fit
+	var eig mat.EigenSym
+	if ok := eig.Factorize(gram, true); !ok {
+		panic("SufficientStats: Eigen decomposition failed")
+	}
+	values := eig.Values(nil)
+	var vectors mat.Dense
+	eig.VectorsTo(&vectors)
+
+	var proj mat.VecDense
+	proj.MulVec(vectors.T(), rhs)
+	for i, v := range values {
+		if v > 1e-12 {
+			proj.SetVec(i, proj.AtVec(i)/v)
+		} else {
+			proj.SetVec(i, 0.0)
+		}
+	}
+
+	var coeff mat.VecDense
+	coeff.MulVec(&vectors, &proj)
+	return coeff.RawVector().Data
+}
+
+// Rss returns the residual sum of squares of the model with the passed coefficients. It is
+// computed as the sum of squared deviations of the residuals from their mean (from the centred
+// sums), plus the contribution of the mean. The result is at least RssTol.
+func (s *SufficientStats) Rss(model []bool, coeff []float64) float64 {
+	selected := SelectedFeatures(model)
+	if len(selected) != len(coeff) {
+		panic("SufficientStats: Inconsistent number of coefficients")
+	}
+
+	rss := s.CovYY
+	meanRes := s.SumY
+	for i, fi := range selected {
+		rss -= 2.0 * coeff[i] * s.CovXy[fi]
+		meanRes -= coeff[i] * s.SumX[fi]
+		for j, fj := range selected {
+			rss += coeff[i] * s.CovXX.At(fi, fj) * coeff[j]
+		}
+	}
+
+	if s.SumW > 0.0 {
+		rss += meanRes * meanRes / s.SumW
+	}
+	return math.Max(rss, RssTol)
+}
+
+// FitModel fits the features that are true in model and returns the coefficients
+// and the residual sum of squares
+func (s *SufficientStats) FitModel(model []bool) ([]float64, float64) {
+	coeff := s.Fit(model)
+	return coeff, s.Rss(model, coeff)
+}
+
+// GetFeatName returns the name of the i-th feature
+func (s *SufficientStats) GetFeatName(i int) string {
+	if i < s.TargetCol {
+		return s.Names[i]
+	}
+	return s.Names[i+1]
+}
+
+// FeatureNames returns the names of all the features
+func (s *SufficientStats) FeatureNames() []string {
+	names := make([]string, len(s.XTy))
+	for i := range names {
+		names[i] = s.GetFeatName(i)
+	}
+	return names
+}
+
+// ReadCSVStats streams a CSV file and accumulates the sufficient statistics. See ParseCSVStats.
+func ReadCSVStats(fname string, targetCol int, params *CSVOptParams) (*SufficientStats, error) {
+	file, err := os.Open(fname)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	return ParseCSVStats(file, targetCol, params)
+}
+
+// ParseCSVStats reads the CSV data in chunks of StatsChunkSize rows and accumulates
+// the sufficient statistics, such that only one chunk is kept in memory at a time.
+// The file format and params have the same meaning as in ParseCSVWithParams, except
+// that categorical columns are not supported and the missing value policy has to be
+// MissingError or MissingDrop.
+func ParseCSVStats(handle io.Reader, targetCol int, params *CSVOptParams) (*SufficientStats, error) {
+	if params == nil {
+		params = NewCSVOptParams()
+	}
+
+	if len(params.Categorical) > 0 || params.AutoCategorical {
+		return nil, fmt.Errorf("parsecsvstats: categorical columns are not supported when streaming")
+	}
+
+	if params.Missing != MissingError && params.Missing != MissingDrop {
+		return nil, fmt.Errorf("parsecsvstats: only the error and drop missing value policies are supported when streaming")
+	}
+
+	lr, err := newCSVLineReader(handle, params.Delimiter)
+	if err != nil {
+		return nil, err
+	}
+
+	names := lr.header
+	if params.TargetName != "" {
+		targetCol = -1
+		for i, n := range names {
+			if n == params.TargetName {
+				targetCol = i
+				break
+			}
+		}
+
+		if targetCol == -1 {
+			return nil, fmt.Errorf("parsecsvstats: target %s: %w", params.TargetName, ErrFeatureNotFound)
+		}
+	}
+
+	if targetCol < 0 {
+		targetCol += len(names)
+	}
+
+	if targetCol < 0 || targetCol >= len(names) {
+		return nil, fmt.Errorf("parsecsvstats: target column %d out of range (file has %d columns)", targetCol, len(names))
+	}
+
+	weightCol := -1
+	if params.WeightName != "" {
+		for i, n := range names {
+			if n == params.WeightName && i != targetCol {
+				weightCol = i
+			}
+		}
+
+		if weightCol == -1 {
+			return nil, fmt.Errorf("parsecsvstats: weights %s: %w", params.WeightName, ErrFeatureNotFound)
+		}
+	}
+
+	numFeat := len(names) - 1
+	if weightCol >= 0 {
+		numFeat--
+	}
+
+	if numFeat == 0 {
+		return nil, fmt.Errorf("parsecsvstats: the file has no feature columns")
+	}
+
+	stats := NewSufficientStats(numFeat)
+	stats.TargetCol = targetCol
+	for i, n := range names {
+		if i != weightCol {
+			stats.Names = append(stats.Names, n)
+		}
+	}
+
+	if weightCol >= 0 {
+		stats.WeightName = params.WeightName
+		if weightCol < targetCol {
+			stats.TargetCol--
+		}
+	}
+
+	chunk := make([]float64, 0, StatsChunkSize*numFeat)
+	y := make([]float64, 0, StatsChunkSize)
+	var w []float64
+	if weightCol >= 0 {
+		w = make([]float64, 0, StatsChunkSize)
+	}
+
+	addChunk := func() {
+		if len(y) > 0 {
+			stats.AddRows(mat.NewDense(len(y), numFeat, chunk), y, w)
+		}
+		chunk = chunk[:0]
+		y = y[:0]
+		if w != nil {
+			w = w[:0]
+		}
+	}
+
+	row := make([]float64, len(names))
+rowLoop:
+	for {
+		line, err := lr.Next()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			return nil, err
+		}
+
+		for j, v := range line {
+			if IsMissing(v) {
+				if params.Missing == MissingDrop {
+					continue rowLoop
+				}
+				return nil, &CSVError{Line: lr.lineNo, Column: j + 1, Err: ErrMissingValue}
+			}
+
+			row[j], err = strconv.ParseFloat(v, 64)
+			if err != nil {
+				return nil, &CSVError{Line: lr.lineNo, Column: j + 1, Err: err}
+			}
+		}
+
+		if weightCol >= 0 {
+			if row[weightCol] < 0.0 || math.IsInf(row[weightCol], 0) {
+				return nil, &CSVError{Line: lr.lineNo, Column: weightCol + 1, Err: ErrInvalidWeight}
+			}
+			w = append(w, row[weightCol])
+		}
+
+		for j, v := range row {
+			switch j {
+			case targetCol:
+				y = append(y, v)
+			case weightCol:
+			default:
+				chunk = append(chunk, v)
+			}
+		}
+
+		if len(y) == StatsChunkSize {
+			addChunk()
+		}
+	}
+	addChunk()
+
+	if stats.NumRows == 0 {
+		return nil, ErrNoData
+	}
+	return stats, nil
+}
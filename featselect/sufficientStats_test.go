@@ -0,0 +1,246 @@
+package featselect
+
+import (
+	"fmt"
+	"math"
+	"strings"
+	"testing"
+
+	"github.com/davidkleiven/goselect/featselect/testfeatselect"
+	"gonum.org/v1/gonum/floats"
+	"gonum.org/v1/gonum/mat"
+)
+
+func statsTestData() (*mat.Dense, []float64, []float64) {
+	X := mat.NewDense(7, 4, []float64{1.0, 0.0, 0.0, 0.0,
+		1.0, 1.0, 1.0, 1.0,
+		1.0, 2.0, 4.0, 8.0,
+		1.0, 3.0, 9.0, 15.0,
+		1.0, 4.0, 9.0, 30.0,
+		1.0, 2.0, 3.0, 6.0,
+		1.0, -2.0, 5.0, 4.0})
+
+	y := []float64{1.0, 2.0, 5.0, 7.0, 10.0, 8.0, 15.0}
+	w := []float64{1.0, 0.5, 2.0, 1.0, 3.0, 0.2, 1.0}
+	return X, y, w
+}
+
+func TestSufficientStatsFit(t *testing.T) {
+	X, y, w := statsTestData()
+	for i, weights := range [][]float64{nil, w} {
+		stats := NewSufficientStats(4)
+		stats.AddRows(X.Slice(0, 3, 0, 4), y[:3], sliceOrNil(weights, 0, 3))
+		stats.AddRows(X.Slice(3, 7, 0, 4), y[3:], sliceOrNil(weights, 3, 7))
+
+		if nr, nc := stats.Dims(); nr != 7 || nc != 4 {
+			t.Errorf("Test #%d: Expected dimensions (7, 4) got (%d, %d)", i, nr, nc)
+		}
+
+		for _, model := range [][]bool{{true, true, false, false}, {true, false, true, true}, {false, true, true, true}} {
+			design := GetDesignMatrix(model, X)
+			expect := FitWeighted(design, y, weights)
+			expectRss := RssWeighted(design, expect, y, weights)
+
+			coeff, rss := stats.FitModel(model)
+			if !floats.EqualApprox(coeff, expect, 1e-8) {
+				t.Errorf("Test #%d: Expected coefficients %v got %v", i, expect, coeff)
+			}
+
+			if math.Abs(rss-expectRss) > 1e-8 {
+				t.Errorf("Test #%d: Expected rss %f got %f", i, expectRss, rss)
+			}
+		}
+
+		ones := make([]float64, 7)
+		for j := range ones {
+			ones[j] = 1.0
+		}
+		if weights == nil {
+			weights = ones
+		}
+
+		means := stats.Means()
+		stds := stats.Stds()
+		for j := 0; j < 4; j++ {
+			col := mat.Col(nil, j, X)
+			if math.Abs(means[j]-WeightedMean(col, weights)) > 1e-10 || math.Abs(stds[j]-WeightedStd(col, weights)) > 1e-10 {
+				t.Errorf("Test #%d: Unexpected mean or std of column %d", i, j)
+			}
+		}
+
+		if math.Abs(stats.MeanY()-WeightedMean(y, weights)) > 1e-10 || math.Abs(stats.StdY()-WeightedStd(y, weights)) > 1e-10 {
+			t.Errorf("Test #%d: Unexpected mean or std of the target", i)
+		}
+	}
+}
+
+func TestSufficientStatsRssLargeMean(t *testing.T) {
+	// The features and the target have means that are large compared to their spread, such
+	// that the uncentred sums lose all digits of the residual sum of squares
+	X := mat.NewDense(50, 2, nil)
+	y := make([]float64, 50)
+	for i := range y {
+		x := 1e6 + 0.1*float64(i)
+		X.Set(i, 0, 1.0)
+		X.Set(i, 1, x)
+		y[i] = 3.0 + 2.0*x + 1e-3*math.Sin(float64(i))
+	}
+
+	weights := make([]float64, 50)
+	for i := range weights {
+		weights[i] = 1.0 + 0.5*float64(i%3)
+	}
+
+	model := []bool{true, true}
+	coeff := Fit(X, y)
+	for i, w := range [][]float64{nil, weights} {
+		expect := RssWeighted(X, coeff, y, w)
+		stats := NewSufficientStats(2)
+		stats.AddRows(X.Slice(0, 20, 0, 2), y[:20], sliceOrNil(w, 0, 20))
+		stats.AddRows(X.Slice(20, 50, 0, 2), y[20:], sliceOrNil(w, 20, 50))
+
+		if rss := stats.Rss(model, coeff); math.Abs(rss-expect) > 1e-3*expect {
+			t.Errorf("Test #%d: Expected rss %e got %e", i, expect, rss)
+		}
+	}
+}
+
+func TestNormalizedDataFromStatsLargeMean(t *testing.T) {
+	// The features have means that are large compared to their spread, such that the
+	// variances and the normalised Gram matrix can not be taken from the uncentred sums
+	X := mat.NewDense(50, 3, nil)
+	y := make([]float64, 50)
+	for i := range y {
+		X.Set(i, 0, 1.0)
+		X.Set(i, 1, 1e6+0.1*float64(i))
+		X.Set(i, 2, 1e6+math.Sin(float64(i)))
+		y[i] = 1e4 + 2.0*X.At(i, 1) - X.At(i, 2) + 0.01*math.Cos(float64(i))
+	}
+
+	weights := make([]float64, 50)
+	for i := range weights {
+		weights[i] = 1.0 + 0.5*float64(i%3)
+	}
+
+	var cov Empirical
+	var correction PureLasso
+	for i, w := range [][]float64{nil, weights} {
+		stats := NewSufficientStats(3)
+		stats.AddRows(X.Slice(0, 20, 0, 3), y[:20], sliceOrNil(w, 0, 20))
+		stats.AddRows(X.Slice(20, 50, 0, 3), y[20:], sliceOrNil(w, 20, 50))
+
+		weight := w
+		if weight == nil {
+			weight = make([]float64, 50)
+			floats.AddConst(1.0, weight)
+		}
+
+		std := stats.Stds()
+		for c := 1; c < 3; c++ {
+			expect := WeightedStd(mat.Col(nil, c, X), weight)
+			if math.Abs(std[c]-expect) > 1e-6*expect {
+				t.Errorf("Test #%d: Expected std %e of column %d got %e", i, expect, c, std[c])
+			}
+		}
+
+		statsData, err := TryNewNormalizedDataFromStats(stats)
+		if err != nil {
+			t.Fatalf("Test #%d: %v", i, err)
+		}
+
+		data := NewWeightedNormalizedData(mat.DenseCopyOf(X), append([]float64{}, y...), weight)
+		expect := LassoCrdDesc(data, 0.001, &cov, nil, 100000, 1e-10, &correction)
+		res := LassoCrdDesc(statsData, 0.001, &cov, nil, 100000, 1e-10, &correction)
+		if !floats.EqualApprox(res, expect, 1e-6) {
+			t.Errorf("Test #%d: Expected\n%v\nGot\n%v\n", i, expect, res)
+		}
+	}
+}
+
+func sliceOrNil(v []float64, start int, end int) []float64 {
+	if v == nil {
+		return nil
+	}
+	return v[start:end]
+}
+
+func TestParseCSVStats(t *testing.T) {
+	var sb strings.Builder
+	sb.WriteString("w,x1,y,x2\n")
+	numRows := 2*StatsChunkSize + 10
+	for i := 0; i < numRows; i++ {
+		x1 := float64(i%17) - 3.0
+		x2 := float64(i%5) * 0.5
+		fmt.Fprintf(&sb, "%d,%f,%f,%f\n", 1+i%3, x1, 2.0*x1-x2+0.1*float64(i%7), x2)
+	}
+	sb.WriteString("1,NA,1.0,1.0\n")
+
+	params := NewCSVOptParams()
+	params.WeightName = "w"
+	params.Missing = MissingDrop
+	stats, err := ParseCSVStats(strings.NewReader(sb.String()), 2, params)
+	if err != nil {
+		t.Errorf("Unexpected error %v", err)
+		return
+	}
+
+	dset, err := ParseCSVWithParams(strings.NewReader(sb.String()), 2, params)
+	if err != nil {
+		t.Errorf("Unexpected error %v", err)
+		return
+	}
+
+	if stats.NumRows != numRows || stats.TargetCol != 1 || stats.GetFeatName(1) != "x2" {
+		t.Errorf("Expected %d rows, target 1 and second feature x2 got %d, %d and %s", numRows, stats.NumRows, stats.TargetCol, stats.GetFeatName(1))
+	}
+
+	model := []bool{true, true}
+	expect := FitWeighted(dset.X, dset.Y, dset.Weights)
+	if coeff := stats.Fit(model); !floats.EqualApprox(coeff, expect, 1e-8) {
+		t.Errorf("Expected coefficients %v got %v", expect, coeff)
+	}
+
+	params.Missing = MissingMean
+	if _, err := ParseCSVStats(strings.NewReader(sb.String()), 2, params); err == nil {
+		t.Errorf("Expected error for the mean missing value policy")
+	}
+}
+
+func TestSelectModelFromStats(t *testing.T) {
+	X, y, w := statsTestData()
+	stats := NewSufficientStats(4)
+	stats.AddRows(X, y, w)
+
+	var sp SearchProgress
+	highscore := NewHighscore(100)
+	SelectModelFromStats(stats, highscore, &sp, nil)
+
+	Xw, yw := WeightRows(X, y, w)
+	brute := BruteForceSelect(Xw, yw)
+
+	if math.Abs(highscore.BestScore()-brute.BestScore()) > 1e-8 {
+		t.Errorf("BestScore differ. Brute force: %v, BandB: %v", brute.BestScore(), highscore.BestScore())
+	}
+}
+
+func TestLassoCrdDescFromStats(t *testing.T) {
+	X, y := testfeatselect.GetExampleXY()
+	nr, nc := X.Dims()
+	stats := NewSufficientStats(nc)
+	stats.AddRows(X, y, nil)
+
+	data := NewNormalizedData(mat.DenseCopyOf(X), append([]float64{}, y...))
+	statsData := NewNormalizedDataFromStats(stats)
+	if r, c := statsData.Dims(); r != nr || c != nc {
+		t.Errorf("Expected dimensions (%d, %d) got (%d, %d)", nr, nc, r, c)
+	}
+
+	var cov Empirical
+	var correction PureLasso
+	expect := LassoCrdDesc(data, 0.001, &cov, nil, 100000, 1e-10, &correction)
+	res := LassoCrdDesc(statsData, 0.001, &cov, nil, 100000, 1e-10, &correction)
+
+	if !floats.EqualApprox(res, expect, 1e-6) {
+		t.Errorf("Expected\n%v\nGot\n%v\n", expect, res)
+	}
+}
@@ -70,8 +70,16 @@ func Sum(a []int) int {
 // NewLog2Pruned updates the number of pruned solutions.
 // current is log2 of the current number of pruned solutions
 // numPruned is log2 of the new number of pruned solutions
-func NewLog2Pruned(current float64, numPruned int) float64 {
-	diff := float64(numPruned) - current
+// A value of -Inf means that no solutions are pruned
+func NewLog2Pruned(current float64, numPruned float64) float64 {
+	if current < numPruned {
+		current, numPruned = numPruned, current
+	}
+
+	if math.IsInf(numPruned, -1) {
+		return current
+	}
+	diff := numPruned - current
 	return current + math.Log2(1+math.Pow(2, diff))
 }
 
@@ -143,6 +151,35 @@ func Std(v []float64) float64 {
 	return math.Sqrt(sigmaSq / float64(len(v)-1))
 }
 
+// WeightedMean calculates the weighted mean of an array
+func WeightedMean(v []float64, w []float64) float64 {
+	mu := 0.0
+	sumW := 0.0
+	for i := 0; i < len(v); i++ {
+		mu += w[i] * v[i]
+		sumW += w[i]
+	}
+	return mu / sumW
+}
+
+// WeightedStd calculates the weighted standard deviation of an array. The result
+// is the same as Std when all weights are equal
+func WeightedStd(v []float64, w []float64) float64 {
+	if len(v) <= 1 {
+		return 0.0
+	}
+
+	mu := WeightedMean(v, w)
+	sigmaSq := 0.0
+	sumW := 0.0
+	for i := 0; i < len(v); i++ {
+		sigmaSq += w[i] * (v[i] - mu) * (v[i] - mu)
+		sumW += w[i]
+	}
+	n := float64(len(v))
+	return math.Sqrt(sigmaSq / sumW * n / (n - 1.0))
+}
+
 // NormalizeArray normalizes an array to unit variance and zero mean
 func NormalizeArray(v []float64) {
 	mu := Mean(v)
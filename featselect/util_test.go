@@ -451,3 +451,38 @@ func boolArrayEqual(a []bool, b []bool) bool {
 	}
 	return true
 }
+
+func TestWeightedStd(t *testing.T) {
+	v := []float64{1.0, 3.0, 2.0, 8.0}
+	if math.Abs(WeightedStd(v, []float64{2.0, 2.0, 2.0, 2.0})-Std(v)) > 1e-10 {
+		t.Errorf("Equal weights should give the same std as Std")
+	}
+
+	// Integer weights should give the same mean as repeating the values
+	mu := WeightedMean(v, []float64{1.0, 2.0, 0.0, 1.0})
+	if math.Abs(mu-15.0/4.0) > 1e-10 {
+		t.Errorf("Expected weighted mean %f got %f", 15.0/4.0, mu)
+	}
+}
+
+func TestNewLog2Pruned(t *testing.T) {
+	for i, test := range []struct {
+		current, numPruned, want float64
+	}{
+		{current: 0.0, numPruned: 0.0, want: 1.0},
+		{current: 3.0, numPruned: 1.0, want: math.Log2(10.0)},
+		{current: 1.0, numPruned: 3.0, want: math.Log2(10.0)},
+		{current: 50.0, numPruned: 2.0, want: 50.0},
+		{current: 2.0, numPruned: 2000.0, want: 2000.0},
+		{current: math.Inf(-1), numPruned: 4.0, want: 4.0},
+		{current: 4.0, numPruned: math.Inf(-1), want: 4.0},
+	} {
+		if got := NewLog2Pruned(test.current, test.numPruned); math.Abs(got-test.want) > 1e-10 {
+			t.Errorf("Test #%d: Expected %f got %f", i, test.want, got)
+		}
+	}
+
+	if got := NewLog2Pruned(math.Inf(-1), math.Inf(-1)); !math.IsInf(got, -1) {
+		t.Errorf("Expected -Inf when nothing is pruned got %f", got)
+	}
+}